@@ -12,7 +12,13 @@ type Body struct {
 type SectionProperties struct {
 	PageSize        *PageSize
 	PageMargin      *PageMargin
+	PageBorders     *PageBorders    // 页面边框
+	LineNumbering   *LineNumbering  // 行号
+	PageNumberType  *PageNumberType // 页码格式
 	Columns         *Columns
+	VerticalAlign   string // 页面垂直对齐方式：top, center, both, bottom
+	TitlePage       bool   // 首页不同
+	TextDirection   string // 文字方向：lrTb, tbRl, btLr, lrTbV, tbRlV, tbLrV
 	DocGrid         *DocGrid
 	HeaderReference []*HeaderFooterReference
 	FooterReference []*HeaderFooterReference
@@ -36,15 +42,63 @@ type PageMargin struct {
 	Gutter int // 装订线，单位为twip
 }
 
+// PageBorders 表示页面边框
+type PageBorders struct {
+	Display    string  // 应用范围：allPages, firstPage, notFirstPage
+	OffsetFrom string  // 度量基准：text, page
+	ZOrder     string  // 层次：front, back
+	Top        *Border // Border.Style可以是线型(single, double等)或艺术型边框名称(apples, stars等)
+	Left       *Border
+	Bottom     *Border
+	Right      *Border
+}
+
+// LineNumbering 表示行号设置
+type LineNumbering struct {
+	CountBy  int    // 行号间隔
+	Start    int    // 起始编号（从0开始计数，0表示从1开始显示）
+	Distance int    // 行号与正文的距离，单位为twip
+	Restart  string // 重新编号方式：newPage, newSection, continuous
+}
+
+// PageNumberType 表示页码格式
+type PageNumberType struct {
+	Format string // 编号格式：decimal, upperRoman, lowerRoman, chineseCounting等
+	Start  int    // 起始页码，0表示续前节
+}
+
+// 页码编号格式
+const (
+	PageNumberFormatDecimal                 = "decimal"                 // 1, 2, 3
+	PageNumberFormatUpperRoman              = "upperRoman"              // I, II, III
+	PageNumberFormatLowerRoman              = "lowerRoman"              // i, ii, iii
+	PageNumberFormatUpperLetter             = "upperLetter"             // A, B, C
+	PageNumberFormatLowerLetter             = "lowerLetter"             // a, b, c
+	PageNumberFormatChineseCounting         = "chineseCounting"         // 一, 二, 三
+	PageNumberFormatChineseCountingThousand = "chineseCountingThousand" // 一, 二, 三（带千位）
+	PageNumberFormatChineseLegalSimplified  = "chineseLegalSimplified"  // 壹, 贰, 叁
+	PageNumberFormatNumberInDash            = "numberInDash"            // - 1 -, - 2 -
+)
+
 // Columns 表示分栏
 type Columns struct {
-	Num   int // 栏数
-	Space int // 栏间距，单位为twip
+	Num       int       // 栏数
+	Space     int       // 栏间距，单位为twip
+	Separator bool      // 栏间分隔线
+	Cols      []*Column // 各栏宽度，非空时表示不等宽分栏
+}
+
+// Column 表示不等宽分栏中的一栏
+type Column struct {
+	Width int // 栏宽，单位为twip
+	Space int // 与下一栏的间距，单位为twip
 }
 
 // DocGrid 表示文档网格
 type DocGrid struct {
-	LinePitch int // 行距，单位为twip
+	Type      string // 网格类型：default, lines, linesAndChars, snapToChars
+	LinePitch int    // 行距，单位为twip
+	CharSpace int    // 字符间距调整量，用于东亚字符网格
 }
 
 // HeaderFooterReference 表示页眉页脚引用
@@ -130,55 +184,162 @@ func (b *Body) ToXML() string {
 	}
 
 	// 添加节属性
-	xml += "<w:sectPr xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\">"
+	xml += b.SectionProperties.ToXML()
+
+	xml += "</w:body>"
+	return xml
+}
+
+// ToXML 将节属性转换为XML，子元素顺序遵循WordprocessingML规范
+func (s *SectionProperties) ToXML() string {
+	xml := "<w:sectPr xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\">"
 
 	// 页眉引用
-	for _, headerRef := range b.SectionProperties.HeaderReference {
+	for _, headerRef := range s.HeaderReference {
 		xml += fmt.Sprintf("<w:headerReference w:type=\"%s\" r:id=\"%s\" />",
 			headerRef.Type, headerRef.ID)
 	}
 
 	// 页脚引用
-	for _, footerRef := range b.SectionProperties.FooterReference {
+	for _, footerRef := range s.FooterReference {
 		xml += fmt.Sprintf("<w:footerReference w:type=\"%s\" r:id=\"%s\" />",
 			footerRef.Type, footerRef.ID)
 	}
 
 	// 页面大小
-	if b.SectionProperties.PageSize != nil {
+	if s.PageSize != nil {
 		xml += fmt.Sprintf("<w:pgSz w:w=\"%d\" w:h=\"%d\" w:orient=\"%s\" />",
-			b.SectionProperties.PageSize.Width,
-			b.SectionProperties.PageSize.Height,
-			b.SectionProperties.PageSize.Orientation)
+			s.PageSize.Width,
+			s.PageSize.Height,
+			s.PageSize.Orientation)
 	}
 
 	// 页面边距
-	if b.SectionProperties.PageMargin != nil {
+	if s.PageMargin != nil {
 		xml += fmt.Sprintf("<w:pgMar w:top=\"%d\" w:right=\"%d\" w:bottom=\"%d\" w:left=\"%d\" w:header=\"%d\" w:footer=\"%d\" w:gutter=\"%d\" />",
-			b.SectionProperties.PageMargin.Top,
-			b.SectionProperties.PageMargin.Right,
-			b.SectionProperties.PageMargin.Bottom,
-			b.SectionProperties.PageMargin.Left,
-			b.SectionProperties.PageMargin.Header,
-			b.SectionProperties.PageMargin.Footer,
-			b.SectionProperties.PageMargin.Gutter)
+			s.PageMargin.Top,
+			s.PageMargin.Right,
+			s.PageMargin.Bottom,
+			s.PageMargin.Left,
+			s.PageMargin.Header,
+			s.PageMargin.Footer,
+			s.PageMargin.Gutter)
+	}
+
+	// 页面边框
+	if s.PageBorders != nil {
+		xml += "<w:pgBorders"
+		if s.PageBorders.OffsetFrom != "" {
+			xml += fmt.Sprintf(" w:offsetFrom=\"%s\"", s.PageBorders.OffsetFrom)
+		}
+		if s.PageBorders.ZOrder != "" {
+			xml += fmt.Sprintf(" w:zOrder=\"%s\"", s.PageBorders.ZOrder)
+		}
+		if s.PageBorders.Display != "" {
+			xml += fmt.Sprintf(" w:display=\"%s\"", s.PageBorders.Display)
+		}
+		xml += ">"
+		sides := []struct {
+			name   string
+			border *Border
+		}{
+			{"top", s.PageBorders.Top},
+			{"left", s.PageBorders.Left},
+			{"bottom", s.PageBorders.Bottom},
+			{"right", s.PageBorders.Right},
+		}
+		for _, side := range sides {
+			if side.border != nil {
+				xml += fmt.Sprintf("<w:%s w:val=\"%s\" w:sz=\"%d\" w:space=\"%d\" w:color=\"%s\" />",
+					side.name,
+					side.border.Style,
+					side.border.Size,
+					side.border.Space,
+					side.border.Color)
+			}
+		}
+		xml += "</w:pgBorders>"
+	}
+
+	// 行号
+	if s.LineNumbering != nil {
+		xml += "<w:lnNumType"
+		if s.LineNumbering.CountBy > 0 {
+			xml += fmt.Sprintf(" w:countBy=\"%d\"", s.LineNumbering.CountBy)
+		}
+		if s.LineNumbering.Start > 0 {
+			xml += fmt.Sprintf(" w:start=\"%d\"", s.LineNumbering.Start)
+		}
+		if s.LineNumbering.Distance > 0 {
+			xml += fmt.Sprintf(" w:distance=\"%d\"", s.LineNumbering.Distance)
+		}
+		if s.LineNumbering.Restart != "" {
+			xml += fmt.Sprintf(" w:restart=\"%s\"", s.LineNumbering.Restart)
+		}
+		xml += " />"
+	}
+
+	// 页码格式
+	if s.PageNumberType != nil {
+		xml += "<w:pgNumType"
+		if s.PageNumberType.Format != "" {
+			xml += fmt.Sprintf(" w:fmt=\"%s\"", s.PageNumberType.Format)
+		}
+		if s.PageNumberType.Start > 0 {
+			xml += fmt.Sprintf(" w:start=\"%d\"", s.PageNumberType.Start)
+		}
+		xml += " />"
 	}
 
 	// 分栏
-	if b.SectionProperties.Columns != nil {
-		xml += fmt.Sprintf("<w:cols w:num=\"%d\" w:space=\"%d\" />",
-			b.SectionProperties.Columns.Num,
-			b.SectionProperties.Columns.Space)
+	if s.Columns != nil {
+		if len(s.Columns.Cols) > 0 {
+			xml += fmt.Sprintf("<w:cols w:num=\"%d\" w:sep=\"%s\" w:equalWidth=\"0\">",
+				len(s.Columns.Cols),
+				boolToString(s.Columns.Separator))
+			for _, col := range s.Columns.Cols {
+				xml += fmt.Sprintf("<w:col w:w=\"%d\" w:space=\"%d\" />", col.Width, col.Space)
+			}
+			xml += "</w:cols>"
+		} else {
+			xml += fmt.Sprintf("<w:cols w:num=\"%d\" w:space=\"%d\"",
+				s.Columns.Num,
+				s.Columns.Space)
+			if s.Columns.Separator {
+				xml += " w:sep=\"1\""
+			}
+			xml += " />"
+		}
+	}
+
+	// 页面垂直对齐方式
+	if s.VerticalAlign != "" {
+		xml += fmt.Sprintf("<w:vAlign w:val=\"%s\" />", s.VerticalAlign)
+	}
+
+	// 首页不同
+	if s.TitlePage {
+		xml += "<w:titlePg />"
+	}
+
+	// 文字方向
+	if s.TextDirection != "" {
+		xml += fmt.Sprintf("<w:textDirection w:val=\"%s\" />", s.TextDirection)
 	}
 
 	// 文档网格
-	if b.SectionProperties.DocGrid != nil {
-		xml += fmt.Sprintf("<w:docGrid w:linePitch=\"%d\" />",
-			b.SectionProperties.DocGrid.LinePitch)
+	if s.DocGrid != nil {
+		xml += "<w:docGrid"
+		if s.DocGrid.Type != "" {
+			xml += fmt.Sprintf(" w:type=\"%s\"", s.DocGrid.Type)
+		}
+		xml += fmt.Sprintf(" w:linePitch=\"%d\"", s.DocGrid.LinePitch)
+		if s.DocGrid.CharSpace != 0 {
+			xml += fmt.Sprintf(" w:charSpace=\"%d\"", s.DocGrid.CharSpace)
+		}
+		xml += " />"
 	}
 
 	xml += "</w:sectPr>"
-
-	xml += "</w:body>"
 	return xml
 }
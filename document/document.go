@@ -1,26 +1,37 @@
 package document
 
 import (
-	"archive/zip"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
+
+	"github.com/landaiqing/go-dockit/opc"
 )
 
 // Document 表示一个Word文档
 type Document struct {
-	Body          *Body
-	Properties    *DocumentProperties
-	Relationships *Relationships
-	Styles        *Styles
-	Numbering     *Numbering
-	Footers       []*Footer
-	Headers       []*Header
-	Theme         *Theme
-	Settings      *Settings
-	ContentTypes  *ContentTypes
-	Rels          *DocumentRels
+	Body               *Body
+	Properties         *DocumentProperties
+	ExtendedProperties *ExtendedProperties
+	CustomProperties   *CustomProperties
+	Relationships      *Relationships // 额外的包级关系，保存时ID排在基本关系之后
+	Styles             *Styles
+	Numbering          *Numbering
+	Footers            []*Footer
+	Headers            []*Header
+	Theme              *Theme
+	Settings           *Settings
+	ContentTypes       *ContentTypes
+	Rels               *DocumentRels
+	Media              *MediaStore
+	Watermark          *Watermark      // 文档水印，保存时加入每个没有单独设置水印的页眉，见SetWatermark
+	CustomParts        []*opc.Part     // 自定义部件，保存时原样写入包中
+	Strict             bool            // 严格模式，保存前校验文档，见Validate
+	SaveOptions        opc.SaveOptions // 写出选项，如确定性输出和压缩级别
 }
 
 // DocumentProperties 包含文档的元数据
@@ -32,8 +43,8 @@ type DocumentProperties struct {
 	Description    string
 	LastModifiedBy string
 	Revision       int
-	Created        time.Time
-	Modified       time.Time
+	Created        time.Time // 创建时间，零值表示使用保存时的时间
+	Modified       time.Time // 修改时间，零值表示使用保存时的时间
 }
 
 // NewDocument 创建一个新的Word文档
@@ -41,159 +52,150 @@ func NewDocument() *Document {
 	return &Document{
 		Body: NewBody(),
 		Properties: &DocumentProperties{
-			Created:  time.Now(),
-			Modified: time.Now(),
 			Revision: 1,
 		},
-		Relationships: NewRelationships(),
-		Styles:        NewStyles(),
-		Numbering:     NewNumbering(),
-		Footers:       make([]*Footer, 0),
-		Headers:       make([]*Header, 0),
-		Theme:         NewTheme(),
-		Settings:      NewSettings(),
-		ContentTypes:  NewContentTypes(),
-		Rels:          NewDocumentRels(),
+		ExtendedProperties: NewExtendedProperties(),
+		CustomProperties:   NewCustomProperties(),
+		Relationships:      NewRelationships(),
+		Styles:             NewStyles(),
+		Numbering:          NewNumbering(),
+		Footers:            make([]*Footer, 0),
+		Headers:            make([]*Header, 0),
+		Theme:              NewTheme(),
+		Settings:           NewSettings(),
+		ContentTypes:       NewContentTypes(),
+		Rels:               NewDocumentRels(),
+		Media:              NewMediaStore("media"),
 	}
 }
 
 // Save 将文档保存到指定路径
 func (d *Document) Save(path string) error {
-	// 创建一个新的zip文件
-	zipFile, err := os.Create(path)
+	// 先组装包，严格模式下校验失败时不会创建文件
+	pkg, err := d.Package()
 	if err != nil {
 		return err
 	}
-	defer zipFile.Close()
-
-	// 创建一个zip writer
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	return pkg.Save(path)
+}
 
-	// 添加[Content_Types].xml
-	if err := d.addContentTypes(zipWriter); err != nil {
-		return err
+// Bytes 将文档序列化为字节数据
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	// 添加_rels/.rels
-	if err := d.addRels(zipWriter); err != nil {
-		return err
+// WriteTo 将文档写入w，实现io.WriterTo接口
+// 可以直接写入HTTP响应、对象存储上传流或内存缓冲区
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	pkg, err := d.Package()
+	if err != nil {
+		return 0, err
 	}
+	return pkg.WriteTo(w)
+}
 
-	// 添加docProps/app.xml和docProps/core.xml
-	if err := d.addDocProps(zipWriter); err != nil {
-		return err
+// Package 将文档组装为OPC包，可以在写出之前检查或调整包中的部件
+func (d *Document) Package() (*opc.Package, error) {
+	if d.Strict {
+		if err := d.Validate(); err != nil {
+			return nil, err
+		}
 	}
 
-	// 添加word/document.xml
-	if err := d.addDocument(zipWriter); err != nil {
-		return err
+	// 确定性输出时按文档顺序重新编号图形和可编辑区域，组装完成后恢复原来的编号，保存不会修改文档
+	if d.SaveOptions.Deterministic {
+		defer d.renumberIDs()()
 	}
 
-	// 添加word/styles.xml
-	if err := d.addStyles(zipWriter); err != nil {
-		return err
-	}
+	pkg := opc.NewPackage()
+	pkg.ContentTypes = d.ContentTypes.ContentTypes
+	pkg.Options = d.SaveOptions
 
-	// 添加word/numbering.xml
-	if err := d.addNumbering(zipWriter); err != nil {
-		return err
+	// 包级关系
+	pkg.Relationships.AddRelationship("rId1", opc.RelTypeOfficeDocument, "document/document.xml")
+	pkg.Relationships.AddRelationship("rId2", opc.RelTypeCoreProperties, "docProps/core.xml")
+	pkg.Relationships.AddRelationship("rId3", opc.RelTypeExtendedProperties, "docProps/app.xml")
+	if len(d.CustomProperties.Properties) > 0 {
+		pkg.Relationships.Add(opc.RelTypeCustomProperties, "docProps/custom.xml")
 	}
 
-	// 添加word/_rels/document.xml.rels
-	if err := d.addDocumentRels(zipWriter); err != nil {
-		return err
+	// 用户添加的包级关系，ID排在基本关系之后重新分配
+	for _, rel := range d.Relationships.Relationships {
+		pkg.Relationships.Relationships = append(pkg.Relationships.Relationships, &Relationship{
+			ID:         pkg.Relationships.NextID(),
+			Type:       rel.Type,
+			Target:     rel.Target,
+			TargetMode: rel.TargetMode,
+		})
 	}
 
-	// 添加word/theme/theme1.xml
-	if err := d.addTheme(zipWriter); err != nil {
-		return err
+	// 文档属性
+	pkg.AddPart("docProps/app.xml", opc.ContentTypeExtendedProperties, []byte(d.ExtendedProperties.ToXML(d.collectStatistics())))
+	pkg.AddPart("docProps/core.xml", opc.ContentTypeCoreProperties, []byte(d.coreXML(d.SaveOptions.Timestamp())))
+	if len(d.CustomProperties.Properties) > 0 {
+		pkg.AddPart("docProps/custom.xml", opc.ContentTypeCustomProperties, []byte(d.CustomProperties.ToXML()))
 	}
 
-	// 添加word/settings.xml
-	if err := d.addSettings(zipWriter); err != nil {
-		return err
+	// 文档主体及其关系
+	mainPart := pkg.AddPart("document/document.xml", contentTypeDocument, []byte(d.documentXML()))
+	mainPart.Relationships = d.Rels.Relationships
+
+	pkg.AddPart("document/styles.xml", contentTypeStyles, []byte(d.Styles.ToXML()))
+	pkg.AddPart("document/numbering.xml", contentTypeNumbering, []byte(d.Numbering.ToXML()))
+	pkg.AddPart("document/theme/theme1.xml", opc.ContentTypeTheme, []byte(d.Theme.ToXML()))
+	settings := d.Settings
+	if d.SaveOptions.Deterministic {
+		// 确定性输出的文档保护盐值由文档主体和密码生成，只写入本次保存使用的设置副本
+		copied := *settings
+		copied.DocumentProtection = settings.DocumentProtection.deterministic(mainPart.Data)
+		settings = &copied
 	}
+	pkg.AddPart("document/settings.xml", contentTypeSettings, []byte(settings.ToXML()))
+
+	// 水印图片只登记到本次保存使用的媒体集合副本中
+	media := d.Media.Clone()
 
 	// 添加页眉
 	for i, header := range d.Headers {
-		if err := d.addHeader(zipWriter, header, i+1); err != nil {
-			return err
-		}
+		d.addHeader(pkg, header, i+1, media)
 	}
 
 	// 添加页脚
 	for i, footer := range d.Footers {
-		if err := d.addFooter(zipWriter, footer, i+1); err != nil {
-			return err
-		}
+		pkg.AddPart(fmt.Sprintf("document/footer%d.xml", i+1), contentTypeFooter, []byte(footer.ToXML()))
 	}
 
-	// 添加图片
-	for _, rel := range d.Rels.Relationships.GetRelationshipsByType("http://schemas.openxmlformats.org/officeDocument/2006/relationships/image") {
-		if err := d.addImage(zipWriter, rel); err != nil {
-			return err
-		}
+	// 添加媒体文件
+	for _, file := range media.Files {
+		pkg.AddPart("document/"+file.Path, "", file.Data)
 	}
 
-	return nil
-}
-
-// 以下是内部方法，用于将各个部分添加到zip文件中
-func (d *Document) addContentTypes(zipWriter *zip.Writer) error {
-	// 添加[Content_Types].xml
-	w, err := zipWriter.Create("[Content_Types].xml")
-	if err != nil {
-		return err
+	// 添加未通过媒体集合注册的图片
+	for _, rel := range d.Rels.Relationships.GetRelationshipsByType(opc.RelTypeImage) {
+		if media.Get(rel.Target) != nil {
+			continue
+		}
+		imageData := d.findImageData(rel.ID)
+		if len(imageData) == 0 {
+			return nil, fmt.Errorf("未找到图片数据: %s", rel.ID)
+		}
+		pkg.AddPart("document/"+rel.Target, "", imageData)
 	}
 
-	_, err = w.Write([]byte(d.ContentTypes.ToXML()))
-	return err
-}
-
-func (d *Document) addRels(zipWriter *zip.Writer) error {
-	// 添加_rels/.rels
-	w, err := zipWriter.Create("_rels/.rels")
-	if err != nil {
-		return err
+	// 添加自定义部件，与内置部件同名时替换内置部件
+	for _, part := range d.CustomParts {
+		pkg.PutPart(part)
 	}
 
-	// 创建基本关系
-	rels := NewRelationships()
-	rels.AddRelationship("rId1", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument", "document/document.xml")
-	rels.AddRelationship("rId2", "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties", "docProps/core.xml")
-	rels.AddRelationship("rId3", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties", "docProps/app.xml")
-
-	_, err = w.Write([]byte(rels.ToXML()))
-	return err
+	return pkg, nil
 }
 
-func (d *Document) addDocProps(zipWriter *zip.Writer) error {
-	// 添加docProps/app.xml
-	w1, err := zipWriter.Create("docProps/app.xml")
-	if err != nil {
-		return err
-	}
-
-	// 创建app.xml内容
-	appXML := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
-	appXML += "<Properties xmlns=\"http://schemas.openxmlformats.org/officeDocument/2006/extended-properties\" xmlns:vt=\"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes\">\n"
-	appXML += "<Application>go-flowdoc</Application>\n"
-	appXML += "<AppVersion>1.0.0</AppVersion>\n"
-	appXML += "</Properties>\n"
-
-	_, err = w1.Write([]byte(appXML))
-	if err != nil {
-		return err
-	}
-
-	// 添加docProps/core.xml
-	w2, err := zipWriter.Create("docProps/core.xml")
-	if err != nil {
-		return err
-	}
-
-	// 创建core.xml内容
+// coreXML 生成docProps/core.xml的内容，未设置的创建和修改时间使用now
+func (d *Document) coreXML(now time.Time) string {
 	coreXML := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
 	coreXML += "<cp:coreProperties xmlns:cp=\"http://schemas.openxmlformats.org/package/2006/metadata/core-properties\" "
 	coreXML += "xmlns:dc=\"http://purl.org/dc/elements/1.1/\" "
@@ -202,27 +204,27 @@ func (d *Document) addDocProps(zipWriter *zip.Writer) error {
 	coreXML += "xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\">\n"
 
 	if d.Properties.Title != "" {
-		coreXML += "<dc:title>" + d.Properties.Title + "</dc:title>\n"
+		coreXML += "<dc:title>" + escapeXML(d.Properties.Title) + "</dc:title>\n"
 	}
 
 	if d.Properties.Subject != "" {
-		coreXML += "<dc:subject>" + d.Properties.Subject + "</dc:subject>\n"
+		coreXML += "<dc:subject>" + escapeXML(d.Properties.Subject) + "</dc:subject>\n"
 	}
 
 	if d.Properties.Creator != "" {
-		coreXML += "<dc:creator>" + d.Properties.Creator + "</dc:creator>\n"
+		coreXML += "<dc:creator>" + escapeXML(d.Properties.Creator) + "</dc:creator>\n"
 	}
 
 	if d.Properties.Keywords != "" {
-		coreXML += "<cp:keywords>" + d.Properties.Keywords + "</cp:keywords>\n"
+		coreXML += "<cp:keywords>" + escapeXML(d.Properties.Keywords) + "</cp:keywords>\n"
 	}
 
 	if d.Properties.Description != "" {
-		coreXML += "<dc:description>" + d.Properties.Description + "</dc:description>\n"
+		coreXML += "<dc:description>" + escapeXML(d.Properties.Description) + "</dc:description>\n"
 	}
 
 	if d.Properties.LastModifiedBy != "" {
-		coreXML += "<cp:lastModifiedBy>" + d.Properties.LastModifiedBy + "</cp:lastModifiedBy>\n"
+		coreXML += "<cp:lastModifiedBy>" + escapeXML(d.Properties.LastModifiedBy) + "</cp:lastModifiedBy>\n"
 	}
 
 	if d.Properties.Revision > 0 {
@@ -230,26 +232,25 @@ func (d *Document) addDocProps(zipWriter *zip.Writer) error {
 	}
 
 	// 格式化时间
-	createdTime := d.Properties.Created.Format("2006-01-02T15:04:05Z")
-	modifiedTime := d.Properties.Modified.Format("2006-01-02T15:04:05Z")
+	created, modified := d.Properties.Created, d.Properties.Modified
+	if created.IsZero() {
+		created = now
+	}
+	if modified.IsZero() {
+		modified = now
+	}
+	createdTime := created.UTC().Format("2006-01-02T15:04:05Z")
+	modifiedTime := modified.UTC().Format("2006-01-02T15:04:05Z")
 
 	coreXML += "<dcterms:created xsi:type=\"dcterms:W3CDTF\">" + createdTime + "</dcterms:created>\n"
 	coreXML += "<dcterms:modified xsi:type=\"dcterms:W3CDTF\">" + modifiedTime + "</dcterms:modified>\n"
 
 	coreXML += "</cp:coreProperties>\n"
-
-	_, err = w2.Write([]byte(coreXML))
-	return err
+	return coreXML
 }
 
-func (d *Document) addDocument(zipWriter *zip.Writer) error {
-	// 添加word/document.xml
-	w, err := zipWriter.Create("document/document.xml")
-	if err != nil {
-		return err
-	}
-
-	// 创建document.xml内容
+// documentXML 生成document/document.xml的内容
+func (d *Document) documentXML() string {
 	docXML := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
 	docXML += "<w:document xmlns:w=\"http://schemas.openxmlformats.org/wordprocessingml/2006/main\" "
 	docXML += "xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\" "
@@ -259,154 +260,177 @@ func (d *Document) addDocument(zipWriter *zip.Writer) error {
 	docXML += d.Body.ToXML()
 
 	docXML += "</w:document>"
-
-	_, err = w.Write([]byte(docXML))
-	return err
-}
-
-func (d *Document) addStyles(zipWriter *zip.Writer) error {
-	// 添加word/styles.xml
-	w, err := zipWriter.Create("document/styles.xml")
-	if err != nil {
-		return err
+	return docXML
+}
+
+// addHeader 将页眉及其关系添加到包中，页眉本身不会被修改
+// 页眉没有单独设置水印时使用文档水印；图片水印登记到media中，页眉关系使用副本填写图片路径
+func (d *Document) addHeader(pkg *opc.Package, header *Header, index int, media *MediaStore) {
+	rels := header.Relationships
+	watermark, own := header.Watermark, header.Watermark != nil
+	if !own {
+		watermark = d.Watermark
+	}
+	if watermark != nil {
+		h := *header
+		w := *watermark
+		// 每个页眉的水印形状使用不同的ID，避免多个页眉中的形状ID重复
+		w.shapeID = 2048 + index
+		h.Watermark = &w
+		header = &h
+
+		// 各页眉中相同的水印图片只保存一份
+		if w.Type == WatermarkTypeImage {
+			file := media.Add("watermark."+w.ImageFormat, w.ImageData)
+			rels = rels.Clone()
+			if rel := rels.GetRelationshipByID(w.ImageID); own && rel != nil {
+				rel.Target = file.Path
+			} else {
+				w.ImageID = rels.Add(opc.RelTypeImage, file.Path).ID
+			}
+		}
 	}
 
-	_, err = w.Write([]byte(d.Styles.ToXML()))
-	return err
+	part := pkg.AddPart(fmt.Sprintf("document/header%d.xml", index), contentTypeHeader, []byte(header.ToXML()))
+	part.Relationships = rels
 }
 
-func (d *Document) addNumbering(zipWriter *zip.Writer) error {
-	// 添加word/numbering.xml
-	w, err := zipWriter.Create("document/numbering.xml")
-	if err != nil {
-		return err
+// findImageData 根据关系ID在正文、页眉和页脚中查找图片数据
+func (d *Document) findImageData(imageID string) []byte {
+	contents := [][]interface{}{d.Body.Content}
+	for _, header := range d.Headers {
+		contents = append(contents, header.Content)
 	}
-
-	_, err = w.Write([]byte(d.Numbering.ToXML()))
-	return err
-}
-
-func (d *Document) addDocumentRels(zipWriter *zip.Writer) error {
-	// 添加word/_rels/document.xml.rels
-	w, err := zipWriter.Create("document/_rels/document.xml.rels")
-	if err != nil {
-		return err
+	for _, footer := range d.Footers {
+		contents = append(contents, footer.Content)
 	}
 
-	_, err = w.Write([]byte(d.Rels.ToXML()))
-	return err
+	for _, content := range contents {
+		for _, item := range content {
+			if p, ok := item.(*Paragraph); ok {
+				for _, run := range p.Runs {
+					if run.Drawing != nil && run.Drawing.RelID == imageID {
+						return run.Drawing.ImageData
+					}
+				}
+			}
+		}
+	}
+	return nil
 }
 
-func (d *Document) addTheme(zipWriter *zip.Writer) error {
-	// 添加word/theme/theme1.xml
-	w, err := zipWriter.Create("document/theme/theme1.xml")
-	if err != nil {
-		return err
+// AddCustomPart 向文档包中添加一个自定义部件，例如附件或其他应用的数据
+// name为包内路径，如attachments/data.json；contentType为空时按扩展名使用默认类型
+// 如需从文档中引用该部件，可以在Rels或Relationships中添加指向它的关系
+func (d *Document) AddCustomPart(name, contentType string, data []byte) *opc.Part {
+	part := opc.NewPart(name, contentType, data)
+	for i, existing := range d.CustomParts {
+		if existing.Name == part.Name {
+			d.CustomParts[i] = part
+			return part
+		}
 	}
-
-	_, err = w.Write([]byte(d.Theme.ToXML()))
-	return err
+	d.CustomParts = append(d.CustomParts, part)
+	return part
 }
 
-func (d *Document) addSettings(zipWriter *zip.Writer) error {
-	// 添加word/settings.xml
-	w, err := zipWriter.Create("document/settings.xml")
-	if err != nil {
-		return err
-	}
+// AddCustomXML 添加一个自定义XML数据部件（customXml/itemN.xml），并建立从文档主体到它的关系
+func (d *Document) AddCustomXML(data []byte) *opc.Part {
+	index := len(d.Rels.Relationships.GetRelationshipsByType(opc.RelTypeCustomXML)) + 1
+	name := fmt.Sprintf("customXml/item%d.xml", index)
+	part := d.AddCustomPart(name, opc.ContentTypeXML, data)
+	d.Rels.Relationships.Add(opc.RelTypeCustomXML, "../"+name)
+	return part
+}
 
-	_, err = w.Write([]byte(d.Settings.ToXML()))
-	return err
+// SetDeterministic 设置确定性输出，相同的内容总是生成完全相同的文件，便于在版本库中保存和比对
+// 开启后使用固定时间戳（见SetSaveTime），按文档顺序为图形和可编辑区域编号，并按名称排序部件
+// 密码保护的盐值在保存时由文档内容和密码生成，与Protect的调用顺序无关
+func (d *Document) SetDeterministic(deterministic bool) *Document {
+	d.SaveOptions.Deterministic = deterministic
+	return d
 }
 
-func (d *Document) addHeader(zipWriter *zip.Writer, header *Header, index int) error {
-	// 添加word/header{index}.xml
-	headerPath := fmt.Sprintf("document/header%d.xml", index)
-	w, err := zipWriter.Create(headerPath)
-	if err != nil {
-		return err
-	}
+// SetSaveTime 设置保存时使用的时间戳，包括zip条目的修改时间和未设置的文档创建、修改时间
+func (d *Document) SetSaveTime(t time.Time) *Document {
+	d.SaveOptions.ModTime = t
+	return d
+}
 
-	_, err = w.Write([]byte(header.ToXML()))
-	return err
+// SetCompressionLevel 设置压缩级别：opc.CompressionDefault、opc.CompressionNone或1~9
+func (d *Document) SetCompressionLevel(level int) *Document {
+	d.SaveOptions.CompressionLevel = level
+	return d
 }
 
-func (d *Document) addFooter(zipWriter *zip.Writer, footer *Footer, index int) error {
-	// 添加word/footer{index}.xml
-	footerPath := fmt.Sprintf("document/footer%d.xml", index)
-	w, err := zipWriter.Create(footerPath)
-	if err != nil {
-		return err
+// renumberIDs 按正文、页眉、页脚的顺序为图形和可编辑区域重新分配从1开始的编号
+// 返回恢复原来编号的函数
+func (d *Document) renumberIDs() func() {
+	contents := [][]interface{}{d.Body.Content}
+	for _, header := range d.Headers {
+		contents = append(contents, header.Content)
+	}
+	for _, footer := range d.Footers {
+		contents = append(contents, footer.Content)
 	}
 
-	_, err = w.Write([]byte(footer.ToXML()))
-	return err
+	r := &idRenumberer{permissions: make(map[*Permission]bool)}
+	for _, content := range contents {
+		r.renumberContent(content)
+	}
+	return func() {
+		for _, restore := range r.restores {
+			restore()
+		}
+	}
 }
 
-func (d *Document) addImage(zipWriter *zip.Writer, rel *Relationship) error {
-	// 从关系中提取图片ID和路径
-	imageID := rel.ID
-	imagePath := rel.Target
-
-	// 查找对应的Drawing对象
-	var imageData []byte
+// idRenumberer 记录重新编号的进度
+type idRenumberer struct {
+	drawings    int
+	permissions map[*Permission]bool
+	restores    []func() // 恢复原来编号的操作
+}
 
-	// 在文档主体中查找
-	for _, para := range d.Body.Content {
-		if p, ok := para.(*Paragraph); ok {
-			for _, run := range p.Runs {
-				if run.Drawing != nil && run.Drawing.ID == imageID {
-					imageData = run.Drawing.ImageData
-					break
+// renumberContent 递归地为段落和表格中的图形和可编辑区域编号
+func (r *idRenumberer) renumberContent(content []interface{}) {
+	for _, item := range content {
+		switch v := item.(type) {
+		case *Paragraph:
+			r.renumberPermission(v.PermissionStart)
+			r.renumberPermission(v.PermissionEnd)
+			for _, run := range v.Runs {
+				drawing := run.Drawing
+				if drawing == nil {
+					continue
 				}
-			}
-		}
-	}
+				id, relID := drawing.ID, drawing.RelID
+				r.restores = append(r.restores, func() { drawing.ID, drawing.RelID = id, relID })
 
-	// 在页眉中查找
-	if len(imageData) == 0 {
-		for _, header := range d.Headers {
-			for _, content := range header.Content {
-				if p, ok := content.(*Paragraph); ok {
-					for _, run := range p.Runs {
-						if run.Drawing != nil && run.Drawing.ID == imageID {
-							imageData = run.Drawing.ImageData
-							break
-						}
-					}
-				}
+				// 先固定图片的关系ID，避免旧的rId+编号形式的引用随编号改变
+				drawing.RelID = drawing.embedID()
+				r.drawings++
+				drawing.ID = strconv.Itoa(r.drawings)
 			}
-		}
-	}
-
-	// 在页脚中查找
-	if len(imageData) == 0 {
-		for _, footer := range d.Footers {
-			for _, content := range footer.Content {
-				if p, ok := content.(*Paragraph); ok {
-					for _, run := range p.Runs {
-						if run.Drawing != nil && run.Drawing.ID == imageID {
-							imageData = run.Drawing.ImageData
-							break
-						}
-					}
+		case *Table:
+			for _, row := range v.Rows {
+				for _, cell := range row.Cells {
+					r.renumberContent(cell.Content)
 				}
 			}
 		}
 	}
+}
 
-	if len(imageData) == 0 {
-		return fmt.Errorf("未找到图片数据: %s", imageID)
-	}
-
-	// 添加图片文件
-	w, err := zipWriter.Create("document/" + imagePath)
-	if err != nil {
-		return err
+// renumberPermission 为可编辑区域编号，跨段落共享的区域只编号一次
+func (r *idRenumberer) renumberPermission(perm *Permission) {
+	if perm == nil || r.permissions[perm] {
+		return
 	}
-
-	_, err = w.Write(imageData)
-	return err
+	r.permissions[perm] = true
+	id := perm.ID
+	r.restores = append(r.restores, func() { perm.ID = id })
+	perm.ID = strconv.Itoa(len(r.permissions))
 }
 
 // AddParagraph 向文档添加一个段落
@@ -435,7 +459,7 @@ func (d *Document) AddHeader() *Header {
 	d.Headers = append(d.Headers, header)
 
 	// 添加页眉关系
-	headerID := fmt.Sprintf("rId%d", len(d.Rels.Relationships.Relationships)+1)
+	headerID := d.Rels.Relationships.NextID()
 	headerPath := fmt.Sprintf("header%d.xml", len(d.Headers))
 	d.Rels.AddHeader(headerID, headerPath)
 
@@ -451,7 +475,7 @@ func (d *Document) AddFooter() *Footer {
 	d.Footers = append(d.Footers, footer)
 
 	// 添加页脚关系
-	footerID := fmt.Sprintf("rId%d", len(d.Rels.Relationships.Relationships)+1)
+	footerID := d.Rels.Relationships.NextID()
 	footerPath := fmt.Sprintf("footer%d.xml", len(d.Footers))
 	d.Rels.AddFooter(footerID, footerPath)
 
@@ -463,28 +487,27 @@ func (d *Document) AddFooter() *Footer {
 
 // AddImage 向文档添加一个图片
 func (d *Document) AddImage(path string, width, height int) (*Run, error) {
+	// 读取图片数据
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建一个新段落和运行
 	para := d.AddParagraph()
 	run := para.AddRun()
 
 	// 创建图片
 	drawing := NewDrawing()
-	err := drawing.SetImagePath(path)
-	if err != nil {
-		return nil, err
-	}
+	drawing.ImagePath = path
+	drawing.SetImageData(data)
+	drawing.SetName(filepath.Base(path))
 
 	// 设置图片大小
 	drawing.SetSize(width, height)
 
-	// 添加图片关系
-	imageID := fmt.Sprintf("rId%d", len(d.Rels.Relationships.Relationships)+1)
-	imageName := filepath.Base(path)
-	imagePath := fmt.Sprintf("media/%s", imageName)
-	d.Rels.AddImage(imageID, imagePath)
-
-	// 设置图片ID
-	drawing.ID = imageID
+	// 添加图片关系，同名不同内容的图片会分配不同的文件名
+	drawing.RelID = d.addImageRel(filepath.Base(path), data)
 
 	// 添加图片到运行
 	run.AddDrawing(drawing)
@@ -494,6 +517,10 @@ func (d *Document) AddImage(path string, width, height int) (*Run, error) {
 
 // AddImageBytes 通过字节数据添加图片
 func (d *Document) AddImageBytes(data []byte, format, name string, width, height int) (*Run, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("图片数据为空: %s", name)
+	}
+
 	// 创建一个新段落和运行
 	para := d.AddParagraph()
 	run := para.AddRun()
@@ -507,12 +534,7 @@ func (d *Document) AddImageBytes(data []byte, format, name string, width, height
 	drawing.SetSize(width, height)
 
 	// 添加图片关系
-	imageID := fmt.Sprintf("rId%d", len(d.Rels.Relationships.Relationships)+1)
-	imagePath := fmt.Sprintf("media/%s.%s", name, format)
-	d.Rels.AddImage(imageID, imagePath)
-
-	// 设置图片ID
-	drawing.ID = imageID
+	drawing.RelID = d.addImageRel(fmt.Sprintf("%s.%s", name, format), data)
 
 	// 添加图片到运行
 	run.AddDrawing(drawing)
@@ -520,6 +542,13 @@ func (d *Document) AddImageBytes(data []byte, format, name string, width, height
 	return run, nil
 }
 
+// addImageRel 注册图片文件并返回文档到该图片的关系ID
+// 内容相同的图片共享同一个媒体文件和关系
+func (d *Document) addImageRel(name string, data []byte) string {
+	file := d.Media.Add(name, data)
+	return d.Rels.Relationships.Add(opc.RelTypeImage, file.Path).ID
+}
+
 // SetTitle 设置文档标题
 func (d *Document) SetTitle(title string) *Document {
 	d.Properties.Title = title
@@ -626,6 +655,131 @@ func (d *Document) SetColumns(num, space int) *Document {
 	return d
 }
 
+// SetColumnWidths 设置不等宽分栏，widths和spaces为各栏宽度和栏后间距，单位为twip
+func (d *Document) SetColumnWidths(widths, spaces []int, separator bool) *Document {
+	cols := make([]*Column, 0, len(widths))
+	for i, width := range widths {
+		col := &Column{Width: width}
+		if i < len(spaces) {
+			col.Space = spaces[i]
+		}
+		cols = append(cols, col)
+	}
+	d.Body.SectionProperties.Columns.Num = len(cols)
+	d.Body.SectionProperties.Columns.Cols = cols
+	d.Body.SectionProperties.Columns.Separator = separator
+	return d
+}
+
+// SetColumnSeparator 设置栏间是否显示分隔线
+func (d *Document) SetColumnSeparator(separator bool) *Document {
+	d.Body.SectionProperties.Columns.Separator = separator
+	return d
+}
+
+// SetPageBorder 设置页面边框
+// position为top, left, bottom, right或all；style可以是线型(single, double等)或艺术型边框名称(apples等)
+func (d *Document) SetPageBorder(position string, style string, size int, color string, space int) *Document {
+	if style == "" {
+		style = "none"
+	}
+	if color == "" {
+		color = "auto"
+	}
+
+	border := &Border{
+		Style: style,
+		Size:  size,
+		Color: color,
+		Space: space,
+	}
+
+	if d.Body.SectionProperties.PageBorders == nil {
+		d.Body.SectionProperties.PageBorders = &PageBorders{}
+	}
+	pageBorders := d.Body.SectionProperties.PageBorders
+
+	switch position {
+	case "top":
+		pageBorders.Top = border
+	case "left":
+		pageBorders.Left = border
+	case "bottom":
+		pageBorders.Bottom = border
+	case "right":
+		pageBorders.Right = border
+	case "all":
+		pageBorders.Top = border
+		pageBorders.Left = border
+		pageBorders.Bottom = border
+		pageBorders.Right = border
+	}
+
+	return d
+}
+
+// SetPageBorderOptions 设置页面边框的应用范围和度量基准
+// display为allPages, firstPage, notFirstPage；offsetFrom为text或page
+func (d *Document) SetPageBorderOptions(display, offsetFrom string) *Document {
+	if d.Body.SectionProperties.PageBorders == nil {
+		d.Body.SectionProperties.PageBorders = &PageBorders{}
+	}
+	d.Body.SectionProperties.PageBorders.Display = display
+	d.Body.SectionProperties.PageBorders.OffsetFrom = offsetFrom
+	return d
+}
+
+// SetLineNumbering 设置行号
+// restart为newPage, newSection, continuous；distance单位为twip，0表示使用Word默认值
+func (d *Document) SetLineNumbering(countBy, start, distance int, restart string) *Document {
+	d.Body.SectionProperties.LineNumbering = &LineNumbering{
+		CountBy:  countBy,
+		Start:    start,
+		Distance: distance,
+		Restart:  restart,
+	}
+	return d
+}
+
+// SetVerticalAlignment 设置页面垂直对齐方式：top, center, both, bottom
+func (d *Document) SetVerticalAlignment(align string) *Document {
+	d.Body.SectionProperties.VerticalAlign = align
+	return d
+}
+
+// SetPageNumberFormat 设置页码格式和起始页码，start为0表示续前节
+func (d *Document) SetPageNumberFormat(format string, start int) *Document {
+	d.Body.SectionProperties.PageNumberType = &PageNumberType{
+		Format: format,
+		Start:  start,
+	}
+	return d
+}
+
+// SetTitlePage 设置首页不同页眉页脚
+// 类型为first的页眉页脚只有在启用首页不同时才会显示，添加时不会自动启用
+func (d *Document) SetTitlePage(titlePage bool) *Document {
+	d.Body.SectionProperties.TitlePage = titlePage
+	return d
+}
+
+// SetTextDirection 设置节的文字方向：lrTb(横排), tbRl(竖排)等
+func (d *Document) SetTextDirection(direction string) *Document {
+	d.Body.SectionProperties.TextDirection = direction
+	return d
+}
+
+// SetDocGrid 设置文档网格
+// gridType为default, lines, linesAndChars, snapToChars；charSpace用于东亚字符网格的字符间距调整
+func (d *Document) SetDocGrid(gridType string, linePitch, charSpace int) *Document {
+	d.Body.SectionProperties.DocGrid = &DocGrid{
+		Type:      gridType,
+		LinePitch: linePitch,
+		CharSpace: charSpace,
+	}
+	return d
+}
+
 // AddHeaderReference 添加页眉引用
 func (d *Document) AddHeaderReference(headerType, id string) *Document {
 	headerRef := &HeaderFooterReference{
@@ -652,7 +806,7 @@ func (d *Document) AddHeaderWithReference(headerType string) *Header {
 	d.Headers = append(d.Headers, header)
 
 	// 添加页眉关系
-	headerID := fmt.Sprintf("rId%d", len(d.Rels.Relationships.Relationships)+1)
+	headerID := d.Rels.Relationships.NextID()
 	headerPath := fmt.Sprintf("header%d.xml", len(d.Headers))
 	d.Rels.AddHeader(headerID, headerPath)
 
@@ -671,7 +825,7 @@ func (d *Document) AddFooterWithReference(footerType string) *Footer {
 	d.Footers = append(d.Footers, footer)
 
 	// 添加页脚关系
-	footerID := fmt.Sprintf("rId%d", len(d.Rels.Relationships.Relationships)+1)
+	footerID := d.Rels.Relationships.NextID()
 	footerPath := fmt.Sprintf("footer%d.xml", len(d.Footers))
 	d.Rels.AddFooter(footerID, footerPath)
 
@@ -10,7 +10,8 @@ import (
 
 // Drawing 表示Word文档中的图形
 type Drawing struct {
-	ID          string
+	ID          string // 图形在文档中的编号
+	RelID       string // 图片关系ID
 	Name        string
 	Description string
 	ImagePath   string
@@ -143,7 +144,7 @@ func (d *Drawing) ToXML() string {
 
 		// 图片填充
 		xml += "<pic:blipFill>"
-		xml += "<a:blip r:embed=\"rId" + d.ID + "\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\" />"
+		xml += "<a:blip r:embed=\"" + d.embedID() + "\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\" />"
 		xml += "<a:stretch>"
 		xml += "<a:fillRect />"
 		xml += "</a:stretch>"
@@ -249,7 +250,7 @@ func (d *Drawing) ToXML() string {
 
 		// 图片填充
 		xml += "<pic:blipFill>"
-		xml += "<a:blip r:embed=\"rId" + d.ID + "\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\" />"
+		xml += "<a:blip r:embed=\"" + d.embedID() + "\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\" />"
 		xml += "<a:stretch>"
 		xml += "<a:fillRect />"
 		xml += "</a:stretch>"
@@ -277,6 +278,14 @@ func (d *Drawing) ToXML() string {
 	return xml
 }
 
+// embedID 返回图片引用的关系ID
+func (d *Drawing) embedID() string {
+	if d.RelID != "" {
+		return d.RelID
+	}
+	return "rId" + d.ID
+}
+
 // GetImageData 获取图片数据的Base64编码
 func (d *Drawing) GetImageData() string {
 	return base64.StdEncoding.EncodeToString(d.ImageData)
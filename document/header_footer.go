@@ -2,8 +2,10 @@ package document
 
 // Header 表示Word文档中的页眉
 type Header struct {
-	ID      string
-	Content []interface{} // 可以是段落、表格等元素
+	ID            string
+	Content       []interface{} // 可以是段落、表格等元素
+	Watermark     *Watermark    // 水印
+	Relationships *Relationships
 }
 
 // Footer 表示Word文档中的页脚
@@ -15,8 +17,9 @@ type Footer struct {
 // NewHeader 创建一个新的页眉
 func NewHeader() *Header {
 	return &Header{
-		ID:      generateUniqueID(),
-		Content: make([]interface{}, 0),
+		ID:            generateUniqueID(),
+		Content:       make([]interface{}, 0),
+		Relationships: NewRelationships(),
 	}
 }
 
@@ -98,7 +101,13 @@ func (f *Footer) AddPageNumber() *Paragraph {
 // ToXML 将页眉转换为XML
 func (h *Header) ToXML() string {
 	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>"
-	xml += "<w:hdr xmlns:w=\"http://schemas.openxmlformats.org/wordprocessingml/2006/main\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\">"
+	xml += "<w:hdr xmlns:w=\"http://schemas.openxmlformats.org/wordprocessingml/2006/main\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\" "
+	xml += "xmlns:v=\"urn:schemas-microsoft-com:vml\" xmlns:o=\"urn:schemas-microsoft-com:office:office\" xmlns:w10=\"urn:schemas-microsoft-com:office:word\">"
+
+	// 水印放在页眉最前面，位于正文文字下方
+	if h.Watermark != nil {
+		xml += h.Watermark.ToXML()
+	}
 
 	// 添加所有内容元素的XML
 	for _, content := range h.Content {
@@ -0,0 +1,14 @@
+package document
+
+import "github.com/landaiqing/go-dockit/opc"
+
+// MediaFile 表示文档中的一个媒体文件
+type MediaFile = opc.MediaFile
+
+// MediaStore 管理文档中的媒体文件
+type MediaStore = opc.MediaStore
+
+// NewMediaStore 创建一个新的媒体文件集合，dir为相对于document目录的媒体目录
+func NewMediaStore(dir string) *MediaStore {
+	return opc.NewMediaStore(dir)
+}
@@ -4,8 +4,10 @@ import "fmt"
 
 // Paragraph 表示Word文档中的段落
 type Paragraph struct {
-	Runs       []*Run
-	Properties *ParagraphProperties
+	Runs            []*Run
+	Properties      *ParagraphProperties
+	PermissionStart *Permission // 可编辑区域起始
+	PermissionEnd   *Permission // 可编辑区域结束
 }
 
 // ParagraphProperties 表示段落的属性
@@ -314,11 +316,21 @@ func (p *Paragraph) ToXML() string {
 
 	xml += "</w:pPr>"
 
+	// 可编辑区域起始
+	if p.PermissionStart != nil {
+		xml += p.PermissionStart.startXML()
+	}
+
 	// 添加所有Run的XML
 	for _, run := range p.Runs {
 		xml += run.ToXML()
 	}
 
+	// 可编辑区域结束
+	if p.PermissionEnd != nil {
+		xml += p.PermissionEnd.endXML()
+	}
+
 	xml += "</w:p>"
 	return xml
 }
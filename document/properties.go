@@ -0,0 +1,320 @@
+package document
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 自定义属性的值类型
+const (
+	PropertyTypeString = "lpwstr"   // 文本
+	PropertyTypeInt    = "i4"       // 整数
+	PropertyTypeInt64  = "i8"       // 超出i4范围的整数
+	PropertyTypeUint   = "ui4"      // 无符号整数
+	PropertyTypeUint64 = "ui8"      // 超出ui4范围的无符号整数
+	PropertyTypeFloat  = "r8"       // 浮点数
+	PropertyTypeBool   = "bool"     // 是/否
+	PropertyTypeDate   = "filetime" // 日期
+)
+
+// 自定义属性集使用的格式ID，由OPC规范固定
+const customPropertyFmtID = "{D5CDD505-2E9C-101B-9397-08002B2CF9AE}"
+
+// ExtendedProperties 表示文档的扩展属性（docProps/app.xml）
+type ExtendedProperties struct {
+	Application string // 应用程序名称
+	AppVersion  string // 应用程序版本，格式为XX.YYYY
+	Company     string // 公司
+	Manager     string // 经理
+	Template    string // 模板名称
+}
+
+// NewExtendedProperties 创建默认的扩展属性
+func NewExtendedProperties() *ExtendedProperties {
+	return &ExtendedProperties{
+		Application: "go-dockit",
+		AppVersion:  "1.0000",
+		Template:    "Normal.dotm",
+	}
+}
+
+// CustomProperty 表示一个自定义文档属性
+type CustomProperty struct {
+	Name  string
+	Type  string      // 值类型：lpwstr, i4, i8, ui4, ui8, r8, bool, filetime
+	Value interface{} // 值，类型与Type对应
+}
+
+// CustomProperties 表示自定义文档属性集合（docProps/custom.xml）
+type CustomProperties struct {
+	Properties []*CustomProperty
+}
+
+// NewCustomProperties 创建一个新的自定义属性集合
+func NewCustomProperties() *CustomProperties {
+	return &CustomProperties{
+		Properties: make([]*CustomProperty, 0),
+	}
+}
+
+// Set 设置自定义属性，值的类型决定属性类型；同名属性会被覆盖
+// 支持string、整数、浮点数、bool和time.Time；整数优先使用i4，超出范围时使用i8，无符号整数使用ui4或ui8
+func (c *CustomProperties) Set(name string, value interface{}) (*CustomProperty, error) {
+	var propType string
+	switch v := value.(type) {
+	case string:
+		propType = PropertyTypeString
+	case int8, int16, int32, uint8, uint16:
+		propType = PropertyTypeInt
+	case int:
+		propType = intPropertyType(int64(v))
+	case int64:
+		propType = intPropertyType(v)
+	case uint32:
+		propType = PropertyTypeUint
+	case uint:
+		propType = uintPropertyType(uint64(v))
+	case uint64:
+		propType = uintPropertyType(v)
+	case float32, float64:
+		propType = PropertyTypeFloat
+	case bool:
+		propType = PropertyTypeBool
+	case time.Time:
+		propType = PropertyTypeDate
+	default:
+		return nil, fmt.Errorf("不支持的自定义属性类型: %s (%T)", name, value)
+	}
+
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			prop.Type = propType
+			prop.Value = value
+			return prop, nil
+		}
+	}
+
+	prop := &CustomProperty{
+		Name:  name,
+		Type:  propType,
+		Value: value,
+	}
+	c.Properties = append(c.Properties, prop)
+	return prop, nil
+}
+
+// intPropertyType 返回能容纳有符号整数v的属性类型
+func intPropertyType(v int64) string {
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		return PropertyTypeInt64
+	}
+	return PropertyTypeInt
+}
+
+// uintPropertyType 返回能容纳无符号整数v的属性类型
+func uintPropertyType(v uint64) string {
+	if v > math.MaxUint32 {
+		return PropertyTypeUint64
+	}
+	return PropertyTypeUint
+}
+
+// Get 获取指定名称的自定义属性
+func (c *CustomProperties) Get(name string) *CustomProperty {
+	for _, prop := range c.Properties {
+		if prop.Name == name {
+			return prop
+		}
+	}
+	return nil
+}
+
+// Remove 删除指定名称的自定义属性
+func (c *CustomProperties) Remove(name string) {
+	for i, prop := range c.Properties {
+		if prop.Name == name {
+			c.Properties = append(c.Properties[:i], c.Properties[i+1:]...)
+			return
+		}
+	}
+}
+
+// ToXML 将自定义属性集合转换为XML
+func (c *CustomProperties) ToXML() string {
+	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
+	xml += "<Properties xmlns=\"http://schemas.openxmlformats.org/officeDocument/2006/custom-properties\" xmlns:vt=\"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes\">\n"
+
+	// pid从2开始，0和1为保留值
+	for i, prop := range c.Properties {
+		xml += fmt.Sprintf("<property fmtid=\"%s\" pid=\"%d\" name=\"%s\">", customPropertyFmtID, i+2, escapeXML(prop.Name))
+		xml += "<vt:" + prop.Type + ">" + prop.formatValue() + "</vt:" + prop.Type + ">"
+		xml += "</property>\n"
+	}
+
+	xml += "</Properties>\n"
+	return xml
+}
+
+// formatValue 将属性值格式化为XML文本
+func (p *CustomProperty) formatValue() string {
+	switch v := p.Value.(type) {
+	case string:
+		return escapeXML(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format("2006-01-02T15:04:05Z")
+	default:
+		return escapeXML(fmt.Sprintf("%v", v))
+	}
+}
+
+// documentStatistics 表示文档的统计信息
+type documentStatistics struct {
+	Paragraphs           int
+	Words                int
+	Characters           int
+	CharactersWithSpaces int
+}
+
+// collectStatistics 统计文档正文中的段落数、字数和字符数
+func (d *Document) collectStatistics() *documentStatistics {
+	stats := &documentStatistics{}
+	collectContentStatistics(d.Body.Content, stats)
+	return stats
+}
+
+// collectContentStatistics 递归统计段落和表格中的文本
+func collectContentStatistics(content []interface{}, stats *documentStatistics) {
+	for _, item := range content {
+		switch v := item.(type) {
+		case *Paragraph:
+			text := ""
+			for _, run := range v.Runs {
+				text += run.Text
+			}
+			if strings.TrimSpace(text) != "" {
+				stats.Paragraphs++
+			}
+			countText(text, stats)
+		case *Table:
+			for _, row := range v.Rows {
+				for _, cell := range row.Cells {
+					collectContentStatistics(cell.Content, stats)
+				}
+			}
+		}
+	}
+}
+
+// countText 统计文本的字数和字符数，与Word一致，每个东亚字符计为一个字
+func countText(text string, stats *documentStatistics) {
+	inWord := false
+	for _, r := range text {
+		stats.CharactersWithSpaces++
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			stats.Characters++
+			stats.Words++
+			inWord = false
+		default:
+			stats.Characters++
+			if !inWord {
+				stats.Words++
+				inWord = true
+			}
+		}
+	}
+}
+
+// ToXML 将扩展属性转换为XML
+func (e *ExtendedProperties) ToXML(stats *documentStatistics) string {
+	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
+	xml += "<Properties xmlns=\"http://schemas.openxmlformats.org/officeDocument/2006/extended-properties\" xmlns:vt=\"http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes\">\n"
+
+	if e.Template != "" {
+		xml += "<Template>" + escapeXML(e.Template) + "</Template>\n"
+	}
+
+	if stats != nil {
+		xml += fmt.Sprintf("<Words>%d</Words>\n", stats.Words)
+		xml += fmt.Sprintf("<Characters>%d</Characters>\n", stats.Characters)
+	}
+
+	if e.Application != "" {
+		xml += "<Application>" + escapeXML(e.Application) + "</Application>\n"
+	}
+
+	xml += "<DocSecurity>0</DocSecurity>\n"
+
+	if stats != nil {
+		xml += fmt.Sprintf("<Paragraphs>%d</Paragraphs>\n", stats.Paragraphs)
+	}
+
+	xml += "<ScaleCrop>false</ScaleCrop>\n"
+
+	if e.Manager != "" {
+		xml += "<Manager>" + escapeXML(e.Manager) + "</Manager>\n"
+	}
+
+	if e.Company != "" {
+		xml += "<Company>" + escapeXML(e.Company) + "</Company>\n"
+	}
+
+	xml += "<LinksUpToDate>false</LinksUpToDate>\n"
+
+	if stats != nil {
+		xml += fmt.Sprintf("<CharactersWithSpaces>%d</CharactersWithSpaces>\n", stats.CharactersWithSpaces)
+	}
+
+	xml += "<SharedDoc>false</SharedDoc>\n"
+	xml += "<HyperlinksChanged>false</HyperlinksChanged>\n"
+
+	if e.AppVersion != "" {
+		xml += "<AppVersion>" + escapeXML(e.AppVersion) + "</AppVersion>\n"
+	}
+
+	xml += "</Properties>\n"
+	return xml
+}
+
+// SetCustomProperty 设置自定义文档属性，例如合同编号、密级等
+func (d *Document) SetCustomProperty(name string, value interface{}) error {
+	_, err := d.CustomProperties.Set(name, value)
+	return err
+}
+
+// SetApplication 设置生成文档的应用程序名称
+func (d *Document) SetApplication(application string) *Document {
+	d.ExtendedProperties.Application = application
+	return d
+}
+
+// SetCompany 设置文档所属公司
+func (d *Document) SetCompany(company string) *Document {
+	d.ExtendedProperties.Company = company
+	return d
+}
+
+// SetManager 设置文档经理
+func (d *Document) SetManager(manager string) *Document {
+	d.ExtendedProperties.Manager = manager
+	return d
+}
+
+// SetTemplate 设置文档模板名称
+func (d *Document) SetTemplate(template string) *Document {
+	d.ExtendedProperties.Template = template
+	return d
+}
@@ -0,0 +1,244 @@
+package document
+
+import (
+	"encoding/base64"
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/landaiqing/go-dockit/opc"
+)
+
+// 文档保护的编辑限制类型
+const (
+	ProtectionReadOnly       = "readOnly"       // 只读
+	ProtectionComments       = "comments"       // 仅允许批注
+	ProtectionTrackedChanges = "trackedChanges" // 仅允许修订
+	ProtectionForms          = "forms"          // 仅允许填写窗体
+)
+
+// 编辑权限组
+const (
+	EditorGroupEveryone = "everyone" // 所有人
+	EditorGroupEditors  = "editors"  // 编辑者
+	EditorGroupOwners   = "owners"   // 所有者
+	EditorGroupCurrent  = "current"  // 当前用户
+)
+
+// DocumentProtection 表示文档保护设置
+type DocumentProtection struct {
+	Edit        string // 编辑限制：readOnly, comments, trackedChanges, forms
+	Enforcement bool   // 是否强制执行
+	HashValue   string // 密码哈希值，Base64编码
+	SaltValue   string // 盐值，Base64编码
+	SpinCount   int    // 哈希迭代次数
+	password    string // 设置的密码，确定性输出时用于在保存时生成盐值
+}
+
+// NewDocumentProtection 创建一个文档保护设置，password为空表示不设置密码
+func NewDocumentProtection(edit, password string) (*DocumentProtection, error) {
+	var salt []byte
+	if password != "" {
+		var err error
+		if salt, err = opc.NewSalt(); err != nil {
+			return nil, err
+		}
+	}
+	return newDocumentProtection(edit, password, salt), nil
+}
+
+// newDocumentProtection 使用指定的盐值创建文档保护设置
+func newDocumentProtection(edit, password string, salt []byte) *DocumentProtection {
+	protection := &DocumentProtection{
+		Edit:        edit,
+		Enforcement: true,
+	}
+
+	if password != "" {
+		protection.password = password
+		protection.SaltValue = base64.StdEncoding.EncodeToString(salt)
+		protection.SpinCount = opc.DefaultSpinCount
+		protection.HashValue = base64.StdEncoding.EncodeToString(hashWordPassword(password, salt, opc.DefaultSpinCount))
+	}
+
+	return protection
+}
+
+// ToXML 将文档保护设置转换为XML
+func (p *DocumentProtection) ToXML() string {
+	xml := fmt.Sprintf("<w:documentProtection w:edit=\"%s\" w:enforcement=\"%s\"", p.Edit, boolToString(p.Enforcement))
+
+	// 使用SHA-512哈希（cryptAlgorithmSid=14），Word 2010及以上版本可识别
+	if p.HashValue != "" {
+		xml += " w:cryptProviderType=\"rsaAES\" w:cryptAlgorithmClass=\"hash\" w:cryptAlgorithmType=\"typeAny\" w:cryptAlgorithmSid=\"14\""
+		xml += fmt.Sprintf(" w:cryptSpinCount=\"%d\" w:hash=\"%s\" w:salt=\"%s\"", p.SpinCount, p.HashValue, p.SaltValue)
+	}
+
+	xml += " />"
+	return xml
+}
+
+// Protect 设置文档保护，例如以只读方式打开已签署的合同
+// 开启确定性输出时，盐值在保存时由文档内容和密码生成，见SetDeterministic
+func (d *Document) Protect(edit, password string) error {
+	protection, err := NewDocumentProtection(edit, password)
+	if err != nil {
+		return err
+	}
+	d.Settings.DocumentProtection = protection
+	return nil
+}
+
+// deterministic 返回确定性输出时写入的文档保护设置，盐值由密码和文档主体content生成
+// 没有记录密码（例如直接设置的哈希）时返回原设置
+func (p *DocumentProtection) deterministic(content []byte) *DocumentProtection {
+	if p == nil || p.password == "" {
+		return p
+	}
+	protection := newDocumentProtection(p.Edit, p.password, opc.DeterministicSalt(p.password, content))
+	protection.Enforcement = p.Enforcement
+	return protection
+}
+
+// Unprotect 取消文档保护
+func (d *Document) Unprotect() *Document {
+	d.Settings.DocumentProtection = nil
+	return d
+}
+
+// hashWordPassword 按照Word的规则计算密码哈希
+// Word先将密码转换为旧版XOR密钥，再将其十六进制字符串与盐值一起迭代计算SHA-512
+func hashWordPassword(password string, salt []byte, spinCount int) []byte {
+	key := legacyPasswordKey(password)
+
+	// 旧版密钥按字节逆序后转换为大写十六进制字符串
+	keyHex := fmt.Sprintf("%02X%02X%02X%02X", byte(key), byte(key>>8), byte(key>>16), byte(key>>24))
+
+	return opc.HashPassword(keyHex, salt, spinCount)
+}
+
+// 旧版密码密钥的初始值，按密码长度索引
+var legacyInitialCodes = [15]uint16{
+	0xE1F0, 0x1D0F, 0xCC9C, 0x84C0, 0x110C, 0x0E10, 0xF1CE, 0x313E,
+	0x1872, 0xE139, 0xD40F, 0x84F9, 0x280C, 0xA96A, 0x4EC3,
+}
+
+// 旧版密码密钥的加密矩阵
+var legacyEncryptionMatrix = [15][7]uint16{
+	{0xAEFC, 0x4DD9, 0x9BB2, 0x2745, 0x4E8A, 0x9D14, 0x2A09},
+	{0x7B61, 0xF6C2, 0xFDA5, 0xEB6B, 0xC6F7, 0x9DCF, 0x2BBF},
+	{0x4563, 0x8AC6, 0x05AD, 0x0B5A, 0x16B4, 0x2D68, 0x5AD0},
+	{0x0375, 0x06EA, 0x0DD4, 0x1BA8, 0x3750, 0x6EA0, 0xDD40},
+	{0xD849, 0xA0B3, 0x5147, 0xA28E, 0x553D, 0xAA7A, 0x44D5},
+	{0x6F45, 0xDE8A, 0xAD35, 0x4A4B, 0x9496, 0x390D, 0x721A},
+	{0xEB23, 0xC667, 0x9CEF, 0x29FF, 0x53FE, 0xA7FC, 0x5FD9},
+	{0x47D3, 0x8FA6, 0x0F6D, 0x1EDA, 0x3DB4, 0x7B68, 0xF6D0},
+	{0xB861, 0x60E3, 0xC1C6, 0x93AD, 0x377B, 0x6EF6, 0xDDEC},
+	{0x45A0, 0x8B40, 0x06A1, 0x0D42, 0x1A84, 0x3508, 0x6A10},
+	{0xAA51, 0x4483, 0x8906, 0x022D, 0x045A, 0x08B4, 0x1168},
+	{0x76B4, 0xED68, 0xCAF1, 0x85C3, 0x1BA7, 0x374E, 0x6E9C},
+	{0x3730, 0x6E60, 0xDCC0, 0xA9A1, 0x4363, 0x86C6, 0x1DAD},
+	{0x3331, 0x6662, 0xCCC4, 0x89A9, 0x0373, 0x06E6, 0x0DCC},
+	{0x1021, 0x2042, 0x4084, 0x8108, 0x1231, 0x2462, 0x48C4},
+}
+
+// legacyPasswordKey 计算Word旧版的32位XOR密码密钥
+// 高16位由加密矩阵计算，低16位为密码校验值
+func legacyPasswordKey(password string) uint32 {
+	if password == "" {
+		return 0
+	}
+
+	// 密码最多取前15个字符，每个字符取低字节，低字节为0时取高字节
+	units := utf16.Encode([]rune(password))
+	if len(units) > 15 {
+		units = units[:15]
+	}
+	chars := make([]byte, len(units))
+	for i, u := range units {
+		if low := byte(u); low != 0 {
+			chars[i] = low
+		} else {
+			chars[i] = byte(u >> 8)
+		}
+	}
+
+	// 高位字
+	high := legacyInitialCodes[len(chars)-1]
+	for i, c := range chars {
+		row := 15 - len(chars) + i
+		for bit := 0; bit < 7; bit++ {
+			if c&(1<<bit) != 0 {
+				high ^= legacyEncryptionMatrix[row][bit]
+			}
+		}
+	}
+
+	// 低位字
+	var low uint16
+	for i := len(chars) - 1; i >= 0; i-- {
+		low = rotateLeft15(low) ^ uint16(chars[i])
+	}
+	low = rotateLeft15(low) ^ uint16(len(chars))
+	low ^= 0xCE4B
+
+	return uint32(high)<<16 | uint32(low)
+}
+
+// rotateLeft15 在15位范围内循环左移一位
+func rotateLeft15(v uint16) uint16 {
+	return (v>>14)&0x0001 | (v<<1)&0x7FFF
+}
+
+// Permission 表示文档保护状态下的可编辑区域
+type Permission struct {
+	ID          string // 区域ID，在文档内唯一
+	EditorGroup string // 允许编辑的用户组：everyone, editors, owners, current
+	Editor      string // 允许编辑的单个用户，如user@example.com
+}
+
+// NewPermission 创建一个可编辑区域
+func NewPermission(editorGroup, editor string) *Permission {
+	return &Permission{
+		ID:          generateUniqueID(),
+		EditorGroup: editorGroup,
+		Editor:      editor,
+	}
+}
+
+// startXML 返回可编辑区域起始标记
+func (p *Permission) startXML() string {
+	xml := "<w:permStart w:id=\"" + p.ID + "\""
+	if p.EditorGroup != "" {
+		xml += " w:edGrp=\"" + p.EditorGroup + "\""
+	}
+	if p.Editor != "" {
+		xml += " w:ed=\"" + escapeXML(p.Editor) + "\""
+	}
+	xml += " />"
+	return xml
+}
+
+// endXML 返回可编辑区域结束标记
+func (p *Permission) endXML() string {
+	return "<w:permEnd w:id=\"" + p.ID + "\" />"
+}
+
+// SetEditable 将整个段落设置为可编辑区域，editorGroup通常为everyone
+func (p *Paragraph) SetEditable(editorGroup string) *Paragraph {
+	perm := NewPermission(editorGroup, "")
+	p.PermissionStart = perm
+	p.PermissionEnd = perm
+	return p
+}
+
+// StartPermission 在段落开头开始一个可编辑区域，可跨越多个段落
+func (p *Paragraph) StartPermission(perm *Permission) *Paragraph {
+	p.PermissionStart = perm
+	return p
+}
+
+// EndPermission 在段落末尾结束一个可编辑区域
+func (p *Paragraph) EndPermission(perm *Permission) *Paragraph {
+	p.PermissionEnd = perm
+	return p
+}
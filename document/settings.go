@@ -10,6 +10,7 @@ type Settings struct {
 	Zoom                    int    // 缩放比例
 	DefaultTabStop          int    // 默认制表位
 	CharacterSpacingControl string // 字符间距控制
+	DocumentProtection      *DocumentProtection
 	Compatibility           *Compatibility
 }
 
@@ -83,6 +84,12 @@ func (s *Settings) SetCompatibilityMode(compatibilityMode string) *Settings {
 	return s
 }
 
+// SetDocumentProtection 设置文档保护
+func (s *Settings) SetDocumentProtection(protection *DocumentProtection) *Settings {
+	s.DocumentProtection = protection
+	return s
+}
+
 // ToXML 将设置转换为XML
 func (s *Settings) ToXML() string {
 	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>"
@@ -96,6 +103,11 @@ func (s *Settings) ToXML() string {
 	// 缩放比例
 	xml += "<w:zoom w:percent=\"" + fmt.Sprintf("%d", s.Zoom) + "\" />"
 
+	// 文档保护
+	if s.DocumentProtection != nil {
+		xml += s.DocumentProtection.ToXML()
+	}
+
 	// 默认制表位
 	xml += "<w:defaultTabStop w:val=\"" + fmt.Sprintf("%d", s.DefaultTabStop) + "\" />"
 
@@ -2,18 +2,16 @@ package document
 
 import (
 	"fmt"
-	"math/rand"
-	"time"
+	"sync/atomic"
 )
 
-// 初始化随机数生成器
-func init() {
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-}
+// lastID 最近一次分配的ID
+var lastID uint64
 
-// generateUniqueID 生成一个唯一的ID
+// generateUniqueID 生成一个在进程内唯一的ID
+// 使用递增序号而不是随机数，避免同一文档中的ID冲突
 func generateUniqueID() string {
-	return fmt.Sprintf("%d", rand.Intn(1000000))
+	return fmt.Sprintf("%d", atomic.AddUint64(&lastID, 1))
 }
 
 // boolToInt 将布尔值转换为整数
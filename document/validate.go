@@ -0,0 +1,275 @@
+package document
+
+import (
+	"fmt"
+
+	"github.com/landaiqing/go-dockit/opc"
+)
+
+// ValidationError 表示一个校验错误
+type ValidationError = opc.ValidationError
+
+// ValidationErrors 表示一组校验错误
+type ValidationErrors = opc.ValidationErrors
+
+// 校验错误的类别，可以使用errors.Is判断
+var (
+	ErrInvalidValue     = opc.ErrInvalidValue
+	ErrInvalidColor     = opc.ErrInvalidColor
+	ErrDuplicateID      = opc.ErrDuplicateID
+	ErrMissingReference = opc.ErrMissingReference
+)
+
+// 各属性允许的取值
+var (
+	validParagraphAlignments = map[string]bool{
+		"left": true, "center": true, "right": true, "both": true, "start": true, "end": true,
+		"distribute": true, "mediumKashida": true, "highKashida": true, "lowKashida": true,
+		"thaiDistribute": true, "numTab": true,
+	}
+	validTableAlignments    = map[string]bool{"left": true, "center": true, "right": true, "start": true, "end": true}
+	validCellVertAlignments = map[string]bool{"top": true, "center": true, "bottom": true, "both": true}
+	validHighlights         = map[string]bool{
+		"black": true, "blue": true, "cyan": true, "green": true, "magenta": true, "red": true,
+		"yellow": true, "white": true, "darkBlue": true, "darkCyan": true, "darkGreen": true,
+		"darkMagenta": true, "darkRed": true, "darkYellow": true, "darkGray": true, "lightGray": true,
+		"none": true,
+	}
+)
+
+// SetStrict 设置严格模式，严格模式下保存前会先校验文档，校验失败时拒绝写出
+func (d *Document) SetStrict(strict bool) *Document {
+	d.Strict = strict
+	return d
+}
+
+// Validate 校验文档中会导致Word无法打开的错误
+// 没有错误时返回nil，否则返回ValidationErrors，其中每个错误都带有出错位置
+func (d *Document) Validate() error {
+	v := &documentValidator{doc: d}
+
+	v.validateStyles()
+	v.validateNumbering()
+	v.validateContent("body", d.Body.Content)
+	for i, header := range d.Headers {
+		v.validateContent(fmt.Sprintf("headers[%d]", i), header.Content)
+		if header.Watermark != nil {
+			v.checkColor(fmt.Sprintf("headers[%d]/watermark/color", i), header.Watermark.Color)
+		}
+	}
+	if d.Watermark != nil {
+		v.checkColor("watermark/color", d.Watermark.Color)
+	}
+	for i, footer := range d.Footers {
+		v.validateContent(fmt.Sprintf("footers[%d]", i), footer.Content)
+	}
+	v.validateSection("body/sectPr", d.Body.SectionProperties)
+
+	return v.errs.Err()
+}
+
+// documentValidator 遍历文档并收集校验错误
+type documentValidator struct {
+	doc  *Document
+	errs ValidationErrors
+}
+
+// validateStyles 校验样式ID是否重复以及样式中的属性
+func (v *documentValidator) validateStyles() {
+	seen := make(map[string]bool)
+	for i, style := range v.doc.Styles.Styles {
+		path := fmt.Sprintf("styles[%d]", i)
+		if seen[style.ID] {
+			v.errs.Add(path+"/id", style.ID, ErrDuplicateID)
+		}
+		seen[style.ID] = true
+
+		if style.ParagraphProperties != nil {
+			v.validateParagraphProperties(path+"/pPr", style.ParagraphProperties)
+		}
+		if style.RunProperties != nil {
+			v.validateRunProperties(path+"/rPr", style.RunProperties)
+		}
+		if style.TableProperties != nil {
+			v.validateTableProperties(path+"/tblPr", style.TableProperties)
+		}
+	}
+}
+
+// validateNumbering 校验具体编号引用的抽象编号是否存在
+func (v *documentValidator) validateNumbering() {
+	abstractIDs := make(map[int]bool)
+	for _, abstractNum := range v.doc.Numbering.AbstractNums {
+		abstractIDs[abstractNum.ID] = true
+	}
+	for i, num := range v.doc.Numbering.Nums {
+		if !abstractIDs[num.AbstractNumID] {
+			v.errs.Add(fmt.Sprintf("numbering/num[%d]/abstractNumId", i), fmt.Sprintf("%d", num.AbstractNumID), ErrMissingReference)
+		}
+	}
+}
+
+// validateContent 校验段落、表格等内容
+func (v *documentValidator) validateContent(path string, content []interface{}) {
+	for i, item := range content {
+		switch c := item.(type) {
+		case *Paragraph:
+			v.validateParagraph(fmt.Sprintf("%s/p[%d]", path, i), c)
+		case *Table:
+			v.validateTable(fmt.Sprintf("%s/tbl[%d]", path, i), c)
+		}
+	}
+}
+
+// validateParagraph 校验段落及其中的文本运行
+func (v *documentValidator) validateParagraph(path string, p *Paragraph) {
+	v.validateParagraphProperties(path+"/pPr", p.Properties)
+
+	if numID := p.Properties.NumID; numID > 0 && v.findNum(numID) == nil {
+		v.errs.Add(path+"/pPr/numId", fmt.Sprintf("%d", numID), ErrMissingReference)
+	}
+
+	for i, run := range p.Runs {
+		v.validateRunProperties(fmt.Sprintf("%s/r[%d]/rPr", path, i), run.Properties)
+	}
+}
+
+// validateParagraphProperties 校验段落属性
+func (v *documentValidator) validateParagraphProperties(path string, props *ParagraphProperties) {
+	if props.Alignment != "" && !validParagraphAlignments[props.Alignment] {
+		v.errs.Add(path+"/jc", props.Alignment, ErrInvalidValue)
+	}
+	v.checkBorder(path+"/pBdr/top", props.BorderTop)
+	v.checkBorder(path+"/pBdr/bottom", props.BorderBottom)
+	v.checkBorder(path+"/pBdr/left", props.BorderLeft)
+	v.checkBorder(path+"/pBdr/right", props.BorderRight)
+	v.checkShading(path+"/shd", props.Shading)
+}
+
+// validateRunProperties 校验文本运行属性
+func (v *documentValidator) validateRunProperties(path string, props *RunProperties) {
+	if props == nil {
+		return
+	}
+	v.checkColor(path+"/color", props.Color)
+	if props.Highlight != "" && !validHighlights[props.Highlight] {
+		v.errs.Add(path+"/highlight", props.Highlight, ErrInvalidValue)
+	}
+	v.checkShading(path+"/shd", props.Shading)
+}
+
+// validateTable 校验表格及其中的单元格
+func (v *documentValidator) validateTable(path string, t *Table) {
+	v.validateTableProperties(path+"/tblPr", t.Properties)
+
+	for i, row := range t.Rows {
+		for j, cell := range row.Cells {
+			cellPath := fmt.Sprintf("%s/tr[%d]/tc[%d]", path, i, j)
+			if cell.Properties != nil {
+				if align := cell.Properties.VertAlign; align != "" && !validCellVertAlignments[align] {
+					v.errs.Add(cellPath+"/tcPr/vAlign", align, ErrInvalidValue)
+				}
+				v.checkTableBorders(cellPath+"/tcPr/tcBorders", cell.Properties.Borders)
+				v.checkShading(cellPath+"/tcPr/shd", cell.Properties.Shading)
+			}
+			v.validateContent(cellPath, cell.Content)
+		}
+	}
+}
+
+// validateTableProperties 校验表格属性
+func (v *documentValidator) validateTableProperties(path string, props *TableProperties) {
+	if props.Alignment != "" && !validTableAlignments[props.Alignment] {
+		v.errs.Add(path+"/jc", props.Alignment, ErrInvalidValue)
+	}
+	v.checkTableBorders(path+"/tblBorders", props.Borders)
+}
+
+// validateSection 校验节属性，页眉页脚引用必须指向已存在的关系
+func (v *documentValidator) validateSection(path string, s *SectionProperties) {
+	for i, ref := range s.HeaderReference {
+		v.checkReference(fmt.Sprintf("%s/headerReference[%d]", path, i), ref.ID, "http://schemas.openxmlformats.org/officeDocument/2006/relationships/header")
+	}
+	for i, ref := range s.FooterReference {
+		v.checkReference(fmt.Sprintf("%s/footerReference[%d]", path, i), ref.ID, "http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer")
+	}
+
+	if s.PageBorders != nil {
+		v.checkBorder(path+"/pgBorders/top", s.PageBorders.Top)
+		v.checkBorder(path+"/pgBorders/left", s.PageBorders.Left)
+		v.checkBorder(path+"/pgBorders/bottom", s.PageBorders.Bottom)
+		v.checkBorder(path+"/pgBorders/right", s.PageBorders.Right)
+	}
+
+	switch s.VerticalAlign {
+	case "", "top", "center", "both", "bottom":
+	default:
+		v.errs.Add(path+"/vAlign", s.VerticalAlign, ErrInvalidValue)
+	}
+}
+
+// checkReference 检查关系ID是否存在且类型正确
+func (v *documentValidator) checkReference(path, id, relType string) {
+	rel := v.doc.Rels.Relationships.GetRelationshipByID(id)
+	if rel == nil || rel.Type != relType {
+		v.errs.Add(path+"/id", id, ErrMissingReference)
+	}
+}
+
+// checkTableBorders 检查表格边框的颜色
+func (v *documentValidator) checkTableBorders(path string, borders *TableBorders) {
+	if borders == nil {
+		return
+	}
+	v.checkBorder(path+"/top", borders.Top)
+	v.checkBorder(path+"/bottom", borders.Bottom)
+	v.checkBorder(path+"/left", borders.Left)
+	v.checkBorder(path+"/right", borders.Right)
+	v.checkBorder(path+"/insideH", borders.InsideH)
+	v.checkBorder(path+"/insideV", borders.InsideV)
+}
+
+// checkBorder 检查边框的颜色
+func (v *documentValidator) checkBorder(path string, border *Border) {
+	if border != nil {
+		v.checkColor(path+"/color", border.Color)
+	}
+}
+
+// checkShading 检查底纹的颜色
+func (v *documentValidator) checkShading(path string, shading *Shading) {
+	if shading != nil {
+		v.checkColor(path+"/fill", shading.Fill)
+		v.checkColor(path+"/color", shading.Color)
+	}
+}
+
+// checkColor 检查颜色是否为auto或RRGGBB格式，空字符串表示未设置
+func (v *documentValidator) checkColor(path, color string) {
+	if color != "" && color != "auto" && !isHexColor(color) {
+		v.errs.Add(path, color, ErrInvalidColor)
+	}
+}
+
+// findNum 根据ID查找具体编号
+func (v *documentValidator) findNum(id int) *Num {
+	for _, num := range v.doc.Numbering.Nums {
+		if num.ID == id {
+			return num
+		}
+	}
+	return nil
+}
+
+// isHexColor 判断字符串是否为6位十六进制颜色
+func isHexColor(color string) bool {
+	if len(color) != 6 {
+		return false
+	}
+	for _, c := range color {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,267 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"  // 注册GIF解码器，用于读取图片水印尺寸
+	_ "image/jpeg" // 注册JPEG解码器，用于读取图片水印尺寸
+	_ "image/png"  // 注册PNG解码器，用于读取图片水印尺寸
+	"strings"
+	"unicode/utf8"
+)
+
+// 水印类型
+const (
+	WatermarkTypeText  = "text"  // 文字水印
+	WatermarkTypeImage = "image" // 图片水印
+)
+
+// 水印版式
+const (
+	WatermarkLayoutDiagonal   = "diagonal"   // 斜式
+	WatermarkLayoutHorizontal = "horizontal" // 水平
+)
+
+// Watermark 表示页眉中的水印
+type Watermark struct {
+	Type        string  // 水印类型：text, image
+	Text        string  // 水印文字
+	Font        string  // 字体
+	Color       string  // 颜色，格式为RRGGBB
+	Opacity     float64 // 不透明度，取值0~1
+	Layout      string  // 版式：diagonal, horizontal
+	Width       float64 // 宽度，单位为磅
+	Height      float64 // 高度，单位为磅
+	ImageData   []byte  // 图片数据
+	ImageFormat string  // 图片格式：png, jpeg, gif等
+	ImageID     string  // 图片在页眉关系中的ID
+	Washout     bool    // 冲蚀效果
+	shapeID     int     // VML形状ID，保存时按页眉分配，保证文档内唯一
+}
+
+// WatermarkOptions 表示水印选项，零值字段使用默认值
+type WatermarkOptions struct {
+	Font      string  // 字体，默认为Calibri
+	Color     string  // 颜色，格式为RRGGBB，默认为C0C0C0
+	Opacity   float64 // 不透明度，取值0~1，默认为0.5
+	Layout    string  // 版式：diagonal(默认), horizontal
+	Width     float64 // 宽度，单位为磅，默认根据内容计算
+	Height    float64 // 高度，单位为磅，默认根据内容计算
+	KeepColor bool    // 图片水印保留原色，不使用冲蚀效果
+}
+
+// 水印默认宽度，单位为磅（约等于A4/Letter页面的正文宽度）
+const defaultWatermarkWidth = 468.0
+
+// NewTextWatermark 创建一个文字水印
+func NewTextWatermark(text string, opts *WatermarkOptions) *Watermark {
+	if opts == nil {
+		opts = &WatermarkOptions{}
+	}
+
+	w := &Watermark{
+		Type:    WatermarkTypeText,
+		Text:    text,
+		Font:    opts.Font,
+		Color:   opts.Color,
+		Opacity: opts.Opacity,
+		Layout:  opts.Layout,
+		Width:   opts.Width,
+		Height:  opts.Height,
+	}
+
+	if w.Font == "" {
+		w.Font = "Calibri"
+	}
+	if w.Color == "" {
+		w.Color = "C0C0C0"
+	}
+	if w.Opacity <= 0 || w.Opacity > 1 {
+		w.Opacity = 0.5
+	}
+	if w.Layout == "" {
+		w.Layout = WatermarkLayoutDiagonal
+	}
+
+	// 根据文字宽度估算形状大小，全角字符按1个字宽、半角字符按0.6个字宽计算
+	if w.Width <= 0 {
+		w.Width = defaultWatermarkWidth
+	}
+	if w.Height <= 0 {
+		units := 0.0
+		for _, r := range text {
+			if utf8.RuneLen(r) > 1 {
+				units += 1.0
+			} else {
+				units += 0.6
+			}
+		}
+		if units < 2 {
+			units = 2
+		}
+		w.Height = w.Width / units
+	}
+
+	return w
+}
+
+// NewImageWatermark 创建一个图片水印
+func NewImageWatermark(data []byte, format string, opts *WatermarkOptions) (*Watermark, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("水印图片数据为空")
+	}
+	if opts == nil {
+		opts = &WatermarkOptions{}
+	}
+
+	w := &Watermark{
+		Type:        WatermarkTypeImage,
+		ImageData:   data,
+		ImageFormat: strings.ToLower(strings.TrimPrefix(format, ".")),
+		Width:       opts.Width,
+		Height:      opts.Height,
+		Washout:     !opts.KeepColor,
+	}
+	if w.ImageFormat == "jpg" {
+		w.ImageFormat = "jpeg"
+	}
+
+	// 未指定尺寸时按图片原始宽高比缩放到默认宽度
+	if w.Width <= 0 || w.Height <= 0 {
+		width, height := defaultWatermarkWidth, defaultWatermarkWidth/2
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil && cfg.Width > 0 && cfg.Height > 0 {
+			height = width * float64(cfg.Height) / float64(cfg.Width)
+		}
+		switch {
+		case w.Width <= 0 && w.Height <= 0:
+			w.Width, w.Height = width, height
+		case w.Width <= 0:
+			w.Width = w.Height * width / height
+		default:
+			w.Height = w.Width * height / width
+		}
+	}
+
+	return w, nil
+}
+
+// SetWatermark 为文档设置文字水印
+// 水印在保存时放置到每个页眉中，包括之后添加的首页页眉和偶数页页眉；如果文档没有默认页眉，会自动创建并引用一个
+func (d *Document) SetWatermark(text string, opts *WatermarkOptions) *Document {
+	d.ensureDefaultHeader()
+	d.Watermark = NewTextWatermark(text, opts)
+	return d
+}
+
+// SetImageWatermark 为文档设置图片水印
+// format为图片格式，如png、jpeg；图片默认使用冲蚀效果
+func (d *Document) SetImageWatermark(data []byte, format string, opts *WatermarkOptions) error {
+	watermark, err := NewImageWatermark(data, format, opts)
+	if err != nil {
+		return err
+	}
+	d.ensureDefaultHeader()
+	d.Watermark = watermark
+	return nil
+}
+
+// RemoveWatermark 取消文档水印，单独设置在页眉中的水印不受影响
+func (d *Document) RemoveWatermark() *Document {
+	d.Watermark = nil
+	return d
+}
+
+// ensureDefaultHeader 主体节没有默认页眉时创建并引用一个，使水印能够显示
+func (d *Document) ensureDefaultHeader() {
+	for _, ref := range d.Body.SectionProperties.HeaderReference {
+		if ref.Type == "default" {
+			return
+		}
+	}
+	d.AddHeaderWithReference("default")
+}
+
+// SetWatermark 设置页眉的水印
+func (h *Header) SetWatermark(watermark *Watermark) *Header {
+	if watermark.Type == WatermarkTypeImage {
+		// 复用已有的水印图片关系，避免重复设置时产生多余的关系
+		if h.Watermark != nil && h.Watermark.ImageID != "" {
+			watermark.ImageID = h.Watermark.ImageID
+		} else {
+			watermark.ImageID = h.Relationships.NextID()
+			// 图片路径在保存时由文档的媒体集合分配
+			h.Relationships.AddRelationship(watermark.ImageID, "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image", "")
+		}
+	}
+	h.Watermark = watermark
+	return h
+}
+
+// ToXML 将水印转换为XML
+func (w *Watermark) ToXML() string {
+	xml := "<w:p><w:pPr><w:spacing w:after=\"0\" /></w:pPr><w:r><w:rPr><w:noProof /></w:rPr><w:pict>"
+
+	// 形状位置：相对页边距居中，置于文字下方
+	style := fmt.Sprintf("position:absolute;margin-left:0;margin-top:0;width:%.2fpt;height:%.2fpt;", w.Width, w.Height)
+
+	if w.Type == WatermarkTypeImage {
+		xml += "<v:shapetype id=\"_x0000_t75\" coordsize=\"21600,21600\" o:spt=\"75\" o:preferrelative=\"t\" path=\"m@4@5l@4@11@9@11@9@5xe\" filled=\"f\" stroked=\"f\">"
+		xml += "<v:stroke joinstyle=\"miter\" />"
+		xml += "<v:formulas>"
+		xml += "<v:f eqn=\"if lineDrawn pixelLineWidth 0\" /><v:f eqn=\"sum @0 1 0\" /><v:f eqn=\"sum 0 0 @1\" />"
+		xml += "<v:f eqn=\"prod @2 1 2\" /><v:f eqn=\"prod @3 21600 pixelWidth\" /><v:f eqn=\"prod @3 21600 pixelHeight\" />"
+		xml += "<v:f eqn=\"sum @0 0 1\" /><v:f eqn=\"prod @6 1 2\" /><v:f eqn=\"prod @7 21600 pixelWidth\" />"
+		xml += "<v:f eqn=\"sum @8 21600 0\" /><v:f eqn=\"prod @7 21600 pixelHeight\" /><v:f eqn=\"sum @10 21600 0\" />"
+		xml += "</v:formulas>"
+		xml += "<v:path o:extrusionok=\"f\" gradientshapeok=\"t\" o:connecttype=\"rect\" />"
+		xml += "<o:lock v:ext=\"edit\" aspectratio=\"t\" />"
+		xml += "</v:shapetype>"
+
+		style += "z-index:-251656192;mso-position-horizontal:center;mso-position-horizontal-relative:margin;mso-position-vertical:center;mso-position-vertical-relative:margin"
+		xml += "<v:shape id=\"WordPictureWatermark\" o:spid=\"" + w.spid(2050) + "\" type=\"#_x0000_t75\" style=\"" + style + "\" o:allowincell=\"f\">"
+		xml += "<v:imagedata r:id=\"" + w.ImageID + "\" o:title=\"\""
+		if w.Washout {
+			xml += " gain=\"19661f\" blacklevel=\"22938f\""
+		}
+		xml += " />"
+		xml += "<w10:wrap anchorx=\"margin\" anchory=\"margin\" />"
+		xml += "</v:shape>"
+	} else {
+		xml += "<v:shapetype id=\"_x0000_t136\" coordsize=\"21600,21600\" o:spt=\"136\" adj=\"10800\" path=\"m@7,l@8,m@5,21600l@6,21600e\">"
+		xml += "<v:formulas>"
+		xml += "<v:f eqn=\"sum #0 0 10800\" /><v:f eqn=\"prod #0 2 1\" /><v:f eqn=\"sum 21600 0 @1\" />"
+		xml += "<v:f eqn=\"sum 0 0 @2\" /><v:f eqn=\"sum 21600 0 @3\" /><v:f eqn=\"if @0 @3 0\" />"
+		xml += "<v:f eqn=\"if @0 21600 @1\" /><v:f eqn=\"if @0 0 @2\" /><v:f eqn=\"if @0 @4 21600\" />"
+		xml += "<v:f eqn=\"mid @5 @6\" /><v:f eqn=\"mid @8 @5\" /><v:f eqn=\"mid @7 @8\" />"
+		xml += "<v:f eqn=\"mid @6 @7\" /><v:f eqn=\"sum @6 0 @5\" />"
+		xml += "</v:formulas>"
+		xml += "<v:path textpathok=\"t\" o:connecttype=\"custom\" o:connectlocs=\"@9,0;@10,10800;@11,21600;@12,10800\" o:connectangles=\"270,180,90,0\" />"
+		xml += "<v:textpath on=\"t\" fitshape=\"t\" />"
+		xml += "<v:handles><v:h position=\"#0,bottomRight\" xrange=\"6629,14971\" /></v:handles>"
+		xml += "<o:lock v:ext=\"edit\" text=\"t\" shapetype=\"t\" />"
+		xml += "</v:shapetype>"
+
+		if w.Layout != WatermarkLayoutHorizontal {
+			style += "rotation:315;"
+		}
+		style += "z-index:-251657216;mso-position-horizontal:center;mso-position-horizontal-relative:margin;mso-position-vertical:center;mso-position-vertical-relative:margin"
+		xml += "<v:shape id=\"PowerPlusWaterMarkObject\" o:spid=\"" + w.spid(2049) + "\" type=\"#_x0000_t136\" style=\"" + style + "\" o:allowincell=\"f\" fillcolor=\"#" + w.Color + "\" stroked=\"f\">"
+		xml += fmt.Sprintf("<v:fill opacity=\"%.2f\" />", w.Opacity)
+		xml += "<v:textpath style=\"font-family:&quot;" + escapeXML(w.Font) + "&quot;;font-size:1pt\" string=\"" + escapeXML(w.Text) + "\" />"
+		xml += "<w10:wrap anchorx=\"margin\" anchory=\"margin\" />"
+		xml += "</v:shape>"
+	}
+
+	xml += "</w:pict></w:r></w:p>"
+	return xml
+}
+
+// spid 返回水印形状的o:spid，未分配形状ID时使用def
+func (w *Watermark) spid(def int) string {
+	id := w.shapeID
+	if id <= 0 {
+		id = def
+	}
+	return fmt.Sprintf("_x0000_s%d", id)
+}
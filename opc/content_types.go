@@ -0,0 +1,184 @@
+package opc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// 常用的内容类型
+const (
+	ContentTypeRelationships      = "application/vnd.openxmlformats-package.relationships+xml"
+	ContentTypeXML                = "application/xml"
+	ContentTypeCoreProperties     = "application/vnd.openxmlformats-package.core-properties+xml"
+	ContentTypeExtendedProperties = "application/vnd.openxmlformats-officedocument.extended-properties+xml"
+	ContentTypeCustomProperties   = "application/vnd.openxmlformats-officedocument.custom-properties+xml"
+	ContentTypeTheme              = "application/vnd.openxmlformats-officedocument.theme+xml"
+	ContentTypeOctetStream        = "application/octet-stream"
+)
+
+// ContentTypes 表示包中的内容类型集合（[Content_Types].xml）
+type ContentTypes struct {
+	Defaults  []*Default
+	Overrides []*Override
+}
+
+// Default 表示按扩展名匹配的默认内容类型
+type Default struct {
+	Extension   string
+	ContentType string
+}
+
+// Override 表示针对单个部件的覆盖内容类型
+type Override struct {
+	PartName    string
+	ContentType string
+}
+
+// NewContentTypes 创建一个新的内容类型集合，包含关系、XML和常见图片格式的默认类型
+func NewContentTypes() *ContentTypes {
+	ct := &ContentTypes{
+		Defaults:  make([]*Default, 0),
+		Overrides: make([]*Override, 0),
+	}
+
+	ct.AddDefault("xml", ContentTypeXML)
+	ct.AddDefault("rels", ContentTypeRelationships)
+	ct.AddDefault("png", "image/png")
+	ct.AddDefault("jpeg", "image/jpeg")
+	ct.AddDefault("jpg", "image/jpeg")
+	ct.AddDefault("gif", "image/gif")
+	ct.AddDefault("bmp", "image/bmp")
+	ct.AddDefault("tiff", "image/tiff")
+	ct.AddDefault("tif", "image/tiff")
+	ct.AddDefault("wmf", "image/x-wmf")
+	ct.AddDefault("emf", "image/x-emf")
+
+	return ct
+}
+
+// AddDefault 添加一个默认的内容类型，已存在的扩展名会被更新
+func (c *ContentTypes) AddDefault(extension, contentType string) *Default {
+	if def := c.GetDefault(extension); def != nil {
+		def.ContentType = contentType
+		return def
+	}
+	def := &Default{
+		Extension:   extension,
+		ContentType: contentType,
+	}
+	c.Defaults = append(c.Defaults, def)
+	return def
+}
+
+// AddOverride 添加一个覆盖的内容类型，已存在的部件会被更新
+func (c *ContentTypes) AddOverride(partName, contentType string) *Override {
+	if override := c.GetOverride(partName); override != nil {
+		override.ContentType = contentType
+		return override
+	}
+	override := &Override{
+		PartName:    partName,
+		ContentType: contentType,
+	}
+	c.Overrides = append(c.Overrides, override)
+	return override
+}
+
+// GetDefault 根据扩展名获取默认的内容类型，扩展名不区分大小写
+func (c *ContentTypes) GetDefault(extension string) *Default {
+	for _, def := range c.Defaults {
+		if strings.EqualFold(def.Extension, extension) {
+			return def
+		}
+	}
+	return nil
+}
+
+// GetOverride 根据部件名称获取覆盖的内容类型，部件名称不区分大小写
+func (c *ContentTypes) GetOverride(partName string) *Override {
+	for _, override := range c.Overrides {
+		if strings.EqualFold(override.PartName, partName) {
+			return override
+		}
+	}
+	return nil
+}
+
+// ContentType 返回部件的内容类型，先查找覆盖类型，再按扩展名查找默认类型
+func (c *ContentTypes) ContentType(partName string) string {
+	partName = normalizePartName(partName)
+	if override := c.GetOverride("/" + partName); override != nil {
+		return override.ContentType
+	}
+	if def := c.GetDefault(strings.TrimPrefix(path.Ext(partName), ".")); def != nil {
+		return def.ContentType
+	}
+	return ""
+}
+
+// Clone 复制内容类型集合
+func (c *ContentTypes) Clone() *ContentTypes {
+	clone := &ContentTypes{
+		Defaults:  make([]*Default, 0, len(c.Defaults)),
+		Overrides: make([]*Override, 0, len(c.Overrides)),
+	}
+	for _, def := range c.Defaults {
+		clone.Defaults = append(clone.Defaults, &Default{Extension: def.Extension, ContentType: def.ContentType})
+	}
+	for _, override := range c.Overrides {
+		clone.Overrides = append(clone.Overrides, &Override{PartName: override.PartName, ContentType: override.ContentType})
+	}
+	return clone
+}
+
+// ToXML 将内容类型集合转换为XML
+func (c *ContentTypes) ToXML() string {
+	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
+	xml += "<Types xmlns=\"http://schemas.openxmlformats.org/package/2006/content-types\">"
+
+	// 添加所有默认的内容类型
+	for _, def := range c.Defaults {
+		xml += "<Default Extension=\"" + escapeXML(def.Extension) + "\""
+		xml += " ContentType=\"" + escapeXML(def.ContentType) + "\"/>"
+	}
+
+	// 添加所有覆盖的内容类型
+	for _, override := range c.Overrides {
+		xml += "<Override PartName=\"" + escapeXML(override.PartName) + "\""
+		xml += " ContentType=\"" + escapeXML(override.ContentType) + "\"/>"
+	}
+
+	xml += "</Types>"
+	return xml
+}
+
+// ParseContentTypes 解析[Content_Types].xml
+func ParseContentTypes(data []byte) (*ContentTypes, error) {
+	var doc struct {
+		Defaults []struct {
+			Extension   string `xml:"Extension,attr"`
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Default"`
+		Overrides []struct {
+			PartName    string `xml:"PartName,attr"`
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Override"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析内容类型失败: %w", err)
+	}
+
+	ct := &ContentTypes{
+		Defaults:  make([]*Default, 0, len(doc.Defaults)),
+		Overrides: make([]*Override, 0, len(doc.Overrides)),
+	}
+	for _, def := range doc.Defaults {
+		ct.Defaults = append(ct.Defaults, &Default{Extension: def.Extension, ContentType: def.ContentType})
+	}
+	for _, override := range doc.Overrides {
+		ct.Overrides = append(ct.Overrides, &Override{PartName: override.PartName, ContentType: override.ContentType})
+	}
+	return ct, nil
+}
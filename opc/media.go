@@ -0,0 +1,95 @@
+package opc
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MediaFile 表示包中的一个媒体文件
+type MediaFile struct {
+	Name string // 文件名，如image1.png
+	Path string // 相对于主部件目录的路径，如media/image1.png
+	Data []byte
+	hash [sha256.Size]byte
+}
+
+// MediaStore 管理包中的媒体文件
+// 保证文件名唯一，并按内容哈希对相同的图片去重
+type MediaStore struct {
+	Dir    string // 媒体目录，如media
+	Files  []*MediaFile
+	byHash map[[sha256.Size]byte]*MediaFile
+	byPath map[string]*MediaFile
+}
+
+// NewMediaStore 创建一个新的媒体文件集合
+func NewMediaStore(dir string) *MediaStore {
+	return &MediaStore{
+		Dir:    dir,
+		Files:  make([]*MediaFile, 0),
+		byHash: make(map[[sha256.Size]byte]*MediaFile),
+		byPath: make(map[string]*MediaFile),
+	}
+}
+
+// Add 添加媒体文件并返回它
+// 内容相同的文件只保存一次，直接返回已有的文件；文件名冲突时自动追加序号
+func (m *MediaStore) Add(name string, data []byte) *MediaFile {
+	hash := sha256.Sum256(data)
+	if file, ok := m.byHash[hash]; ok {
+		return file
+	}
+
+	file := &MediaFile{
+		Name: m.uniqueName(name),
+		Data: data,
+		hash: hash,
+	}
+	file.Path = path.Join(m.Dir, file.Name)
+
+	m.Files = append(m.Files, file)
+	m.byHash[hash] = file
+	m.byPath[file.Path] = file
+	return file
+}
+
+// Clone 返回媒体文件集合的副本，向副本添加文件不会影响原集合
+// 文件本身在两个集合之间共享
+func (m *MediaStore) Clone() *MediaStore {
+	clone := NewMediaStore(m.Dir)
+	clone.Files = append(clone.Files, m.Files...)
+	for hash, file := range m.byHash {
+		clone.byHash[hash] = file
+	}
+	for p, file := range m.byPath {
+		clone.byPath[p] = file
+	}
+	return clone
+}
+
+// Get 根据路径获取媒体文件
+func (m *MediaStore) Get(filePath string) *MediaFile {
+	return m.byPath[filePath]
+}
+
+// uniqueName 生成一个未被占用的文件名
+func (m *MediaStore) uniqueName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || name == "." || name == "/" {
+		name = "image"
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		base = "image"
+	}
+
+	candidate := base + ext
+	for i := 2; m.byPath[path.Join(m.Dir, candidate)] != nil; i++ {
+		candidate = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+	return candidate
+}
@@ -0,0 +1,39 @@
+package opc
+
+import (
+	"compress/flate"
+	"time"
+)
+
+// 压缩级别
+const (
+	CompressionDefault = 0                     // 默认压缩级别
+	CompressionNone    = -1                    // 不压缩，仅存储
+	CompressionFastest = flate.BestSpeed       // 最快压缩
+	CompressionBest    = flate.BestCompression // 最高压缩率
+)
+
+// DeterministicTime 确定性输出默认使用的时间戳，即zip格式能表示的最早时间
+var DeterministicTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SaveOptions 控制包的写出方式
+type SaveOptions struct {
+	// Deterministic 确定性输出，相同的内容总是生成完全相同的字节
+	// 开启后使用固定时间戳，并按部件名称排序写出部件和内容类型
+	Deterministic bool
+	// ModTime 写出时使用的时间戳，包括zip条目的修改时间；为零值时确定性输出使用DeterministicTime，否则使用当前时间
+	ModTime time.Time
+	// CompressionLevel 压缩级别：CompressionDefault、CompressionNone或1~9
+	CompressionLevel int
+}
+
+// Timestamp 返回写出时使用的时间戳
+func (o SaveOptions) Timestamp() time.Time {
+	if !o.ModTime.IsZero() {
+		return o.ModTime
+	}
+	if o.Deterministic {
+		return DeterministicTime
+	}
+	return time.Now()
+}
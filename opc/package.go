@@ -0,0 +1,337 @@
+package opc
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// contentTypesPartName 内容类型部件在包中的名称
+const contentTypesPartName = "[Content_Types].xml"
+
+// Package 表示一个OPC包，即docx、xlsx等Office文件的容器
+// 包由若干部件、描述部件类型的内容类型集合以及部件之间的关系组成
+type Package struct {
+	ContentTypes  *ContentTypes
+	Relationships *Relationships // 包级关系（_rels/.rels）
+	Parts         []*Part
+	Options       SaveOptions // 写出选项
+}
+
+// NewPackage 创建一个新的空包
+func NewPackage() *Package {
+	return &Package{
+		ContentTypes:  NewContentTypes(),
+		Relationships: NewRelationships(),
+		Parts:         make([]*Part, 0),
+	}
+}
+
+// AddPart 向包中添加一个部件并返回它，同名部件会被替换
+func (p *Package) AddPart(name, contentType string, data []byte) *Part {
+	part := NewPart(name, contentType, data)
+	p.PutPart(part)
+	return part
+}
+
+// PutPart 向包中放入一个已创建的部件，同名部件会被替换
+// 部件名称不区分大小写
+func (p *Package) PutPart(part *Part) {
+	part.Name = normalizePartName(part.Name)
+	for i, existing := range p.Parts {
+		if strings.EqualFold(existing.Name, part.Name) {
+			p.Parts[i] = part
+			return
+		}
+	}
+	p.Parts = append(p.Parts, part)
+}
+
+// GetPart 根据名称获取部件，不存在时返回nil
+func (p *Package) GetPart(name string) *Part {
+	name = normalizePartName(name)
+	for _, part := range p.Parts {
+		if strings.EqualFold(part.Name, name) {
+			return part
+		}
+	}
+	return nil
+}
+
+// RemovePart 从包中删除一个部件
+func (p *Package) RemovePart(name string) {
+	name = normalizePartName(name)
+	for i, part := range p.Parts {
+		if strings.EqualFold(part.Name, name) {
+			p.Parts = append(p.Parts[:i], p.Parts[i+1:]...)
+			return
+		}
+	}
+}
+
+// Save 将包保存到文件
+func (p *Package) Save(filename string) (err error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	_, err = p.WriteTo(file)
+	return err
+}
+
+// WriteTo 将包以zip格式写入w，实现io.WriterTo接口
+// 依次写入[Content_Types].xml、包级关系，然后写入各部件及其关系
+func (p *Package) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	zipWriter := zip.NewWriter(cw)
+
+	// 指定压缩级别时替换默认的Deflate压缩器
+	if level := p.Options.CompressionLevel; level >= flate.BestSpeed && level <= flate.BestCompression {
+		zipWriter.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, level)
+		})
+	}
+
+	if err := p.writeParts(zipWriter); err != nil {
+		return cw.n, err
+	}
+
+	// 关闭zip writer以写入中央目录
+	err := zipWriter.Close()
+	return cw.n, err
+}
+
+// writeParts 将所有部件写入zip
+func (p *Package) writeParts(zipWriter *zip.Writer) error {
+	pw := &partWriter{
+		zipWriter: zipWriter,
+		modTime:   p.Options.Timestamp(),
+		method:    zip.Deflate,
+	}
+	if p.Options.CompressionLevel == CompressionNone {
+		pw.method = zip.Store
+	}
+
+	if err := pw.write(contentTypesPartName, []byte(p.contentTypes().ToXML())); err != nil {
+		return err
+	}
+
+	if len(p.Relationships.Relationships) > 0 {
+		if err := pw.write(RelsPartName(""), []byte(p.Relationships.ToXML())); err != nil {
+			return err
+		}
+	}
+
+	for _, part := range p.orderedParts() {
+		if err := pw.writePart(part); err != nil {
+			return err
+		}
+		if part.Relationships != nil && len(part.Relationships.Relationships) > 0 {
+			if err := pw.write(part.RelsName(), []byte(part.Relationships.ToXML())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// orderedParts 返回写出部件的顺序，确定性输出时按部件名称排序，否则保持添加顺序
+func (p *Package) orderedParts() []*Part {
+	if !p.Options.Deterministic {
+		return p.Parts
+	}
+	parts := make([]*Part, len(p.Parts))
+	copy(parts, p.Parts)
+	sort.SliceStable(parts, func(i, j int) bool {
+		return parts[i].Name < parts[j].Name
+	})
+	return parts
+}
+
+// contentTypes 根据部件生成最终的内容类型集合
+// 部件的内容类型与扩展名的默认类型一致时不需要覆盖，否则添加覆盖类型
+func (p *Package) contentTypes() *ContentTypes {
+	ct := p.ContentTypes.Clone()
+	for _, part := range p.Parts {
+		partName := "/" + part.Name
+		contentType := part.ContentType
+		if contentType == "" {
+			if ct.ContentType(part.Name) != "" {
+				continue
+			}
+			contentType = ContentTypeOctetStream
+		}
+
+		if override := ct.GetOverride(partName); override != nil {
+			override.ContentType = contentType
+			continue
+		}
+		if def := ct.GetDefault(strings.TrimPrefix(path.Ext(part.Name), ".")); def != nil && def.ContentType == contentType {
+			continue
+		}
+		ct.AddOverride(partName, contentType)
+	}
+
+	if p.Options.Deterministic {
+		sort.SliceStable(ct.Defaults, func(i, j int) bool {
+			return ct.Defaults[i].Extension < ct.Defaults[j].Extension
+		})
+		sort.SliceStable(ct.Overrides, func(i, j int) bool {
+			return ct.Overrides[i].PartName < ct.Overrides[j].PartName
+		})
+	}
+	return ct
+}
+
+// partWriter 使用统一的修改时间和压缩方式向zip中写入文件
+type partWriter struct {
+	zipWriter *zip.Writer
+	modTime   time.Time
+	method    uint16
+}
+
+// create 在zip中创建一个文件条目
+func (pw *partWriter) create(name string) (io.Writer, error) {
+	return pw.zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   pw.method,
+		Modified: pw.modTime,
+	})
+}
+
+// write 向zip中写入一个文件
+func (pw *partWriter) write(name string, data []byte) error {
+	w, err := pw.create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// writePart 向zip中写入一个部件，设置了Open的部件从流中复制内容
+func (pw *partWriter) writePart(part *Part) (err error) {
+	if part.Open == nil {
+		return pw.write(part.Name, part.Data)
+	}
+
+	rc, err := part.Open()
+	if err != nil {
+		return fmt.Errorf("打开部件 %s 失败: %w", part.Name, err)
+	}
+	defer func() {
+		if closeErr := rc.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	w, err := pw.create(part.Name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+// Open 从文件中读取一个包
+func Open(filename string) (*Package, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return OpenReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// OpenReader 从r中读取一个包，size为数据的总长度
+func OpenReader(r io.ReaderAt, size int64) (*Package, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(zipReader.File))
+	names := make([]string, 0, len(zipReader.File))
+	for _, file := range zipReader.File {
+		if strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取部件 %s 失败: %w", file.Name, err)
+		}
+		name := normalizePartName(file.Name)
+		files[name] = data
+		names = append(names, name)
+	}
+
+	data, ok := files[contentTypesPartName]
+	if !ok {
+		return nil, fmt.Errorf("包中缺少 %s", contentTypesPartName)
+	}
+	ct, err := ParseContentTypes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &Package{
+		ContentTypes:  ct,
+		Relationships: NewRelationships(),
+		Parts:         make([]*Part, 0, len(names)),
+	}
+
+	// 先读取普通部件，再将关系部件关联到对应的源部件
+	relsNames := make([]string, 0)
+	for _, name := range names {
+		if name == contentTypesPartName {
+			continue
+		}
+		if path.Ext(name) == ".rels" && path.Base(path.Dir(name)) == "_rels" {
+			relsNames = append(relsNames, name)
+			continue
+		}
+		part := NewPart(name, ct.ContentType(name), files[name])
+		pkg.Parts = append(pkg.Parts, part)
+	}
+
+	for _, name := range relsNames {
+		rels, err := ParseRelationships(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		source := sourcePartName(name)
+		if source == "" {
+			pkg.Relationships = rels
+			continue
+		}
+		if part := pkg.GetPart(source); part != nil {
+			part.Relationships = rels
+		}
+	}
+
+	return pkg, nil
+}
+
+// readZipFile 读取zip中一个文件的全部内容
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,55 @@
+package opc
+
+import (
+	"io"
+	"path"
+	"strings"
+)
+
+// Part 表示包中的一个部件
+type Part struct {
+	Name          string // 包内路径，不带前导斜杠，如word/document.xml
+	ContentType   string // 内容类型，为空时按扩展名使用默认类型
+	Data          []byte
+	Relationships *Relationships // 以该部件为源的关系
+
+	// Open 非空时写出部件内容改为从它返回的流中复制，Data被忽略
+	// 用于体积很大、不适合整体放在内存中的部件，例如流式写入的工作表
+	Open func() (io.ReadCloser, error)
+}
+
+// NewPart 创建一个新的部件
+func NewPart(name, contentType string, data []byte) *Part {
+	return &Part{
+		Name:          normalizePartName(name),
+		ContentType:   contentType,
+		Data:          data,
+		Relationships: NewRelationships(),
+	}
+}
+
+// RelsName 返回该部件的关系部件名称
+func (p *Part) RelsName() string {
+	return RelsPartName(p.Name)
+}
+
+// ResolveTarget 将该部件关系中的相对目标解析为包内的部件名称
+func (p *Part) ResolveTarget(target string) string {
+	return ResolveTarget(p.Name, target)
+}
+
+// ResolveTarget 将源部件关系中的目标解析为包内的部件名称
+// source为空表示包级关系；以/开头的目标为相对于包根目录的绝对路径
+func ResolveTarget(source, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return normalizePartName(target)
+	}
+	return normalizePartName(path.Join(path.Dir(normalizePartName(source)), target))
+}
+
+// normalizePartName 规范化部件名称：使用正斜杠并去掉前导斜杠
+func normalizePartName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	return name
+}
@@ -0,0 +1,85 @@
+package opc
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// DefaultSpinCount 密码哈希的默认迭代次数，与Office保持一致
+const DefaultSpinCount = 100000
+
+// PasswordHash 表示加盐迭代的SHA-512密码哈希，用于工作表、工作簿等保护设置
+type PasswordHash struct {
+	AlgorithmName string // 哈希算法名称，固定为SHA-512
+	HashValue     string // 哈希值，Base64编码
+	SaltValue     string // 盐值，Base64编码
+	SpinCount     int    // 哈希迭代次数
+}
+
+// NewPasswordHash 使用随机盐值计算密码的哈希
+func NewPasswordHash(password string) (*PasswordHash, error) {
+	salt, err := NewSalt()
+	if err != nil {
+		return nil, err
+	}
+	return NewPasswordHashWithSalt(password, salt), nil
+}
+
+// NewPasswordHashWithSalt 使用指定的盐值计算密码的哈希
+func NewPasswordHashWithSalt(password string, salt []byte) *PasswordHash {
+	return &PasswordHash{
+		AlgorithmName: "SHA-512",
+		HashValue:     base64.StdEncoding.EncodeToString(HashPassword(password, salt, DefaultSpinCount)),
+		SaltValue:     base64.StdEncoding.EncodeToString(salt),
+		SpinCount:     DefaultSpinCount,
+	}
+}
+
+// NewSalt 生成16字节的随机盐值
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("生成密码盐值失败: %w", err)
+	}
+	return salt, nil
+}
+
+// DeterministicSalt 根据密码和受保护的内容生成16字节盐值，用于确定性输出
+// 相同的内容和密码总是得到相同的盐值，内容不同的文档即使密码相同，盐值和哈希也不同
+func DeterministicSalt(password string, content ...[]byte) []byte {
+	h := sha512.New()
+	h.Write([]byte("go-dockit/salt"))
+	for _, data := range append([][]byte{[]byte(password)}, content...) {
+		h.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(data))))
+		h.Write(data)
+	}
+	return h.Sum(nil)[:16]
+}
+
+// HashPassword 计算加盐迭代的SHA-512密码哈希
+// H0 = SHA512(salt + UTF-16LE(password))，Hn = SHA512(Hn-1 + 迭代序号)
+func HashPassword(password string, salt []byte, spinCount int) []byte {
+	units := utf16.Encode([]rune(password))
+	data := make([]byte, 0, len(salt)+len(units)*2)
+	data = append(data, salt...)
+	for _, u := range units {
+		data = binary.LittleEndian.AppendUint16(data, u)
+	}
+
+	sum := sha512.Sum512(data)
+	hash := sum[:]
+
+	buf := make([]byte, len(hash)+4)
+	for i := 0; i < spinCount; i++ {
+		copy(buf, hash)
+		binary.LittleEndian.PutUint32(buf[len(hash):], uint32(i))
+		sum = sha512.Sum512(buf)
+		hash = sum[:]
+	}
+
+	return hash
+}
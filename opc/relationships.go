@@ -0,0 +1,196 @@
+package opc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// 常用的关系类型
+const (
+	RelTypeOfficeDocument     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument"
+	RelTypeCoreProperties     = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	RelTypeExtendedProperties = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"
+	RelTypeCustomProperties   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties"
+	RelTypeCustomXML          = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/customXml"
+	RelTypeImage              = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	RelTypeHyperlink          = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+	RelTypeStyles             = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles"
+	RelTypeTheme              = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme"
+	RelTypePackage            = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/package"
+)
+
+// Relationships 表示一个部件（或整个包）的关系集合
+type Relationships struct {
+	Relationships []*Relationship
+}
+
+// Relationship 表示一个关系
+type Relationship struct {
+	ID         string
+	Type       string
+	Target     string
+	TargetMode string // 目标模式：Internal, External
+}
+
+// NewRelationships 创建一个新的关系集合
+func NewRelationships() *Relationships {
+	return &Relationships{
+		Relationships: make([]*Relationship, 0),
+	}
+}
+
+// AddRelationship 添加一个关系
+func (r *Relationships) AddRelationship(id, relType, target string) *Relationship {
+	rel := &Relationship{
+		ID:     id,
+		Type:   relType,
+		Target: target,
+	}
+	r.Relationships = append(r.Relationships, rel)
+	return rel
+}
+
+// NextID 分配一个在当前部件中未被使用的关系ID
+// 新ID为现有最大rId序号加1，即使关系被手动添加或删除也不会重复
+func (r *Relationships) NextID() string {
+	max := 0
+	for _, rel := range r.Relationships {
+		if n, err := strconv.Atoi(strings.TrimPrefix(rel.ID, "rId")); err == nil && n > max {
+			max = n
+		}
+	}
+	return fmt.Sprintf("rId%d", max+1)
+}
+
+// Add 使用自动分配的ID添加一个关系
+// 如果已经存在相同类型和目标的内部关系，则直接返回该关系
+func (r *Relationships) Add(relType, target string) *Relationship {
+	if rel := r.GetRelationshipByTarget(relType, target); rel != nil && rel.TargetMode == "" {
+		return rel
+	}
+	return r.AddRelationship(r.NextID(), relType, target)
+}
+
+// AddExternal 使用自动分配的ID添加一个外部关系
+func (r *Relationships) AddExternal(relType, target string) *Relationship {
+	return r.AddExternalRelationship(r.NextID(), relType, target)
+}
+
+// AddExternalRelationship 添加一个外部关系
+func (r *Relationships) AddExternalRelationship(id, relType, target string) *Relationship {
+	rel := &Relationship{
+		ID:         id,
+		Type:       relType,
+		Target:     target,
+		TargetMode: "External",
+	}
+	r.Relationships = append(r.Relationships, rel)
+	return rel
+}
+
+// Clone 返回关系集合的副本，修改副本中的关系不会影响原集合
+func (r *Relationships) Clone() *Relationships {
+	clone := &Relationships{Relationships: make([]*Relationship, 0, len(r.Relationships))}
+	for _, rel := range r.Relationships {
+		copied := *rel
+		clone.Relationships = append(clone.Relationships, &copied)
+	}
+	return clone
+}
+
+// GetRelationshipByID 根据ID获取关系
+func (r *Relationships) GetRelationshipByID(id string) *Relationship {
+	for _, rel := range r.Relationships {
+		if rel.ID == id {
+			return rel
+		}
+	}
+	return nil
+}
+
+// GetRelationshipByTarget 根据类型和目标获取关系
+func (r *Relationships) GetRelationshipByTarget(relType, target string) *Relationship {
+	for _, rel := range r.Relationships {
+		if rel.Type == relType && rel.Target == target {
+			return rel
+		}
+	}
+	return nil
+}
+
+// GetRelationshipsByType 根据类型获取关系
+func (r *Relationships) GetRelationshipsByType(relType string) []*Relationship {
+	result := make([]*Relationship, 0)
+	for _, rel := range r.Relationships {
+		if rel.Type == relType {
+			result = append(result, rel)
+		}
+	}
+	return result
+}
+
+// ToXML 将关系集合转换为XML
+func (r *Relationships) ToXML() string {
+	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
+	xml += "<Relationships xmlns=\"http://schemas.openxmlformats.org/package/2006/relationships\">"
+
+	for _, rel := range r.Relationships {
+		xml += "<Relationship Id=\"" + escapeXML(rel.ID) + "\""
+		xml += " Type=\"" + escapeXML(rel.Type) + "\""
+		xml += " Target=\"" + escapeXML(rel.Target) + "\""
+		if rel.TargetMode != "" {
+			xml += " TargetMode=\"" + rel.TargetMode + "\""
+		}
+		xml += "/>"
+	}
+
+	xml += "</Relationships>"
+	return xml
+}
+
+// ParseRelationships 解析关系部件的XML
+func ParseRelationships(data []byte) (*Relationships, error) {
+	var doc struct {
+		Relationships []struct {
+			ID         string `xml:"Id,attr"`
+			Type       string `xml:"Type,attr"`
+			Target     string `xml:"Target,attr"`
+			TargetMode string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析关系失败: %w", err)
+	}
+
+	rels := NewRelationships()
+	for _, rel := range doc.Relationships {
+		rels.Relationships = append(rels.Relationships, &Relationship{
+			ID:         rel.ID,
+			Type:       rel.Type,
+			Target:     rel.Target,
+			TargetMode: rel.TargetMode,
+		})
+	}
+	return rels, nil
+}
+
+// RelsPartName 返回部件对应的关系部件名称
+// 例如word/document.xml的关系部件为word/_rels/document.xml.rels，包级关系为_rels/.rels
+func RelsPartName(partName string) string {
+	partName = normalizePartName(partName)
+	if partName == "" {
+		return "_rels/.rels"
+	}
+	dir, file := path.Split(partName)
+	return dir + "_rels/" + file + ".rels"
+}
+
+// sourcePartName 根据关系部件名称返回其所属的部件名称，包级关系返回空字符串
+func sourcePartName(relsName string) string {
+	dir, file := path.Split(relsName)
+	dir = strings.TrimSuffix(strings.TrimSuffix(dir, "/"), "_rels")
+	return dir + strings.TrimSuffix(file, ".rels")
+}
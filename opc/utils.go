@@ -0,0 +1,29 @@
+package opc
+
+import (
+	"io"
+	"strings"
+)
+
+// escapeXML 转义XML属性和文本中的特殊字符
+func escapeXML(s string) string {
+	s = strings.ReplaceAll(s, "&", "&amp;")
+	s = strings.ReplaceAll(s, "<", "&lt;")
+	s = strings.ReplaceAll(s, ">", "&gt;")
+	s = strings.ReplaceAll(s, "\"", "&quot;")
+	s = strings.ReplaceAll(s, "'", "&apos;")
+	return s
+}
+
+// countingWriter 记录写入字节数的io.Writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write 实现io.Writer接口
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,67 @@
+package opc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 校验错误的类别，可以使用errors.Is判断
+var (
+	ErrInvalidValue     = errors.New("无效的取值")
+	ErrInvalidColor     = errors.New("无效的颜色")
+	ErrDuplicateID      = errors.New("重复的ID")
+	ErrMissingReference = errors.New("引用的对象不存在")
+	ErrInvalidName      = errors.New("无效的名称")
+)
+
+// ValidationError 表示一个校验错误
+type ValidationError struct {
+	Path  string // 出错的位置，如body/p[3]/r[1]/color
+	Value string // 出错的值
+	Err   error  // 错误类别，如ErrInvalidColor
+}
+
+// Error 实现error接口
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %v %q", e.Path, e.Err, e.Value)
+}
+
+// Unwrap 返回错误类别
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors 表示一组校验错误
+type ValidationErrors []*ValidationError
+
+// Add 添加一个校验错误
+func (e *ValidationErrors) Add(path, value string, err error) {
+	*e = append(*e, &ValidationError{Path: path, Value: value, Err: err})
+}
+
+// Err 没有错误时返回nil，否则返回错误集合本身
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error 实现error接口
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("校验失败，共%d个错误: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Unwrap 返回所有校验错误，使errors.Is和errors.As可以匹配其中任意一个
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
+}
@@ -0,0 +1,578 @@
+package workbook
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCircularReference 公式之间存在循环引用
+var ErrCircularReference = errors.New("循环引用")
+
+// 单元格的计算状态
+const (
+	calcPending = iota
+	calcRunning
+	calcDone
+)
+
+// cellKey 标识工作簿中的一个单元格
+type cellKey struct {
+	ws       *Worksheet
+	row, col int
+}
+
+// calcEngine 计算工作簿中的公式，每个公式单元格只计算一次
+type calcEngine struct {
+	wb       *Workbook
+	state    map[cellKey]int
+	nodes    map[string]formulaNode
+	now      time.Time
+	circular []string
+	errs     []error
+	names    map[*DefinedName]bool // 正在计算的名称，用于发现循环定义
+}
+
+// newCalcEngine 创建一个新的计算引擎
+// 确定性输出时NOW和TODAY使用保存时间戳（见SetSaveTime），使缓存值不随计算时间变化
+func newCalcEngine(wb *Workbook) *calcEngine {
+	now := time.Now()
+	if wb.SaveOptions.Deterministic {
+		now = wb.SaveOptions.Timestamp()
+	}
+	return &calcEngine{
+		wb:    wb,
+		state: make(map[cellKey]int),
+		nodes: make(map[string]formulaNode),
+		names: make(map[*DefinedName]bool),
+		now:   now,
+	}
+}
+
+// Recalculate 计算工作簿中所有公式，并将结果写入单元格作为缓存值
+// 不会重新计算的查看器会直接显示这些缓存值
+// 存在循环引用时，循环中的单元格结果为0，返回的错误可以用errors.Is(err, ErrCircularReference)判断
+func (wb *Workbook) Recalculate() error {
+	e := newCalcEngine(wb)
+	for _, ws := range wb.Worksheets {
+		for _, row := range ws.Rows {
+			for _, cell := range row.Cells {
+				if cell.Formula != "" {
+					e.evalCell(ws, row.Index-1, cell)
+				}
+			}
+		}
+	}
+	return e.err()
+}
+
+// CalcCellValue 计算指定单元格的值，公式单元格会按需计算它引用的单元格
+// 返回值为float64、string、bool、FormulaError或nil（空单元格）
+func (wb *Workbook) CalcCellValue(sheetName, cellRef string) (interface{}, error) {
+	ws := wb.GetWorksheet(sheetName)
+	if ws == nil {
+		return nil, fmt.Errorf("工作表 %s 不存在", sheetName)
+	}
+	row, col, err := ParseCellRef(cellRef)
+	if err != nil {
+		return nil, err
+	}
+
+	e := newCalcEngine(wb)
+	value := e.cellValue(ws, row, col)
+	return value, e.err()
+}
+
+// GetWorksheet 根据名称获取工作表，名称不区分大小写，不存在时返回nil
+func (wb *Workbook) GetWorksheet(name string) *Worksheet {
+	for _, ws := range wb.Worksheets {
+		if strings.EqualFold(ws.Name, name) {
+			return ws
+		}
+	}
+	return nil
+}
+
+// err 汇总计算过程中的错误
+func (e *calcEngine) err() error {
+	errs := e.errs
+	if len(e.circular) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrCircularReference, strings.Join(e.circular, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// cellValue 返回单元格的值，公式单元格先计算
+func (e *calcEngine) cellValue(ws *Worksheet, row, col int) interface{} {
+	cell := ws.GetCell(row, col)
+	if cell == nil {
+		return nil
+	}
+	if cell.Formula != "" {
+		return e.evalCell(ws, row, cell)
+	}
+	return cellScalar(cell)
+}
+
+// evalCell 计算公式单元格并缓存结果
+func (e *calcEngine) evalCell(ws *Worksheet, row int, cell *Cell) interface{} {
+	key := cellKey{ws: ws, row: row, col: cell.Col}
+	switch e.state[key] {
+	case calcDone:
+		return cellScalar(cell)
+	case calcRunning:
+		// 循环引用，记录后按0计算，与Excel一致
+		e.circular = append(e.circular, ws.Name+"!"+CellRef(row, cell.Col))
+		return float64(0)
+	}
+	e.state[key] = calcRunning
+
+	var result interface{}
+	node, err := e.parse(cell.Formula)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s!%s: %w", ws.Name, CellRef(row, cell.Col), err))
+		result = FormulaErrorName
+	} else {
+		result = e.scalar(e.eval(node, ws))
+	}
+
+	setCachedValue(cell, result)
+	e.state[key] = calcDone
+	return result
+}
+
+// parse 解析公式，相同的公式只解析一次
+func (e *calcEngine) parse(formula string) (formulaNode, error) {
+	if node, ok := e.nodes[formula]; ok {
+		return node, nil
+	}
+	node, err := parseFormula(formula)
+	if err != nil {
+		return nil, err
+	}
+	e.nodes[formula] = node
+	return node, nil
+}
+
+// setCachedValue 将公式结果写入单元格
+func setCachedValue(cell *Cell, value interface{}) {
+	cell.Value = value
+	switch value.(type) {
+	case float64:
+		cell.DataType = "n"
+	case string:
+		cell.DataType = "s"
+	case bool:
+		cell.DataType = "b"
+	case FormulaError:
+		cell.DataType = "e"
+	default:
+		cell.DataType = ""
+	}
+}
+
+// cellScalar 将单元格的值转换为计算使用的类型
+func cellScalar(cell *Cell) interface{} {
+	switch v := cell.Value.(type) {
+	case nil:
+		return nil
+	case FormulaError:
+		return v
+	case bool:
+		return v
+	case string:
+		if cell.DataType == "e" {
+			return FormulaError(v)
+		}
+		if v == "" && cell.DataType != "s" {
+			return nil
+		}
+		if cell.DataType == "n" {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+		return v
+	default:
+		if f, ok := toFloat(v); ok {
+			return f
+		}
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// toFloat 将Go数值类型转换为float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// rangeValue 表示公式中的区域引用，按需读取其中的单元格
+type rangeValue struct {
+	e           *calcEngine
+	ws          *Worksheet
+	row1, col1  int
+	row2, col2  int
+	visibleOnly bool // each跳过隐藏的行，用于SUBTOTAL的101~111功能号
+}
+
+// rows 返回区域的行数
+func (r *rangeValue) rows() int {
+	return r.row2 - r.row1 + 1
+}
+
+// cols 返回区域的列数
+func (r *rangeValue) cols() int {
+	return r.col2 - r.col1 + 1
+}
+
+// at 返回区域中第i行、第j列（均从0开始）的值
+func (r *rangeValue) at(i, j int) interface{} {
+	return r.e.cellValue(r.ws, r.row1+i, r.col1+j)
+}
+
+// each 按行依次访问区域中存在的单元格，跳过空单元格，i和j为单元格在区域中的行列偏移
+// 整行或整列引用只访问实际存储的单元格
+func (r *rangeValue) each(fn func(i, j int, value interface{})) {
+	rows := r.ws.Rows
+	start := sort.Search(len(rows), func(i int) bool { return rows[i].Index-1 >= r.row1 })
+	for _, row := range rows[start:] {
+		if row.Index-1 > r.row2 {
+			break
+		}
+		if r.visibleOnly && row.Hidden {
+			continue
+		}
+		cells := row.Cells
+		first := sort.Search(len(cells), func(i int) bool { return cells[i].Col >= r.col1 })
+		for _, cell := range cells[first:] {
+			if cell.Col > r.col2 {
+				break
+			}
+			var value interface{}
+			if cell.Formula != "" {
+				value = r.e.evalCell(r.ws, row.Index-1, cell)
+			} else {
+				value = cellScalar(cell)
+			}
+			if value != nil {
+				fn(row.Index-1-r.row1, cell.Col-r.col1, value)
+			}
+		}
+	}
+}
+
+// vector 将单行或单列区域转换为值列表，包含空单元格
+func (r *rangeValue) vector() ([]interface{}, bool) {
+	if r.rows() != 1 && r.cols() != 1 {
+		return nil, false
+	}
+	n := r.rows() * r.cols()
+	values := make([]interface{}, n)
+	for k := 0; k < n; k++ {
+		if r.rows() == 1 {
+			values[k] = r.at(0, k)
+		} else {
+			values[k] = r.at(k, 0)
+		}
+	}
+	return values, true
+}
+
+// eval 计算语法树节点，引用返回*rangeValue，其他返回标量
+func (e *calcEngine) eval(node formulaNode, ws *Worksheet) interface{} {
+	switch n := node.(type) {
+	case *literalNode:
+		return n.value
+	case *refNode:
+		return e.resolveRef(n.ref, ws)
+	case *nameNode:
+		return e.evalName(n.name, ws)
+	case *unaryNode:
+		value := e.scalar(e.eval(n.operand, ws))
+		f, errValue := toNumber(value)
+		if errValue != nil {
+			return errValue
+		}
+		switch n.op {
+		case "-":
+			return -f
+		case "%":
+			return f / 100
+		}
+		return f
+	case *binaryNode:
+		return e.evalBinary(n, ws)
+	case *funcNode:
+		return e.evalFunction(n, ws)
+	}
+	return FormulaErrorValue
+}
+
+// resolveRef 将引用解析为区域，工作表不存在时返回#REF!
+func (e *calcEngine) resolveRef(ref *formulaRef, ws *Worksheet) interface{} {
+	if ref.Sheet != "" {
+		ws = e.wb.GetWorksheet(ref.Sheet)
+		if ws == nil {
+			return FormulaErrorRef
+		}
+	}
+	row1, col1, row2, col2 := ref.bounds()
+	return &rangeValue{e: e, ws: ws, row1: row1, col1: col1, row2: row2, col2: col2}
+}
+
+// evalName 计算定义的名称，公式所在工作表范围内的名称优先于工作簿范围的名称
+// 名称不存在或循环定义时返回#NAME?，引用多个区域的名称无法参与计算，返回#VALUE!
+func (e *calcEngine) evalName(name string, ws *Worksheet) interface{} {
+	if e.wb == nil {
+		return FormulaErrorName
+	}
+	dn := e.wb.GetDefinedName(name, ws.Name)
+	if dn == nil {
+		dn = e.wb.GetDefinedName(name, "")
+	}
+	if dn == nil || e.names[dn] {
+		return FormulaErrorName
+	}
+	node, err := e.parse(dn.RefersTo)
+	if err != nil {
+		return FormulaErrorValue
+	}
+	e.names[dn] = true
+	defer delete(e.names, dn)
+	return e.eval(node, ws)
+}
+
+// scalar 将区域转换为单个值，只有单个单元格的区域取该单元格的值
+func (e *calcEngine) scalar(value interface{}) interface{} {
+	r, ok := value.(*rangeValue)
+	if !ok {
+		return value
+	}
+	if r.rows() == 1 && r.cols() == 1 {
+		return r.at(0, 0)
+	}
+	return FormulaErrorValue
+}
+
+// evalBinary 计算二元运算
+func (e *calcEngine) evalBinary(n *binaryNode, ws *Worksheet) interface{} {
+	left := e.scalar(e.eval(n.left, ws))
+	right := e.scalar(e.eval(n.right, ws))
+	if err, ok := left.(FormulaError); ok {
+		return err
+	}
+	if err, ok := right.(FormulaError); ok {
+		return err
+	}
+
+	switch n.op {
+	case "&":
+		return toText(left) + toText(right)
+	case "=", "<>", "<", ">", "<=", ">=":
+		c := compareValues(left, right)
+		switch n.op {
+		case "=":
+			return c == 0
+		case "<>":
+			return c != 0
+		case "<":
+			return c < 0
+		case ">":
+			return c > 0
+		case "<=":
+			return c <= 0
+		default:
+			return c >= 0
+		}
+	}
+
+	a, errValue := toNumber(left)
+	if errValue != nil {
+		return errValue
+	}
+	b, errValue := toNumber(right)
+	if errValue != nil {
+		return errValue
+	}
+	switch n.op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		if b == 0 {
+			return FormulaErrorDiv0
+		}
+		return a / b
+	case "^":
+		result := math.Pow(a, b)
+		if math.IsNaN(result) || math.IsInf(result, 0) {
+			return FormulaErrorNum
+		}
+		return result
+	}
+	return FormulaErrorValue
+}
+
+// toNumber 将标量转换为数字，空值为0，逻辑值为1或0，不能转换的文本返回#VALUE!
+func toNumber(value interface{}) (float64, interface{}) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, FormulaErrorValue
+		}
+		return f, nil
+	case FormulaError:
+		return 0, v
+	}
+	return 0, FormulaErrorValue
+}
+
+// toText 将标量转换为文本
+func toText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return formatNumber(v)
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// toBool 将标量转换为逻辑值
+func toBool(value interface{}) (bool, interface{}) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		switch strings.ToUpper(v) {
+		case "TRUE":
+			return true, nil
+		case "FALSE":
+			return false, nil
+		}
+		return false, FormulaErrorValue
+	case FormulaError:
+		return false, v
+	}
+	return false, FormulaErrorValue
+}
+
+// formatNumber 将数字格式化为最短的十进制文本
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// compareValues 比较两个标量：数字 < 文本 < 逻辑值，文本比较不区分大小写
+// 空值按另一方的类型视为0、空文本或FALSE
+func compareValues(a, b interface{}) int {
+	if a == nil {
+		a = zeroLike(b)
+	}
+	if b == nil {
+		b = zeroLike(a)
+	}
+
+	rank := func(v interface{}) int {
+		switch v.(type) {
+		case float64:
+			return 0
+		case string:
+			return 1
+		case bool:
+			return 2
+		}
+		return 3
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+
+	switch x := a.(type) {
+	case float64:
+		y := b.(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(strings.ToLower(x), strings.ToLower(b.(string)))
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+// zeroLike 返回与v同类型的空值
+func zeroLike(v interface{}) interface{} {
+	switch v.(type) {
+	case string:
+		return ""
+	case bool:
+		return false
+	}
+	return float64(0)
+}
@@ -0,0 +1,622 @@
+package workbook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 图表类型
+const (
+	ChartTypeColumn   = "column"   // 簇状柱形图
+	ChartTypeBar      = "bar"      // 条形图
+	ChartTypeLine     = "line"     // 折线图
+	ChartTypePie      = "pie"      // 饼图
+	ChartTypeDoughnut = "doughnut" // 圆环图
+	ChartTypeScatter  = "scatter"  // 散点图
+	ChartTypeArea     = "area"     // 面积图
+)
+
+// 柱形图、条形图、折线图和面积图的分组方式
+const (
+	ChartGroupingClustered      = "clustered"      // 簇状，仅柱形图和条形图
+	ChartGroupingStandard       = "standard"       // 标准，仅折线图和面积图
+	ChartGroupingStacked        = "stacked"        // 堆积
+	ChartGroupingPercentStacked = "percentStacked" // 百分比堆积
+)
+
+// 图例位置
+const (
+	ChartLegendRight    = "r"
+	ChartLegendLeft     = "l"
+	ChartLegendTop      = "t"
+	ChartLegendBottom   = "b"
+	ChartLegendTopRight = "tr"
+	ChartLegendNone     = "none" // 不显示图例
+)
+
+// 坐标轴ID，主坐标轴为1和2，次坐标轴为3和4
+const (
+	chartCatAxisID   = 1
+	chartValAxisID   = 2
+	chartCatAxis2ID  = 3
+	chartValAxis2ID  = 4
+	defaultHoleSize  = 50
+	chartLineWidth   = 28575 // 折线宽度，单位为EMU，即2.25磅
+	chartMarkerSize  = 5
+	chartDefaultMark = "circle"
+)
+
+var validChartTypes = map[string]bool{
+	ChartTypeColumn: true, ChartTypeBar: true, ChartTypeLine: true, ChartTypePie: true,
+	ChartTypeDoughnut: true, ChartTypeScatter: true, ChartTypeArea: true,
+}
+
+var validChartLegendPositions = map[string]bool{
+	"": true, ChartLegendRight: true, ChartLegendLeft: true, ChartLegendTop: true,
+	ChartLegendBottom: true, ChartLegendTopRight: true, ChartLegendNone: true,
+}
+
+// ChartSpec 描述一个图表
+// 系列的Type与ChartSpec.Type不同时组成组合图，例如在柱形图上叠加一条折线
+type ChartSpec struct {
+	Type       string // 图表类型，见ChartType常量
+	Name       string // 绘图对象的名称，为空时自动生成
+	Title      string
+	Series     []*ChartSeries
+	Grouping   string           // 分组方式，为空时柱形图和条形图为簇状，折线图和面积图为标准
+	Legend     string           // 图例位置，为空时显示在右侧
+	XAxis      *ChartAxis       // 分类轴（散点图为X数值轴）
+	YAxis      *ChartAxis       // 数值轴
+	Y2Axis     *ChartAxis       // 次数值轴，有系列使用次坐标轴时生效
+	DataLabels *ChartDataLabels // 所有系列的数据标签
+	VaryColors bool             // 单系列时每个数据点使用不同颜色，饼图和圆环图总是使用不同颜色
+	HoleSize   int              // 圆环图的内径百分比，为0时使用50
+}
+
+// ChartSeries 表示图表中的一个数据系列
+// 区域引用不带工作表名称时指向图表所在的工作表
+type ChartSeries struct {
+	Name          string   // 系列名称
+	NameRef       string   // 系列名称所在的单元格，例如 "Sheet1!$B$1"，设置时优先于Name
+	Categories    string   // 分类区域，散点图为X值区域，例如 "Sheet1!$A$2:$A$13"
+	Values        string   // 数值区域，例如 "Sheet1!$B$2:$B$13"
+	Type          string   // 组合图中该系列的图表类型，为空时使用ChartSpec.Type
+	SecondaryAxis bool     // 使用次坐标轴
+	Color         string   // 系列颜色，RRGGBB或AARRGGBB格式
+	PointColors   []string // 每个数据点的颜色，用于饼图等
+	Marker        string   // 折线图和散点图的标记: none, circle, square, diamond, triangle, x, star, dash, dot, plus
+	Smooth        bool     // 平滑线
+	DataLabels    *ChartDataLabels
+}
+
+// ChartAxis 表示坐标轴的设置
+type ChartAxis struct {
+	Title          string
+	Min            *float64 // 最小值，nil表示自动
+	Max            *float64 // 最大值，nil表示自动
+	MajorUnit      float64  // 主要刻度单位，0表示自动
+	NumFmt         string   // 刻度标签的数字格式，为空时使用数据源的格式
+	MajorGridlines bool     // 显示主要网格线
+	Hidden         bool     // 隐藏坐标轴
+}
+
+// ChartDataLabels 表示数据标签的设置
+type ChartDataLabels struct {
+	ShowValue      bool
+	ShowCategory   bool
+	ShowSeriesName bool
+	ShowPercent    bool   // 仅饼图和圆环图
+	ShowLegendKey  bool   // 显示图例项标示
+	Position       string // 标签位置: ctr, inEnd, outEnd, inBase, t, b, l, r, bestFit，为空时使用默认位置
+}
+
+// Chart 表示工作表中的一个图表
+type Chart struct {
+	Spec *ChartSpec
+	From anchorPoint // 左上角所在的单元格
+	To   anchorPoint // 右下角所在的单元格
+	ws   *Worksheet
+}
+
+// AddChart 在工作表中添加一个图表，图表占据从anchorFrom到anchorTo的单元格区域
+// 例如: ws.AddChart("E2", "L18", &ChartSpec{Type: ChartTypeColumn, Series: ...})
+func (ws *Worksheet) AddChart(anchorFrom, anchorTo string, spec *ChartSpec) (*Chart, error) {
+	if ws.stream != nil {
+		return nil, fmt.Errorf("流式写入的工作表 %s 不支持添加图表", ws.Name)
+	}
+	if spec == nil {
+		return nil, fmt.Errorf("图表设置不能为空")
+	}
+	from, err := parseAnchor(anchorFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseAnchor(anchorTo)
+	if err != nil {
+		return nil, err
+	}
+	if to.Col <= from.Col || to.Row <= from.Row {
+		return nil, fmt.Errorf("图表的右下角 %s 必须在左上角 %s 的右下方", anchorTo, anchorFrom)
+	}
+
+	// 区域引用补充工作表名称，图表中的引用必须带工作表名称
+	for _, series := range spec.Series {
+		if series == nil {
+			return nil, fmt.Errorf("图表系列不能为空")
+		}
+		for _, ref := range []*string{&series.NameRef, &series.Categories, &series.Values} {
+			if *ref == "" {
+				continue
+			}
+			qualified, err := ws.qualifyRef(*ref)
+			if err != nil {
+				return nil, err
+			}
+			*ref = qualified
+		}
+	}
+	if err := spec.check(); err != nil {
+		return nil, err
+	}
+
+	chart := &Chart{Spec: spec, From: from, To: to, ws: ws}
+	ws.Charts = append(ws.Charts, chart)
+	return chart, nil
+}
+
+// qualifyRef 检查区域引用并转换为带工作表名称的绝对引用，没有工作表名称时使用当前工作表
+func (ws *Worksheet) qualifyRef(ref string) (string, error) {
+	r, ok := parseSheetRef(strings.TrimPrefix(ref, "="))
+	if !ok {
+		return "", fmt.Errorf("无效的图表数据区域: %s", ref)
+	}
+	sheet := r.Sheet
+	if sheet == "" {
+		sheet = ws.Name
+	}
+	r.From.AbsRow, r.From.AbsCol, r.To.AbsRow, r.To.AbsCol = true, true, true, true
+	return quoteSheetName(sheet) + "!" + r.areaString(), nil
+}
+
+// check 检查图表设置是否有效
+func (spec *ChartSpec) check() error {
+	if !validChartTypes[spec.Type] {
+		return fmt.Errorf("无效的图表类型: %s", spec.Type)
+	}
+	if len(spec.Series) == 0 {
+		return fmt.Errorf("图表至少需要一个数据系列")
+	}
+	if !validChartLegendPositions[spec.Legend] {
+		return fmt.Errorf("无效的图例位置: %s", spec.Legend)
+	}
+	switch spec.Grouping {
+	case "", ChartGroupingStacked, ChartGroupingPercentStacked:
+	case ChartGroupingClustered, ChartGroupingStandard:
+		if spec.Grouping == ChartGroupingClustered && spec.Type != ChartTypeColumn && spec.Type != ChartTypeBar ||
+			spec.Grouping == ChartGroupingStandard && spec.Type != ChartTypeLine && spec.Type != ChartTypeArea {
+			return fmt.Errorf("图表类型 %s 不支持分组方式 %s", spec.Type, spec.Grouping)
+		}
+	default:
+		return fmt.Errorf("无效的图表分组方式: %s", spec.Grouping)
+	}
+
+	for i, series := range spec.Series {
+		if series.Values == "" {
+			return fmt.Errorf("第%d个图表系列缺少数值区域", i+1)
+		}
+		seriesType := series.chartType(spec)
+		if !validChartTypes[seriesType] {
+			return fmt.Errorf("无效的图表类型: %s", seriesType)
+		}
+		// 饼图、圆环图和散点图不能与其他类型组合
+		if seriesType != spec.Type && (isPieChart(seriesType) || isPieChart(spec.Type) ||
+			seriesType == ChartTypeScatter || spec.Type == ChartTypeScatter) {
+			return fmt.Errorf("图表类型 %s 不能与 %s 组合", seriesType, spec.Type)
+		}
+		if series.Color != "" {
+			if _, err := normalizeColor(series.Color); err != nil {
+				return err
+			}
+		}
+		for _, color := range series.PointColors {
+			if _, err := normalizeColor(color); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// chartType 返回系列实际使用的图表类型
+func (series *ChartSeries) chartType(spec *ChartSpec) string {
+	if series.Type != "" {
+		return series.Type
+	}
+	return spec.Type
+}
+
+// isPieChart 判断是否为没有坐标轴的饼图或圆环图
+func isPieChart(chartType string) bool {
+	return chartType == ChartTypePie || chartType == ChartTypeDoughnut
+}
+
+// chartGroup 表示组合图中使用同一类型和同一坐标轴的系列
+type chartGroup struct {
+	chartType string
+	secondary bool
+	series    []int // 系列在ChartSpec.Series中的索引
+}
+
+// groups 将系列按图表类型和坐标轴分组，分组按系列出现的顺序排列
+func (spec *ChartSpec) groups() []*chartGroup {
+	var groups []*chartGroup
+	for i, series := range spec.Series {
+		chartType := series.chartType(spec)
+		secondary := series.SecondaryAxis && !isPieChart(chartType)
+		var group *chartGroup
+		for _, g := range groups {
+			if g.chartType == chartType && g.secondary == secondary {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &chartGroup{chartType: chartType, secondary: secondary}
+			groups = append(groups, group)
+		}
+		group.series = append(group.series, i)
+	}
+	return groups
+}
+
+// ToXML 生成图表部件，数据区域的当前值作为缓存写入，以便不重新计算的查看器显示图表
+func (chart *Chart) ToXML(wb *Workbook) string {
+	spec := chart.Spec
+	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
+	xml += "<c:chartSpace xmlns:c=\"http://schemas.openxmlformats.org/drawingml/2006/chart\" xmlns:a=\"http://schemas.openxmlformats.org/drawingml/2006/main\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\">\n"
+	xml += "  <c:roundedCorners val=\"0\" />\n"
+	xml += "  <c:chart>\n"
+
+	if spec.Title != "" {
+		xml += "    " + chartTitleXML(spec.Title) + "\n"
+		xml += "    <c:autoTitleDeleted val=\"0\" />\n"
+	} else {
+		xml += "    <c:autoTitleDeleted val=\"1\" />\n"
+	}
+
+	xml += "    <c:plotArea>\n"
+	xml += "      <c:layout />\n"
+
+	cache := newCalcEngine(wb)
+	groups := spec.groups()
+	hasSecondary := false
+	for _, group := range groups {
+		xml += spec.groupXML(group, chart.ws, cache)
+		hasSecondary = hasSecondary || group.secondary
+	}
+
+	// 坐标轴
+	if !isPieChart(spec.Type) {
+		xml += spec.axesXML(false)
+		if hasSecondary {
+			xml += spec.axesXML(true)
+		}
+	}
+	xml += "    </c:plotArea>\n"
+
+	if spec.Legend != ChartLegendNone {
+		legend := spec.Legend
+		if legend == "" {
+			legend = ChartLegendRight
+		}
+		xml += "    <c:legend><c:legendPos val=\"" + legend + "\" /><c:overlay val=\"0\" /></c:legend>\n"
+	}
+	xml += "    <c:plotVisOnly val=\"1\" />\n"
+	xml += "    <c:dispBlanksAs val=\"gap\" />\n"
+	xml += "  </c:chart>\n"
+	xml += "</c:chartSpace>"
+	return xml
+}
+
+// groupXML 生成一组系列对应的图表元素，如c:barChart、c:lineChart
+func (spec *ChartSpec) groupXML(group *chartGroup, ws *Worksheet, cache *calcEngine) string {
+	grouping := spec.Grouping
+	switch group.chartType {
+	case ChartTypeColumn, ChartTypeBar:
+		if grouping == "" || grouping == ChartGroupingStandard {
+			grouping = ChartGroupingClustered
+		}
+	case ChartTypeLine, ChartTypeArea:
+		if grouping == "" || grouping == ChartGroupingClustered {
+			grouping = ChartGroupingStandard
+		}
+	}
+	varyColors := spec.VaryColors || isPieChart(group.chartType)
+
+	xml := ""
+	switch group.chartType {
+	case ChartTypeColumn, ChartTypeBar:
+		barDir := "col"
+		if group.chartType == ChartTypeBar {
+			barDir = "bar"
+		}
+		xml += "      <c:barChart><c:barDir val=\"" + barDir + "\" /><c:grouping val=\"" + grouping + "\" />"
+	case ChartTypeLine:
+		xml += "      <c:lineChart><c:grouping val=\"" + grouping + "\" />"
+	case ChartTypeArea:
+		xml += "      <c:areaChart><c:grouping val=\"" + grouping + "\" />"
+	case ChartTypePie:
+		xml += "      <c:pieChart>"
+	case ChartTypeDoughnut:
+		xml += "      <c:doughnutChart>"
+	case ChartTypeScatter:
+		xml += "      <c:scatterChart><c:scatterStyle val=\"lineMarker\" />"
+	}
+	xml += fmt.Sprintf("<c:varyColors val=\"%d\" />\n", boolToInt(varyColors))
+
+	for _, i := range group.series {
+		xml += spec.seriesXML(i, group.chartType, ws, cache)
+	}
+	if spec.DataLabels != nil {
+		xml += "        " + spec.DataLabels.toXML() + "\n"
+	}
+
+	xml += "        "
+	switch group.chartType {
+	case ChartTypeColumn, ChartTypeBar:
+		xml += "<c:gapWidth val=\"150\" />"
+		if grouping == ChartGroupingStacked || grouping == ChartGroupingPercentStacked {
+			xml += "<c:overlap val=\"100\" />"
+		}
+		xml += chartAxisIDsXML(group.secondary) + "</c:barChart>\n"
+	case ChartTypeLine:
+		xml += "<c:marker val=\"1\" />" + chartAxisIDsXML(group.secondary) + "</c:lineChart>\n"
+	case ChartTypeArea:
+		xml += chartAxisIDsXML(group.secondary) + "</c:areaChart>\n"
+	case ChartTypePie:
+		xml += "<c:firstSliceAng val=\"0\" /></c:pieChart>\n"
+	case ChartTypeDoughnut:
+		holeSize := spec.HoleSize
+		if holeSize <= 0 {
+			holeSize = defaultHoleSize
+		}
+		xml += fmt.Sprintf("<c:firstSliceAng val=\"0\" /><c:holeSize val=\"%d\" /></c:doughnutChart>\n", holeSize)
+	case ChartTypeScatter:
+		xml += chartAxisIDsXML(group.secondary) + "</c:scatterChart>\n"
+	}
+	return xml
+}
+
+// chartAxisIDsXML 生成图表元素引用的坐标轴ID
+func chartAxisIDsXML(secondary bool) string {
+	if secondary {
+		return fmt.Sprintf("<c:axId val=\"%d\" /><c:axId val=\"%d\" />", chartCatAxis2ID, chartValAxis2ID)
+	}
+	return fmt.Sprintf("<c:axId val=\"%d\" /><c:axId val=\"%d\" />", chartCatAxisID, chartValAxisID)
+}
+
+// seriesXML 生成c:ser元素，子元素的顺序因图表类型而不同
+func (spec *ChartSpec) seriesXML(index int, chartType string, ws *Worksheet, cache *calcEngine) string {
+	series := spec.Series[index]
+	xml := fmt.Sprintf("        <c:ser><c:idx val=\"%d\" /><c:order val=\"%d\" />", index, index)
+
+	// 系列名称
+	if series.NameRef != "" {
+		xml += "<c:tx><c:strRef><c:f>" + escapeXML(series.NameRef) + "</c:f>" + chartStrCache(cache, ws, series.NameRef) + "</c:strRef></c:tx>"
+	} else if series.Name != "" {
+		xml += "<c:tx><c:v>" + escapeXML(series.Name) + "</c:v></c:tx>"
+	}
+
+	// 系列格式，散点图默认只显示标记，颜色用于标记
+	lineLike := chartType == ChartTypeLine || chartType == ChartTypeScatter
+	color, _ := normalizeColor(series.Color)
+	switch {
+	case chartType == ChartTypeScatter && !series.Smooth:
+		xml += "<c:spPr><a:ln w=\"19050\"><a:noFill /></a:ln></c:spPr>"
+	case color != "" && lineLike:
+		xml += fmt.Sprintf("<c:spPr><a:ln w=\"%d\" cap=\"rnd\">%s<a:round /></a:ln></c:spPr>", chartLineWidth, solidFillXML(color))
+	case color != "":
+		xml += "<c:spPr>" + solidFillXML(color) + "</c:spPr>"
+	}
+
+	switch chartType {
+	case ChartTypeColumn, ChartTypeBar:
+		xml += "<c:invertIfNegative val=\"0\" />"
+	case ChartTypeLine, ChartTypeScatter:
+		xml += series.markerXML(chartType)
+	}
+
+	// 数据点颜色
+	for i, c := range series.PointColors {
+		color, _ := normalizeColor(c)
+		if color == "" {
+			continue
+		}
+		xml += fmt.Sprintf("<c:dPt><c:idx val=\"%d\" />", i)
+		if chartType == ChartTypeColumn || chartType == ChartTypeBar {
+			xml += "<c:invertIfNegative val=\"0\" />"
+		}
+		xml += "<c:bubble3D val=\"0\" /><c:spPr>" + solidFillXML(color) + "</c:spPr></c:dPt>"
+	}
+
+	if series.DataLabels != nil {
+		xml += series.DataLabels.toXML()
+	}
+
+	if chartType == ChartTypeScatter {
+		if series.Categories != "" {
+			xml += "<c:xVal><c:numRef><c:f>" + escapeXML(series.Categories) + "</c:f>" + chartNumCache(cache, ws, series.Categories) + "</c:numRef></c:xVal>"
+		}
+		xml += "<c:yVal><c:numRef><c:f>" + escapeXML(series.Values) + "</c:f>" + chartNumCache(cache, ws, series.Values) + "</c:numRef></c:yVal>"
+	} else {
+		if series.Categories != "" {
+			xml += "<c:cat><c:strRef><c:f>" + escapeXML(series.Categories) + "</c:f>" + chartStrCache(cache, ws, series.Categories) + "</c:strRef></c:cat>"
+		}
+		xml += "<c:val><c:numRef><c:f>" + escapeXML(series.Values) + "</c:f>" + chartNumCache(cache, ws, series.Values) + "</c:numRef></c:val>"
+	}
+
+	if lineLike {
+		xml += fmt.Sprintf("<c:smooth val=\"%d\" />", boolToInt(series.Smooth))
+	}
+	xml += "</c:ser>\n"
+	return xml
+}
+
+// markerXML 生成折线图和散点图系列的标记，未指定时折线图使用自动标记，散点图使用圆形标记
+func (series *ChartSeries) markerXML(chartType string) string {
+	symbol := series.Marker
+	if symbol == "" {
+		if chartType != ChartTypeScatter {
+			return ""
+		}
+		symbol = chartDefaultMark
+	}
+	if symbol == "none" {
+		return "<c:marker><c:symbol val=\"none\" /></c:marker>"
+	}
+	xml := fmt.Sprintf("<c:marker><c:symbol val=\"%s\" /><c:size val=\"%d\" />", symbol, chartMarkerSize)
+	if color, _ := normalizeColor(series.Color); color != "" {
+		xml += "<c:spPr>" + solidFillXML(color) + "<a:ln>" + solidFillXML(color) + "</a:ln></c:spPr>"
+	}
+	return xml + "</c:marker>"
+}
+
+// toXML 生成c:dLbls元素
+func (d *ChartDataLabels) toXML() string {
+	xml := "<c:dLbls>"
+	if d.Position != "" {
+		xml += "<c:dLblPos val=\"" + d.Position + "\" />"
+	}
+	xml += fmt.Sprintf("<c:showLegendKey val=\"%d\" /><c:showVal val=\"%d\" /><c:showCatName val=\"%d\" /><c:showSerName val=\"%d\" /><c:showPercent val=\"%d\" /><c:showBubbleSize val=\"0\" />",
+		boolToInt(d.ShowLegendKey), boolToInt(d.ShowValue), boolToInt(d.ShowCategory), boolToInt(d.ShowSeriesName), boolToInt(d.ShowPercent))
+	return xml + "</c:dLbls>"
+}
+
+// axesXML 生成主坐标轴或次坐标轴
+func (spec *ChartSpec) axesXML(secondary bool) string {
+	catID, valID := chartCatAxisID, chartValAxisID
+	x, y := spec.XAxis, spec.YAxis
+	catPos, valPos := "b", "l"
+	if spec.Type == ChartTypeBar {
+		catPos, valPos = "l", "b"
+	}
+	if secondary {
+		catID, valID = chartCatAxis2ID, chartValAxis2ID
+		// 次分类轴不显示，次数值轴显示在另一侧
+		x, y = &ChartAxis{Hidden: true}, spec.Y2Axis
+		if y == nil {
+			y = &ChartAxis{}
+		}
+		if spec.Type == ChartTypeBar {
+			catPos, valPos = "r", "t"
+		} else {
+			catPos, valPos = "t", "r"
+		}
+	}
+	if x == nil {
+		x = &ChartAxis{}
+	}
+	if y == nil {
+		y = &ChartAxis{MajorGridlines: true}
+	}
+
+	xml := "      "
+	if spec.Type == ChartTypeScatter {
+		xml += "<c:valAx>" + x.commonXML(catID, catPos) + "<c:crossAx val=\"" + fmt.Sprint(valID) + "\" /><c:crosses val=\"autoZero\" /><c:crossBetween val=\"midCat\" />"
+		xml += x.majorUnitXML() + "</c:valAx>\n"
+	} else {
+		xml += "<c:catAx>" + x.commonXML(catID, catPos) + "<c:crossAx val=\"" + fmt.Sprint(valID) + "\" /><c:crosses val=\"autoZero\" />"
+		xml += "<c:auto val=\"1\" /><c:lblAlgn val=\"ctr\" /><c:lblOffset val=\"100\" /></c:catAx>\n"
+	}
+
+	crosses := "autoZero"
+	if secondary {
+		crosses = "max"
+	}
+	crossBetween := "between"
+	if spec.Type == ChartTypeScatter {
+		crossBetween = "midCat"
+	}
+	xml += "      <c:valAx>" + y.commonXML(valID, valPos) + "<c:crossAx val=\"" + fmt.Sprint(catID) + "\" /><c:crosses val=\"" + crosses + "\" />"
+	xml += "<c:crossBetween val=\"" + crossBetween + "\" />" + y.majorUnitXML() + "</c:valAx>\n"
+	return xml
+}
+
+// commonXML 生成分类轴和数值轴共有的子元素，从c:axId到c:tickLblPos
+func (axis *ChartAxis) commonXML(id int, pos string) string {
+	xml := fmt.Sprintf("<c:axId val=\"%d\" /><c:scaling><c:orientation val=\"minMax\" />", id)
+	if axis.Max != nil {
+		xml += "<c:max val=\"" + formatNumber(*axis.Max) + "\" />"
+	}
+	if axis.Min != nil {
+		xml += "<c:min val=\"" + formatNumber(*axis.Min) + "\" />"
+	}
+	xml += fmt.Sprintf("</c:scaling><c:delete val=\"%d\" /><c:axPos val=\"%s\" />", boolToInt(axis.Hidden), pos)
+	if axis.MajorGridlines {
+		xml += "<c:majorGridlines />"
+	}
+	if axis.Title != "" {
+		xml += chartTitleXML(axis.Title)
+	}
+	if axis.NumFmt != "" {
+		xml += "<c:numFmt formatCode=\"" + escapeXML(axis.NumFmt) + "\" sourceLinked=\"0\" />"
+	}
+	xml += "<c:majorTickMark val=\"out\" /><c:minorTickMark val=\"none\" /><c:tickLblPos val=\"nextTo\" />"
+	return xml
+}
+
+// majorUnitXML 生成主要刻度单位
+func (axis *ChartAxis) majorUnitXML() string {
+	if axis.MajorUnit > 0 {
+		return "<c:majorUnit val=\"" + formatNumber(axis.MajorUnit) + "\" />"
+	}
+	return ""
+}
+
+// chartTitleXML 生成图表或坐标轴的标题
+func chartTitleXML(title string) string {
+	return "<c:title><c:tx><c:rich><a:bodyPr /><a:p><a:r><a:t>" + escapeXML(title) + "</a:t></a:r></a:p></c:rich></c:tx><c:overlay val=\"0\" /></c:title>"
+}
+
+// solidFillXML 生成纯色填充，color为AARRGGBB格式
+func solidFillXML(color string) string {
+	return "<a:solidFill><a:srgbClr val=\"" + color[2:] + "\" /></a:solidFill>"
+}
+
+// chartRangeValues 读取图表数据区域的当前值，无法读取时返回nil
+func chartRangeValues(cache *calcEngine, ws *Worksheet, ref string) []interface{} {
+	formulaRef, ok := parseSheetRef(ref)
+	if !ok {
+		return nil
+	}
+	r, ok := cache.resolveRef(formulaRef, ws).(*rangeValue)
+	if !ok || r.rows()*r.cols() > MaxRows {
+		return nil
+	}
+	values, _ := r.vector()
+	return values
+}
+
+// chartStrCache 生成文本缓存
+func chartStrCache(cache *calcEngine, ws *Worksheet, ref string) string {
+	values := chartRangeValues(cache, ws, ref)
+	xml := fmt.Sprintf("<c:strCache><c:ptCount val=\"%d\" />", len(values))
+	for i, v := range values {
+		if v != nil {
+			xml += fmt.Sprintf("<c:pt idx=\"%d\"><c:v>%s</c:v></c:pt>", i, escapeXML(toText(v)))
+		}
+	}
+	return xml + "</c:strCache>"
+}
+
+// chartNumCache 生成数值缓存，非数字的值不写入
+func chartNumCache(cache *calcEngine, ws *Worksheet, ref string) string {
+	values := chartRangeValues(cache, ws, ref)
+	xml := fmt.Sprintf("<c:numCache><c:formatCode>General</c:formatCode><c:ptCount val=\"%d\" />", len(values))
+	for i, v := range values {
+		if f, ok := v.(float64); ok {
+			xml += fmt.Sprintf("<c:pt idx=\"%d\"><c:v>%s</c:v></c:pt>", i, formatNumber(f))
+		}
+	}
+	return xml + "</c:numCache>"
+}
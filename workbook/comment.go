@@ -0,0 +1,277 @@
+package workbook
+
+import (
+	"fmt"
+
+	"github.com/landaiqing/go-dockit/opc"
+)
+
+// 批注部件和VML绘图部件的内容类型和关系类型
+const (
+	contentTypeComments   = "application/vnd.openxmlformats-officedocument.spreadsheetml.comments+xml"
+	contentTypeVMLDrawing = "application/vnd.openxmlformats-officedocument.vmlDrawing"
+	relTypeComments       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments"
+	relTypeVMLDrawing     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/vmlDrawing"
+)
+
+// 批注框的默认大小和字体，与Excel新建批注时一致
+const (
+	defaultCommentWidth    = 108   // 磅
+	defaultCommentHeight   = 59.25 // 磅
+	defaultCommentFontName = "Tahoma"
+	defaultCommentFontSize = 9
+)
+
+// Comment 表示单元格的批注
+type Comment struct {
+	Ref     string        // 批注所在的单元格，如 "B2"
+	Author  string        // 作者
+	Runs    []*CommentRun // 批注内容，每一段可以使用不同的字体
+	Visible bool          // 始终显示批注，否则只在鼠标悬停时显示
+	Width   float64       // 批注框的宽度，单位为磅，0表示默认宽度
+	Height  float64       // 批注框的高度，单位为磅，0表示默认高度
+}
+
+// CommentRun 表示批注中字体相同的一段文本
+type CommentRun struct {
+	Text string
+	Font *Font // 为nil时使用批注的默认字体
+}
+
+// AddComment 为单元格添加批注，单元格已有批注时替换原批注
+// 批注默认在鼠标悬停时显示，可以用SetVisible设置为始终显示，用AddRun追加带格式的文本
+// 例如: ws.AddComment("C5", "数据检查", "单价低于成本价")
+func (ws *Worksheet) AddComment(cellRef, author, text string) (*Comment, error) {
+	if ws.stream != nil {
+		return nil, fmt.Errorf("流式写入的工作表 %s 不支持批注", ws.Name)
+	}
+	if !isValidCellRef(cellRef) {
+		return nil, fmt.Errorf("无效的单元格引用: %s", cellRef)
+	}
+	comment := &Comment{Ref: cellRef, Author: author}
+	if text != "" {
+		comment.AddRun(text, nil)
+	}
+	for i, existing := range ws.Comments {
+		if existing.Ref == cellRef {
+			ws.Comments[i] = comment
+			return comment, nil
+		}
+	}
+	ws.Comments = append(ws.Comments, comment)
+	return comment, nil
+}
+
+// GetComment 返回单元格的批注，不存在时返回nil
+func (ws *Worksheet) GetComment(cellRef string) *Comment {
+	for _, comment := range ws.Comments {
+		if comment.Ref == cellRef {
+			return comment
+		}
+	}
+	return nil
+}
+
+// DeleteComment 删除单元格的批注
+func (ws *Worksheet) DeleteComment(cellRef string) {
+	for i, comment := range ws.Comments {
+		if comment.Ref == cellRef {
+			ws.Comments = append(ws.Comments[:i], ws.Comments[i+1:]...)
+			return
+		}
+	}
+}
+
+// AddRun 在批注末尾追加一段文本，font为nil时使用批注的默认字体
+func (c *Comment) AddRun(text string, font *Font) *Comment {
+	run := &CommentRun{Text: text}
+	if font != nil {
+		f := *font
+		if color, err := normalizeColor(f.Color); err == nil && f.Color != "" {
+			f.Color = color
+		}
+		run.Font = &f
+	}
+	c.Runs = append(c.Runs, run)
+	return c
+}
+
+// SetVisible 设置批注是否始终显示
+func (c *Comment) SetVisible(visible bool) *Comment {
+	c.Visible = visible
+	return c
+}
+
+// SetSize 设置批注框的大小，单位为磅
+func (c *Comment) SetSize(width, height float64) *Comment {
+	c.Width = width
+	c.Height = height
+	return c
+}
+
+// Text 返回批注的纯文本内容
+func (c *Comment) Text() string {
+	text := ""
+	for _, run := range c.Runs {
+		text += run.Text
+	}
+	return text
+}
+
+// check 检查批注是否有效
+func (c *Comment) check() error {
+	if !isValidCellRef(c.Ref) {
+		return fmt.Errorf("无效的单元格引用: %s", c.Ref)
+	}
+	if c.Width < 0 || c.Height < 0 {
+		return fmt.Errorf("批注框的大小不能为负数")
+	}
+	for _, run := range c.Runs {
+		if run.Font != nil && run.Font.Color != "" {
+			if _, err := normalizeColor(run.Font.Color); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addCommentParts 添加工作表的批注部件和显示批注所需的VML绘图部件，index为批注部件的序号
+// VML形状ID按每块1024个分配，block为第一个可用的块，返回下一个可用的块
+func (wb *Workbook) addCommentParts(pkg *opc.Package, ws *Worksheet, index, block int) int {
+	pkg.AddPart(fmt.Sprintf("xl/comments%d.xml", index), contentTypeComments, []byte(ws.commentsXML()))
+	pkg.AddPart(fmt.Sprintf("xl/drawings/vmlDrawing%d.vml", index), contentTypeVMLDrawing, []byte(ws.vmlDrawingXML(block)))
+	return block + len(ws.Comments)/1024 + 1
+}
+
+// commentsXML 生成批注部件
+func (ws *Worksheet) commentsXML() string {
+	var authors []string
+	authorIDs := make(map[string]int)
+	for _, comment := range ws.Comments {
+		if _, ok := authorIDs[comment.Author]; !ok {
+			authorIDs[comment.Author] = len(authors)
+			authors = append(authors, comment.Author)
+		}
+	}
+
+	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
+	xml += "<comments xmlns=\"http://schemas.openxmlformats.org/spreadsheetml/2006/main\">\n"
+	xml += "  <authors>\n"
+	for _, author := range authors {
+		xml += "    <author>" + escapeXML(author) + "</author>\n"
+	}
+	xml += "  </authors>\n"
+	xml += "  <commentList>\n"
+	for _, comment := range ws.Comments {
+		xml += fmt.Sprintf("    <comment ref=\"%s\" authorId=\"%d\"><text>", comment.Ref, authorIDs[comment.Author])
+		for _, run := range comment.Runs {
+			xml += "<r>" + run.rPrXML() + "<t xml:space=\"preserve\">" + escapeXML(run.Text) + "</t></r>"
+		}
+		if len(comment.Runs) == 0 {
+			xml += "<t></t>"
+		}
+		xml += "</text></comment>\n"
+	}
+	xml += "  </commentList>\n"
+	xml += "</comments>"
+	return xml
+}
+
+// rPrXML 生成文本段的字体，未设置的名称和大小使用批注的默认值
+func (run *CommentRun) rPrXML() string {
+	font := run.Font
+	if font == nil {
+		font = &Font{}
+	}
+	xml := "<rPr>"
+	if font.Bold {
+		xml += "<b />"
+	}
+	if font.Italic {
+		xml += "<i />"
+	}
+	if font.Underline {
+		xml += "<u />"
+	}
+	size := font.Size
+	if size <= 0 {
+		size = defaultCommentFontSize
+	}
+	xml += "<sz val=\"" + formatNumber(size) + "\" />"
+	if font.Color != "" {
+		xml += "<color rgb=\"" + font.Color + "\" />"
+	} else {
+		xml += "<color indexed=\"81\" />"
+	}
+	name := font.Name
+	if name == "" {
+		name = defaultCommentFontName
+	}
+	xml += "<rFont val=\"" + escapeXML(name) + "\" />"
+	xml += "</rPr>"
+	return xml
+}
+
+// vmlDrawingXML 生成显示批注框的VML绘图部件，形状ID从第block块开始分配，在工作簿内唯一
+func (ws *Worksheet) vmlDrawingXML(block int) string {
+	blocks := fmt.Sprintf("%d", block)
+	for b := block + 1; b <= block+len(ws.Comments)/1024; b++ {
+		blocks += fmt.Sprintf(",%d", b)
+	}
+	xml := "<xml xmlns:v=\"urn:schemas-microsoft-com:vml\" xmlns:o=\"urn:schemas-microsoft-com:office:office\" xmlns:x=\"urn:schemas-microsoft-com:office:excel\">\n"
+	xml += " <o:shapelayout v:ext=\"edit\"><o:idmap v:ext=\"edit\" data=\"" + blocks + "\" /></o:shapelayout>\n"
+	xml += " <v:shapetype id=\"_x0000_t202\" coordsize=\"21600,21600\" o:spt=\"202\" path=\"m,l,21600r21600,l21600,xe\">\n"
+	xml += "  <v:stroke joinstyle=\"miter\" />\n"
+	xml += "  <v:path gradientshapeok=\"t\" o:connecttype=\"rect\" />\n"
+	xml += " </v:shapetype>\n"
+
+	for i, comment := range ws.Comments {
+		row, col, err := ParseCellRef(comment.Ref)
+		if err != nil {
+			continue
+		}
+		width, height := comment.Width, comment.Height
+		if width <= 0 {
+			width = defaultCommentWidth
+		}
+		if height <= 0 {
+			height = defaultCommentHeight
+		}
+
+		// 批注框位于单元格右上方
+		from := anchorPoint{Col: col + 1, ColOff: 15 * emuPerPixel, Row: max(row-1, 0), RowOff: 10 * emuPerPixel}
+		if from.Col >= MaxColumns {
+			from.Col = max(col-3, 0)
+		}
+		to := ws.anchorEnd(from, int64(width*pixelsPerPoint)*emuPerPixel, int64(height*pixelsPerPoint)*emuPerPixel)
+
+		visibility := "hidden"
+		if comment.Visible {
+			visibility = "visible"
+		}
+		xml += fmt.Sprintf(" <v:shape id=\"_x0000_s%d\" type=\"#_x0000_t202\"", block*1024+i+1)
+		xml += " style=\"position:absolute;width:" + formatNumber(width) + "pt;height:" + formatNumber(height) + "pt;z-index:" + fmt.Sprintf("%d", i+1) + ";visibility:" + visibility + "\""
+		xml += " fillcolor=\"#ffffe1\" o:insetmode=\"auto\">\n"
+		xml += "  <v:fill color2=\"#ffffe1\" />\n"
+		xml += "  <v:shadow on=\"t\" color=\"black\" obscured=\"t\" />\n"
+		xml += "  <v:path o:connecttype=\"none\" />\n"
+		xml += "  <v:textbox style=\"mso-direction-alt:auto\"><div style=\"text-align:left\"></div></v:textbox>\n"
+		xml += "  <x:ClientData ObjectType=\"Note\">\n"
+		xml += "   <x:MoveWithCells />\n"
+		xml += "   <x:SizeWithCells />\n"
+		xml += fmt.Sprintf("   <x:Anchor>%d, %d, %d, %d, %d, %d, %d, %d</x:Anchor>\n",
+			from.Col, from.ColOff/emuPerPixel, from.Row, from.RowOff/emuPerPixel,
+			to.Col, to.ColOff/emuPerPixel, to.Row, to.RowOff/emuPerPixel)
+		xml += "   <x:AutoFill>False</x:AutoFill>\n"
+		xml += fmt.Sprintf("   <x:Row>%d</x:Row>\n", row)
+		xml += fmt.Sprintf("   <x:Column>%d</x:Column>\n", col)
+		if comment.Visible {
+			xml += "   <x:Visible />\n"
+		}
+		xml += "  </x:ClientData>\n"
+		xml += " </v:shape>\n"
+	}
+	xml += "</xml>"
+	return xml
+}
@@ -0,0 +1,460 @@
+package workbook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 条件格式规则的类型
+const (
+	ConditionalTypeCellIs          = "cellIs"          // 单元格值比较
+	ConditionalTypeExpression      = "expression"      // 公式
+	ConditionalTypeTop10           = "top10"           // 前N项或后N项
+	ConditionalTypeAboveAverage    = "aboveAverage"    // 高于或低于平均值
+	ConditionalTypeDuplicateValues = "duplicateValues" // 重复值
+	ConditionalTypeUniqueValues    = "uniqueValues"    // 唯一值
+	ConditionalTypeContainsText    = "containsText"    // 包含文本
+	ConditionalTypeNotContainsText = "notContainsText" // 不包含文本
+	ConditionalTypeBeginsWith      = "beginsWith"      // 以文本开头
+	ConditionalTypeEndsWith        = "endsWith"        // 以文本结尾
+	ConditionalTypeColorScale      = "colorScale"      // 色阶
+	ConditionalTypeDataBar         = "dataBar"         // 数据条
+	ConditionalTypeIconSet         = "iconSet"         // 图标集
+)
+
+// 条件格式阈值的类型
+const (
+	ConditionalValueMin        = "min"
+	ConditionalValueMax        = "max"
+	ConditionalValueNumber     = "num"
+	ConditionalValuePercent    = "percent"
+	ConditionalValuePercentile = "percentile"
+	ConditionalValueFormula    = "formula"
+)
+
+// cellIs规则可用的运算符，与数据验证的运算符相同
+var validConditionalOperators = validValidationOperators
+
+// 需要差异格式的规则类型
+var dxfConditionalTypes = map[string]bool{
+	ConditionalTypeCellIs: true, ConditionalTypeExpression: true, ConditionalTypeTop10: true,
+	ConditionalTypeAboveAverage: true, ConditionalTypeDuplicateValues: true, ConditionalTypeUniqueValues: true,
+	ConditionalTypeContainsText: true, ConditionalTypeNotContainsText: true,
+	ConditionalTypeBeginsWith: true, ConditionalTypeEndsWith: true,
+}
+
+// ConditionalFormat 表示应用到一组区域的条件格式
+type ConditionalFormat struct {
+	Sqref string // 应用的区域，多个区域以空格分隔
+	Rules []*ConditionalFormatRule
+}
+
+// ConditionalFormatRule 表示一条条件格式规则
+type ConditionalFormatRule struct {
+	Type         string
+	Operator     string   // cellIs规则的比较运算符
+	Formulas     []string // 条件值或公式，不带等号
+	Style        *Style   // 满足条件时应用的差异格式，添加规则时注册到样式表
+	DxfID        int      // 差异格式索引，-1表示没有差异格式
+	Priority     int      // 优先级，数字越小越先计算，为0时按添加顺序自动分配
+	StopIfTrue   bool
+	Rank         int  // top10规则的项数
+	Percent      bool // top10规则的项数按百分比计算
+	Bottom       bool // top10规则取后N项
+	BelowAverage bool // aboveAverage规则取低于平均值的单元格
+	EqualAverage bool // aboveAverage规则包含等于平均值的单元格
+	StdDev       int  // aboveAverage规则偏离平均值的标准差倍数
+	Text         string
+	ColorScale   *ColorScale
+	DataBar      *DataBar
+	IconSet      *IconSet
+}
+
+// ConditionalValue 表示色阶、数据条和图标集的阈值
+type ConditionalValue struct {
+	Type  string // min, max, num, percent, percentile, formula
+	Value string
+}
+
+// ColorScale 表示色阶，阈值和颜色一一对应
+type ColorScale struct {
+	Values []ConditionalValue
+	Colors []string
+}
+
+// DataBar 表示数据条
+type DataBar struct {
+	Min       ConditionalValue
+	Max       ConditionalValue
+	Color     string
+	HideValue bool // 只显示数据条，不显示单元格的值
+}
+
+// IconSet 表示图标集，阈值的数量与图标数量相同，第一个阈值通常为0%
+type IconSet struct {
+	Style     string // 例如: 3Arrows, 3TrafficLights1, 4Rating, 5Quarters
+	Values    []ConditionalValue
+	Reverse   bool
+	HideValue bool
+}
+
+// NewCellIsRule 创建一个单元格值比较规则，between和notBetween需要两个值
+// 值可以是数字或公式字符串，文本值需要带双引号，例如 "\"完成\""
+func NewCellIsRule(operator string, style *Style, values ...interface{}) *ConditionalFormatRule {
+	rule := &ConditionalFormatRule{Type: ConditionalTypeCellIs, Operator: operator, Style: style}
+	for _, v := range values {
+		rule.Formulas = append(rule.Formulas, conditionalFormula(v))
+	}
+	return rule
+}
+
+// NewExpressionRule 创建一个公式规则，公式结果为TRUE时应用格式
+// 公式中的相对引用相对于区域左上角的单元格
+func NewExpressionRule(formula string, style *Style) *ConditionalFormatRule {
+	return &ConditionalFormatRule{
+		Type:     ConditionalTypeExpression,
+		Formulas: []string{strings.TrimPrefix(formula, "=")},
+		Style:    style,
+	}
+}
+
+// NewTopRule 创建一个前N项规则，percent为true时取前N%
+func NewTopRule(rank int, percent bool, style *Style) *ConditionalFormatRule {
+	return &ConditionalFormatRule{Type: ConditionalTypeTop10, Rank: rank, Percent: percent, Style: style}
+}
+
+// NewBottomRule 创建一个后N项规则，percent为true时取后N%
+func NewBottomRule(rank int, percent bool, style *Style) *ConditionalFormatRule {
+	return &ConditionalFormatRule{Type: ConditionalTypeTop10, Rank: rank, Percent: percent, Bottom: true, Style: style}
+}
+
+// NewAboveAverageRule 创建一个高于平均值的规则，above为false时取低于平均值的单元格
+func NewAboveAverageRule(above bool, style *Style) *ConditionalFormatRule {
+	return &ConditionalFormatRule{Type: ConditionalTypeAboveAverage, BelowAverage: !above, Style: style}
+}
+
+// NewDuplicateRule 创建一个重复值规则
+func NewDuplicateRule(style *Style) *ConditionalFormatRule {
+	return &ConditionalFormatRule{Type: ConditionalTypeDuplicateValues, Style: style}
+}
+
+// NewUniqueRule 创建一个唯一值规则
+func NewUniqueRule(style *Style) *ConditionalFormatRule {
+	return &ConditionalFormatRule{Type: ConditionalTypeUniqueValues, Style: style}
+}
+
+// NewTextRule 创建一个文本规则，ruleType为containsText、notContainsText、beginsWith或endsWith
+// 判断用的公式在添加到区域时根据区域左上角的单元格生成
+func NewTextRule(ruleType, text string, style *Style) *ConditionalFormatRule {
+	return &ConditionalFormatRule{Type: ruleType, Text: text, Style: style}
+}
+
+// NewColorScaleRule 创建一个色阶规则，两种颜色对应最小值和最大值，三种颜色时中间颜色对应第50百分位
+func NewColorScaleRule(colors ...string) *ConditionalFormatRule {
+	scale := &ColorScale{Colors: colors}
+	scale.Values = append(scale.Values, ConditionalValue{Type: ConditionalValueMin})
+	if len(colors) == 3 {
+		scale.Values = append(scale.Values, ConditionalValue{Type: ConditionalValuePercentile, Value: "50"})
+	}
+	scale.Values = append(scale.Values, ConditionalValue{Type: ConditionalValueMax})
+	return &ConditionalFormatRule{Type: ConditionalTypeColorScale, ColorScale: scale}
+}
+
+// NewDataBarRule 创建一个数据条规则，数据条长度从区域的最小值到最大值
+func NewDataBarRule(color string) *ConditionalFormatRule {
+	return &ConditionalFormatRule{
+		Type: ConditionalTypeDataBar,
+		DataBar: &DataBar{
+			Min:   ConditionalValue{Type: ConditionalValueMin},
+			Max:   ConditionalValue{Type: ConditionalValueMax},
+			Color: color,
+		},
+	}
+}
+
+// NewIconSetRule 创建一个图标集规则，图标按百分比均匀划分，例如3个图标的阈值为0%、33%、67%
+func NewIconSetRule(style string) *ConditionalFormatRule {
+	n := 3
+	if style != "" && style[0] >= '3' && style[0] <= '5' {
+		n = int(style[0] - '0')
+	}
+	set := &IconSet{Style: style}
+	for i := 0; i < n; i++ {
+		set.Values = append(set.Values, ConditionalValue{
+			Type:  ConditionalValuePercent,
+			Value: strconv.Itoa((i*100 + n/2) / n),
+		})
+	}
+	return &ConditionalFormatRule{Type: ConditionalTypeIconSet, IconSet: set}
+}
+
+// SetStopIfTrue 设置满足条件时是否停止计算优先级更低的规则
+func (r *ConditionalFormatRule) SetStopIfTrue(stop bool) *ConditionalFormatRule {
+	r.StopIfTrue = stop
+	return r
+}
+
+// SetPriority 设置规则的优先级
+func (r *ConditionalFormatRule) SetPriority(priority int) *ConditionalFormatRule {
+	r.Priority = priority
+	return r
+}
+
+// conditionalFormula 将条件值转换为公式文本
+func conditionalFormula(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strings.TrimPrefix(s, "=")
+	}
+	if f, ok := toFloat(value); ok {
+		return formatNumber(f)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// textRuleFormula 生成文本规则的判断公式，cellRef为区域左上角的单元格
+func textRuleFormula(ruleType, text, cellRef string) string {
+	quoted := "\"" + strings.ReplaceAll(text, "\"", "\"\"") + "\""
+	switch ruleType {
+	case ConditionalTypeContainsText:
+		return "NOT(ISERROR(SEARCH(" + quoted + "," + cellRef + ")))"
+	case ConditionalTypeNotContainsText:
+		return "ISERROR(SEARCH(" + quoted + "," + cellRef + "))"
+	case ConditionalTypeBeginsWith:
+		return "LEFT(" + cellRef + ",LEN(" + quoted + "))=" + quoted
+	case ConditionalTypeEndsWith:
+		return "RIGHT(" + cellRef + ",LEN(" + quoted + "))=" + quoted
+	}
+	return ""
+}
+
+// check 检查规则的参数是否有效
+func (r *ConditionalFormatRule) check() error {
+	switch r.Type {
+	case ConditionalTypeCellIs:
+		if !validConditionalOperators[r.Operator] {
+			return fmt.Errorf("无效的条件格式运算符: %s", r.Operator)
+		}
+		want := 1
+		if r.Operator == ValidationOperatorBetween || r.Operator == ValidationOperatorNotBetween {
+			want = 2
+		}
+		if len(r.Formulas) != want {
+			return fmt.Errorf("条件格式运算符%s需要%d个值", r.Operator, want)
+		}
+	case ConditionalTypeExpression:
+		if len(r.Formulas) != 1 || r.Formulas[0] == "" {
+			return fmt.Errorf("公式条件格式缺少公式")
+		}
+	case ConditionalTypeTop10:
+		if r.Rank < 1 || r.Rank > 1000 || r.Percent && r.Rank > 100 {
+			return fmt.Errorf("无效的前N项数量: %d", r.Rank)
+		}
+	case ConditionalTypeAboveAverage, ConditionalTypeDuplicateValues, ConditionalTypeUniqueValues:
+	case ConditionalTypeContainsText, ConditionalTypeNotContainsText, ConditionalTypeBeginsWith, ConditionalTypeEndsWith:
+		if r.Text == "" {
+			return fmt.Errorf("文本条件格式缺少文本")
+		}
+	case ConditionalTypeColorScale:
+		if r.ColorScale == nil || len(r.ColorScale.Colors) < 2 || len(r.ColorScale.Colors) > 3 ||
+			len(r.ColorScale.Values) != len(r.ColorScale.Colors) {
+			return fmt.Errorf("色阶需要2或3种颜色及相同数量的阈值")
+		}
+		for _, c := range r.ColorScale.Colors {
+			if _, err := normalizeColor(c); err != nil || c == "" {
+				return fmt.Errorf("无效的色阶颜色: %s", c)
+			}
+		}
+	case ConditionalTypeDataBar:
+		if r.DataBar == nil {
+			return fmt.Errorf("数据条规则缺少数据条设置")
+		}
+		if _, err := normalizeColor(r.DataBar.Color); err != nil || r.DataBar.Color == "" {
+			return fmt.Errorf("无效的数据条颜色: %s", r.DataBar.Color)
+		}
+	case ConditionalTypeIconSet:
+		if r.IconSet == nil || len(r.IconSet.Values) < 3 || len(r.IconSet.Values) > 5 {
+			return fmt.Errorf("图标集需要3到5个阈值")
+		}
+	default:
+		return fmt.Errorf("无效的条件格式类型: %s", r.Type)
+	}
+	return nil
+}
+
+// AddConditionalFormat 为单元格区域添加条件格式，规则的差异格式注册到工作簿的样式表
+// sqref可以包含多个以空格或逗号分隔的区域，例如: "D2:D100" 或 "D2:D100,F2:F100"
+func (ws *Worksheet) AddConditionalFormat(sqref string, rules ...*ConditionalFormatRule) error {
+	if err := ws.checkWritable(); err != nil {
+		return err
+	}
+	sqref = strings.Join(strings.Fields(strings.ReplaceAll(sqref, ",", " ")), " ")
+	refs := strings.Fields(sqref)
+	if len(refs) == 0 {
+		return fmt.Errorf("条件格式的应用区域不能为空")
+	}
+	for _, ref := range refs {
+		if _, ok := parseRangeRef(ref); !ok || strings.ContainsAny(ref, "!$") {
+			return fmt.Errorf("无效的条件格式区域: %s", ref)
+		}
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("条件格式至少需要一条规则")
+	}
+
+	// 文本规则的公式引用区域左上角的单元格
+	first, _ := parseRangeRef(refs[0])
+	topLeft := CellRef(max(first.From.Row, 0), max(first.From.Col, 0))
+
+	priority := 0
+	for _, cf := range ws.ConditionalFormats {
+		for _, r := range cf.Rules {
+			priority = max(priority, r.Priority)
+		}
+	}
+
+	for _, rule := range rules {
+		if rule == nil {
+			return fmt.Errorf("条件格式规则不能为空")
+		}
+		if err := rule.check(); err != nil {
+			return err
+		}
+	}
+	for _, rule := range rules {
+		rule.DxfID = -1
+		if rule.Style != nil && dxfConditionalTypes[rule.Type] {
+			if ws.wb == nil {
+				return fmt.Errorf("工作表 %s 不属于任何工作簿，无法注册差异格式", ws.Name)
+			}
+			id, err := ws.wb.Styles.NewDifferentialStyle(rule.Style)
+			if err != nil {
+				return err
+			}
+			rule.DxfID = id
+		}
+		if rule.Text != "" && len(rule.Formulas) == 0 {
+			rule.Formulas = []string{textRuleFormula(rule.Type, rule.Text, topLeft)}
+		}
+		if rule.Priority == 0 {
+			priority++
+			rule.Priority = priority
+		}
+	}
+
+	ws.ConditionalFormats = append(ws.ConditionalFormats, &ConditionalFormat{Sqref: sqref, Rules: rules})
+	return nil
+}
+
+// conditionalFormattingXML 生成conditionalFormatting元素
+func (ws *Worksheet) conditionalFormattingXML() string {
+	xml := ""
+	for _, cf := range ws.ConditionalFormats {
+		xml += "  <conditionalFormatting sqref=\"" + cf.Sqref + "\">\n"
+		for _, rule := range cf.Rules {
+			xml += "    " + rule.toXML() + "\n"
+		}
+		xml += "  </conditionalFormatting>\n"
+	}
+	return xml
+}
+
+// toXML 将规则转换为cfRule元素
+func (r *ConditionalFormatRule) toXML() string {
+	xml := "<cfRule type=\"" + r.Type + "\""
+	if r.DxfID >= 0 && dxfConditionalTypes[r.Type] {
+		xml += fmt.Sprintf(" dxfId=\"%d\"", r.DxfID)
+	}
+	xml += fmt.Sprintf(" priority=\"%d\"", r.Priority)
+	if r.StopIfTrue {
+		xml += " stopIfTrue=\"1\""
+	}
+	switch r.Type {
+	case ConditionalTypeCellIs:
+		xml += " operator=\"" + r.Operator + "\""
+	case ConditionalTypeTop10:
+		if r.Percent {
+			xml += " percent=\"1\""
+		}
+		if r.Bottom {
+			xml += " bottom=\"1\""
+		}
+		xml += fmt.Sprintf(" rank=\"%d\"", r.Rank)
+	case ConditionalTypeAboveAverage:
+		if r.BelowAverage {
+			xml += " aboveAverage=\"0\""
+		}
+		if r.StdDev > 0 {
+			xml += fmt.Sprintf(" stdDev=\"%d\"", r.StdDev)
+		}
+		if r.EqualAverage {
+			xml += " equalAverage=\"1\""
+		}
+	case ConditionalTypeContainsText, ConditionalTypeNotContainsText, ConditionalTypeBeginsWith, ConditionalTypeEndsWith:
+		// 文本规则的运算符与类型名称相同，只有notContainsText对应notContains
+		operator := map[string]string{
+			ConditionalTypeContainsText:    "containsText",
+			ConditionalTypeNotContainsText: "notContains",
+			ConditionalTypeBeginsWith:      "beginsWith",
+			ConditionalTypeEndsWith:        "endsWith",
+		}[r.Type]
+		xml += " operator=\"" + operator + "\" text=\"" + escapeXML(r.Text) + "\""
+	}
+	xml += ">"
+
+	// 公式和cellIs的条件值与单元格公式一样，Excel 2010之后新增的函数需要_xlfn.前缀
+	for _, f := range r.Formulas {
+		xml += "<formula>" + escapeXML(storedFormula(f)) + "</formula>"
+	}
+
+	switch {
+	case r.Type == ConditionalTypeColorScale && r.ColorScale != nil:
+		xml += "<colorScale>"
+		for _, v := range r.ColorScale.Values {
+			xml += v.toXML()
+		}
+		for _, c := range r.ColorScale.Colors {
+			color, _ := normalizeColor(c)
+			xml += "<color rgb=\"" + color + "\" />"
+		}
+		xml += "</colorScale>"
+	case r.Type == ConditionalTypeDataBar && r.DataBar != nil:
+		xml += "<dataBar"
+		if r.DataBar.HideValue {
+			xml += " showValue=\"0\""
+		}
+		color, _ := normalizeColor(r.DataBar.Color)
+		xml += ">" + r.DataBar.Min.toXML() + r.DataBar.Max.toXML() + "<color rgb=\"" + color + "\" /></dataBar>"
+	case r.Type == ConditionalTypeIconSet && r.IconSet != nil:
+		xml += "<iconSet"
+		if r.IconSet.Style != "" {
+			xml += " iconSet=\"" + r.IconSet.Style + "\""
+		}
+		if r.IconSet.HideValue {
+			xml += " showValue=\"0\""
+		}
+		if r.IconSet.Reverse {
+			xml += " reverse=\"1\""
+		}
+		xml += ">"
+		for _, v := range r.IconSet.Values {
+			xml += v.toXML()
+		}
+		xml += "</iconSet>"
+	}
+
+	xml += "</cfRule>"
+	return xml
+}
+
+// toXML 将阈值转换为cfvo元素，公式类型的阈值与单元格公式一样添加_xlfn.前缀
+func (v ConditionalValue) toXML() string {
+	if v.Value == "" {
+		return "<cfvo type=\"" + v.Type + "\" />"
+	}
+	value := v.Value
+	if v.Type == ConditionalValueFormula {
+		value = storedFormula(value)
+	}
+	return "<cfvo type=\"" + v.Type + "\" val=\"" + escapeXML(value) + "\" />"
+}
@@ -0,0 +1,309 @@
+package workbook
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 数据验证的类型
+const (
+	ValidationTypeList       = "list"       // 下拉列表
+	ValidationTypeWhole      = "whole"      // 整数
+	ValidationTypeDecimal    = "decimal"    // 小数
+	ValidationTypeDate       = "date"       // 日期
+	ValidationTypeTime       = "time"       // 时间
+	ValidationTypeTextLength = "textLength" // 文本长度
+	ValidationTypeCustom     = "custom"     // 自定义公式
+)
+
+// 数据验证的比较运算符
+const (
+	ValidationOperatorBetween            = "between"
+	ValidationOperatorNotBetween         = "notBetween"
+	ValidationOperatorEqual              = "equal"
+	ValidationOperatorNotEqual           = "notEqual"
+	ValidationOperatorLessThan           = "lessThan"
+	ValidationOperatorLessThanOrEqual    = "lessThanOrEqual"
+	ValidationOperatorGreaterThan        = "greaterThan"
+	ValidationOperatorGreaterThanOrEqual = "greaterThanOrEqual"
+)
+
+// 出错警告的样式
+const (
+	ValidationErrorStop        = "stop"        // 停止，拒绝输入
+	ValidationErrorWarning     = "warning"     // 警告，可以选择继续
+	ValidationErrorInformation = "information" // 信息，仅提示
+)
+
+// Excel对数据验证的长度限制
+const (
+	maxValidationListLength  = 255
+	maxValidationTitleLength = 32
+	maxValidationTextLength  = 255
+)
+
+var validValidationTypes = map[string]bool{
+	ValidationTypeList: true, ValidationTypeWhole: true, ValidationTypeDecimal: true,
+	ValidationTypeDate: true, ValidationTypeTime: true, ValidationTypeTextLength: true,
+	ValidationTypeCustom: true,
+}
+
+var validValidationOperators = map[string]bool{
+	ValidationOperatorBetween: true, ValidationOperatorNotBetween: true,
+	ValidationOperatorEqual: true, ValidationOperatorNotEqual: true,
+	ValidationOperatorLessThan: true, ValidationOperatorLessThanOrEqual: true,
+	ValidationOperatorGreaterThan: true, ValidationOperatorGreaterThanOrEqual: true,
+}
+
+// DataValidation 表示单元格区域的数据验证规则
+type DataValidation struct {
+	Sqref            string // 应用的区域，多个区域以空格分隔，例如: "A2:A100 C2:C100"
+	Type             string // 验证类型，见ValidationType常量
+	Operator         string // 比较运算符，为空时Excel按between处理
+	Formula1         string // 第一个条件值或公式，不带等号
+	Formula2         string // between和notBetween的第二个条件值
+	AllowBlank       bool   // 允许空值
+	HideDropDown     bool   // 隐藏下拉箭头，仅对list类型有效
+	ShowInputMessage bool
+	PromptTitle      string
+	Prompt           string
+	ShowErrorMessage bool
+	ErrorStyle       string // stop, warning, information
+	ErrorTitle       string
+	Error            string
+	list             []string // SetList设置的选项，用于检查逗号和总长度
+}
+
+// NewDataValidation 创建一个指定类型的数据验证规则，默认允许空值并在输入无效时显示出错警告
+func NewDataValidation(validationType string) *DataValidation {
+	return &DataValidation{
+		Type:             validationType,
+		AllowBlank:       true,
+		ShowErrorMessage: true,
+	}
+}
+
+// NewListValidation 创建一个下拉列表验证规则，选项直接写在规则中
+func NewListValidation(values ...string) *DataValidation {
+	return NewDataValidation(ValidationTypeList).SetList(values...)
+}
+
+// NewListRangeValidation 创建一个下拉列表验证规则，选项来自单元格区域
+// 例如: "$A$1:$A$10" 或 "'Cost Centers'!$A$2:$A$50"
+func NewListRangeValidation(ref string) *DataValidation {
+	return NewDataValidation(ValidationTypeList).SetListRange(ref)
+}
+
+// NewCustomValidation 创建一个自定义公式验证规则，公式结果为TRUE时输入有效
+// 公式中的相对引用相对于区域左上角的单元格
+func NewCustomValidation(formula string) *DataValidation {
+	dv := NewDataValidation(ValidationTypeCustom)
+	dv.Formula1 = strings.TrimPrefix(formula, "=")
+	return dv
+}
+
+// SetList 设置下拉列表的选项，选项中的双引号会被转义
+// Excel用逗号分隔选项，选项本身不能包含逗号，选项和分隔符的总长度不能超过255个字符，添加规则时检查
+func (dv *DataValidation) SetList(values ...string) *DataValidation {
+	items := make([]string, len(values))
+	for i, v := range values {
+		items[i] = strings.ReplaceAll(v, "\"", "\"\"")
+	}
+	dv.Formula1 = "\"" + strings.Join(items, ",") + "\""
+	dv.list = append([]string(nil), values...)
+	return dv
+}
+
+// SetListRange 设置下拉列表选项所在的单元格区域
+func (dv *DataValidation) SetListRange(ref string) *DataValidation {
+	dv.Formula1 = strings.TrimPrefix(ref, "=")
+	dv.list = nil
+	return dv
+}
+
+// SetCondition 设置比较运算符和条件值，between和notBetween需要两个值
+// 值可以是数字、time.Time或公式字符串（例如 "B1" 或 "TODAY()"）
+func (dv *DataValidation) SetCondition(operator string, values ...interface{}) *DataValidation {
+	dv.Operator = operator
+	dv.Formula1, dv.Formula2 = "", ""
+	if len(values) > 0 {
+		dv.Formula1 = dv.formulaValue(values[0])
+	}
+	if len(values) > 1 {
+		dv.Formula2 = dv.formulaValue(values[1])
+	}
+	return dv
+}
+
+// SetAllowBlank 设置是否允许空值
+func (dv *DataValidation) SetAllowBlank(allow bool) *DataValidation {
+	dv.AllowBlank = allow
+	return dv
+}
+
+// SetDropDown 设置下拉列表是否显示下拉箭头
+func (dv *DataValidation) SetDropDown(show bool) *DataValidation {
+	dv.HideDropDown = !show
+	return dv
+}
+
+// SetInputMessage 设置选中单元格时显示的输入提示
+func (dv *DataValidation) SetInputMessage(title, prompt string) *DataValidation {
+	dv.ShowInputMessage = true
+	dv.PromptTitle = title
+	dv.Prompt = prompt
+	return dv
+}
+
+// SetErrorMessage 设置输入无效时显示的出错警告，style为stop、warning或information
+func (dv *DataValidation) SetErrorMessage(style, title, message string) *DataValidation {
+	dv.ShowErrorMessage = true
+	dv.ErrorStyle = style
+	dv.ErrorTitle = title
+	dv.Error = message
+	return dv
+}
+
+// formulaValue 将条件值转换为公式文本，日期和时间转换为序列值
+func (dv *DataValidation) formulaValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimPrefix(v, "=")
+	case time.Time:
+		serial := timeToSerial(v)
+		switch dv.Type {
+		case ValidationTypeDate:
+			serial = math.Floor(serial)
+		case ValidationTypeTime:
+			serial -= math.Floor(serial)
+		}
+		return formatNumber(serial)
+	}
+	if f, ok := toFloat(value); ok {
+		return formatNumber(f)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// check 检查验证规则是否有效
+func (dv *DataValidation) check() error {
+	if dv.Sqref == "" {
+		return fmt.Errorf("数据验证的应用区域不能为空")
+	}
+	for _, ref := range strings.Fields(dv.Sqref) {
+		if _, ok := parseRangeRef(ref); !ok || strings.ContainsAny(ref, "!$") {
+			return fmt.Errorf("无效的数据验证区域: %s", ref)
+		}
+	}
+	if !validValidationTypes[dv.Type] {
+		return fmt.Errorf("无效的数据验证类型: %s", dv.Type)
+	}
+	if dv.Operator != "" && !validValidationOperators[dv.Operator] {
+		return fmt.Errorf("无效的数据验证运算符: %s", dv.Operator)
+	}
+	if dv.Formula1 == "" {
+		return fmt.Errorf("数据验证缺少条件值")
+	}
+	if (dv.Operator == "" || dv.Operator == ValidationOperatorBetween || dv.Operator == ValidationOperatorNotBetween) &&
+		dv.Type != ValidationTypeList && dv.Type != ValidationTypeCustom && dv.Formula2 == "" {
+		return fmt.Errorf("数据验证的between条件需要两个值")
+	}
+	// 直接写在规则中的列表选项不能包含逗号，选项和分隔符的总长度不能超过255个字符
+	if dv.Type == ValidationTypeList && strings.HasPrefix(dv.Formula1, "\"") {
+		length := utf8.RuneCountInString(dv.Formula1) - 2
+		if dv.list != nil {
+			for _, item := range dv.list {
+				if strings.Contains(item, ",") {
+					return fmt.Errorf("下拉列表选项不能包含逗号: %s", item)
+				}
+			}
+			length = utf8.RuneCountInString(strings.Join(dv.list, ","))
+		}
+		if length > maxValidationListLength {
+			return fmt.Errorf("下拉列表选项的总长度超过%d个字符", maxValidationListLength)
+		}
+	}
+	if dv.ErrorStyle != "" && dv.ErrorStyle != ValidationErrorStop &&
+		dv.ErrorStyle != ValidationErrorWarning && dv.ErrorStyle != ValidationErrorInformation {
+		return fmt.Errorf("无效的出错警告样式: %s", dv.ErrorStyle)
+	}
+	if utf8.RuneCountInString(dv.PromptTitle) > maxValidationTitleLength ||
+		utf8.RuneCountInString(dv.ErrorTitle) > maxValidationTitleLength {
+		return fmt.Errorf("数据验证的提示标题超过%d个字符", maxValidationTitleLength)
+	}
+	if utf8.RuneCountInString(dv.Prompt) > maxValidationTextLength ||
+		utf8.RuneCountInString(dv.Error) > maxValidationTextLength {
+		return fmt.Errorf("数据验证的提示信息超过%d个字符", maxValidationTextLength)
+	}
+	return nil
+}
+
+// AddDataValidation 为单元格区域添加数据验证规则
+// sqref可以包含多个以空格或逗号分隔的区域，例如: "B2:B100" 或 "B2:B100,D2:D100"
+func (ws *Worksheet) AddDataValidation(sqref string, rule *DataValidation) error {
+	if err := ws.checkWritable(); err != nil {
+		return err
+	}
+	if rule == nil {
+		return fmt.Errorf("数据验证规则不能为空")
+	}
+	rule.Sqref = strings.Join(strings.Fields(strings.ReplaceAll(sqref, ",", " ")), " ")
+	if err := rule.check(); err != nil {
+		return err
+	}
+	ws.DataValidations = append(ws.DataValidations, rule)
+	return nil
+}
+
+// dataValidationsXML 生成dataValidations元素
+func (ws *Worksheet) dataValidationsXML() string {
+	if len(ws.DataValidations) == 0 {
+		return ""
+	}
+	xml := fmt.Sprintf("  <dataValidations count=\"%d\">\n", len(ws.DataValidations))
+	for _, dv := range ws.DataValidations {
+		xml += "    <dataValidation type=\"" + dv.Type + "\""
+		if dv.ErrorStyle != "" {
+			xml += " errorStyle=\"" + dv.ErrorStyle + "\""
+		}
+		if dv.Operator != "" {
+			xml += " operator=\"" + dv.Operator + "\""
+		}
+		if dv.AllowBlank {
+			xml += " allowBlank=\"1\""
+		}
+		// showDropDown为1时表示隐藏下拉箭头
+		if dv.HideDropDown {
+			xml += " showDropDown=\"1\""
+		}
+		if dv.ShowInputMessage {
+			xml += " showInputMessage=\"1\""
+		}
+		if dv.ShowErrorMessage {
+			xml += " showErrorMessage=\"1\""
+		}
+		if dv.ErrorTitle != "" {
+			xml += " errorTitle=\"" + escapeXML(dv.ErrorTitle) + "\""
+		}
+		if dv.Error != "" {
+			xml += " error=\"" + escapeXML(dv.Error) + "\""
+		}
+		if dv.PromptTitle != "" {
+			xml += " promptTitle=\"" + escapeXML(dv.PromptTitle) + "\""
+		}
+		if dv.Prompt != "" {
+			xml += " prompt=\"" + escapeXML(dv.Prompt) + "\""
+		}
+		xml += " sqref=\"" + dv.Sqref + "\">"
+		xml += "<formula1>" + escapeXML(storedFormula(dv.Formula1)) + "</formula1>"
+		if dv.Formula2 != "" {
+			xml += "<formula2>" + escapeXML(storedFormula(dv.Formula2)) + "</formula2>"
+		}
+		xml += "</dataValidation>\n"
+	}
+	xml += "  </dataValidations>\n"
+	return xml
+}
@@ -0,0 +1,227 @@
+package workbook
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 内置名称
+const (
+	DefinedNamePrintArea      = "_xlnm.Print_Area"
+	DefinedNamePrintTitles    = "_xlnm.Print_Titles"
+	DefinedNameFilterDatabase = "_xlnm._FilterDatabase"
+)
+
+// DefinedName 表示工作簿中定义的名称
+type DefinedName struct {
+	Name     string
+	RefersTo string // 名称引用的公式，不带前导等号，例如 "Sheet1!$B$1" 或 "0.13"
+	Scope    string // 名称所属的工作表，为空表示整个工作簿
+	Comment  string
+	Hidden   bool
+}
+
+// isValidName 判断名称是否可以用作定义的名称或表格名称：以字母、下划线或反斜杠开头，
+// 只包含字母、数字、下划线和句点，并且不能与单元格引用相同
+func isValidName(name string) bool {
+	if name == "" || len([]rune(name)) > 255 {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_' || r == '\\':
+		case i > 0 && (unicode.IsDigit(r) || r == '.'):
+		default:
+			return false
+		}
+	}
+	if ref, _ := parseRefText(name); ref != nil {
+		return false
+	}
+	upper := strings.ToUpper(name)
+	return upper != "R" && upper != "C" && upper != "TRUE" && upper != "FALSE"
+}
+
+// checkRefersTo 检查名称引用的公式，打印区域等内置名称可以是以逗号分隔的多个区域
+func checkRefersTo(refersTo string) error {
+	if refersTo == "" {
+		return fmt.Errorf("名称引用的公式不能为空")
+	}
+	if _, err := parseFormula(refersTo); err == nil {
+		return nil
+	}
+	for _, part := range strings.Split(refersTo, ",") {
+		if _, ok := parseSheetRef(part); !ok {
+			return fmt.Errorf("无效的名称引用: %s", refersTo)
+		}
+	}
+	return nil
+}
+
+// check 检查定义的名称是否有效
+func (dn *DefinedName) check(wb *Workbook) error {
+	if !isValidName(dn.Name) {
+		return fmt.Errorf("无效的名称: %s", dn.Name)
+	}
+	if dn.Scope != "" && wb.GetWorksheet(dn.Scope) == nil {
+		return fmt.Errorf("名称 %s 所属的工作表 %s 不存在", dn.Name, dn.Scope)
+	}
+	return checkRefersTo(dn.RefersTo)
+}
+
+// DefineName 定义一个名称，scope为工作表名称时名称只在该工作表中有效，为空时在整个工作簿中有效
+// 名称可以在公式中代替引用或常量，例如: wb.DefineName("TaxRate", "参数!$B$1", "") 之后使用 "=C2*TaxRate"
+func (wb *Workbook) DefineName(name, refersTo, scope string) (*DefinedName, error) {
+	dn := &DefinedName{Name: name, RefersTo: strings.TrimPrefix(refersTo, "="), Scope: scope}
+	if err := dn.check(wb); err != nil {
+		return nil, err
+	}
+	if wb.GetDefinedName(name, scope) != nil {
+		return nil, fmt.Errorf("名称 %s 已存在", name)
+	}
+	if wb.GetTable(name) != nil {
+		return nil, fmt.Errorf("名称 %s 与表格名称重复", name)
+	}
+	if ws := wb.GetWorksheet(scope); ws != nil {
+		dn.Scope = ws.Name
+	}
+	wb.DefinedNames = append(wb.DefinedNames, dn)
+	return dn, nil
+}
+
+// GetDefinedName 返回scope范围内定义的名称，名称不区分大小写，不存在时返回nil
+// scope为空时返回工作簿范围的名称
+func (wb *Workbook) GetDefinedName(name, scope string) *DefinedName {
+	for _, dn := range wb.DefinedNames {
+		if strings.EqualFold(dn.Name, name) && strings.EqualFold(dn.Scope, scope) {
+			return dn
+		}
+	}
+	return nil
+}
+
+// DeleteDefinedName 删除scope范围内定义的名称
+func (wb *Workbook) DeleteDefinedName(name, scope string) {
+	for i, dn := range wb.DefinedNames {
+		if strings.EqualFold(dn.Name, name) && strings.EqualFold(dn.Scope, scope) {
+			wb.DefinedNames = append(wb.DefinedNames[:i], wb.DefinedNames[i+1:]...)
+			return
+		}
+	}
+}
+
+// setBuiltinName 设置工作表范围的内置名称，refersTo为空时删除该名称
+func (ws *Worksheet) setBuiltinName(name, refersTo string) error {
+	if ws.wb == nil {
+		return fmt.Errorf("工作表 %s 不属于任何工作簿，无法定义名称", ws.Name)
+	}
+	ws.wb.DeleteDefinedName(name, ws.Name)
+	if refersTo == "" {
+		return nil
+	}
+	_, err := ws.wb.DefineName(name, refersTo, ws.Name)
+	return err
+}
+
+// absoluteRefs 将以逗号分隔的区域转换为带工作表名称的绝对引用
+func (ws *Worksheet) absoluteRefs(refs string) (string, error) {
+	var parts []string
+	for _, ref := range strings.Split(refs, ",") {
+		ref = strings.TrimSpace(ref)
+		r, ok := parseRangeRef(ref)
+		if !ok || strings.ContainsAny(ref, "!") {
+			return "", fmt.Errorf("无效的区域: %s", ref)
+		}
+		r.From.AbsRow, r.From.AbsCol, r.To.AbsRow, r.To.AbsCol = true, true, true, true
+		parts = append(parts, quoteSheetName(ws.Name)+"!"+r.areaString())
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// SetPrintArea 设置打印区域，多个区域以逗号分隔，例如 "A1:G20" 或 "A1:D10,F1:H10"
+// ref为空时取消打印区域
+func (ws *Worksheet) SetPrintArea(ref string) error {
+	if ref == "" {
+		return ws.setBuiltinName(DefinedNamePrintArea, "")
+	}
+	refersTo, err := ws.absoluteRefs(ref)
+	if err != nil {
+		return fmt.Errorf("无效的打印区域: %w", err)
+	}
+	return ws.setBuiltinName(DefinedNamePrintArea, refersTo)
+}
+
+// SetPrintTitles 设置在每一页重复打印的标题行和标题列，例如 rows为 "1:2"，cols为 "A:A"
+// 两者都为空时取消打印标题
+func (ws *Worksheet) SetPrintTitles(rows, cols string) error {
+	var parts []string
+	if rows != "" {
+		r, ok := parseRangeRef(rows)
+		if !ok || r.From.Col >= 0 || strings.Contains(rows, "!") {
+			return fmt.Errorf("无效的标题行: %s", rows)
+		}
+		refersTo, _ := ws.absoluteRefs(rows)
+		parts = append(parts, refersTo)
+	}
+	if cols != "" {
+		r, ok := parseRangeRef(cols)
+		if !ok || r.From.Row >= 0 || strings.Contains(cols, "!") {
+			return fmt.Errorf("无效的标题列: %s", cols)
+		}
+		refersTo, _ := ws.absoluteRefs(cols)
+		parts = append(parts, refersTo)
+	}
+	return ws.setBuiltinName(DefinedNamePrintTitles, strings.Join(parts, ","))
+}
+
+// sheetIndex 返回工作表在工作簿中的序号，不存在时返回-1
+func (wb *Workbook) sheetIndex(name string) int {
+	for i, ws := range wb.Worksheets {
+		if strings.EqualFold(ws.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// definedNamesXML 生成definedNames元素，设置了自动筛选的工作表自动添加隐藏的_FilterDatabase名称
+func (wb *Workbook) definedNamesXML() string {
+	names := make([]*DefinedName, 0, len(wb.DefinedNames))
+	names = append(names, wb.DefinedNames...)
+	for _, ws := range wb.Worksheets {
+		if ws.AutoFilter != nil && wb.GetDefinedName(DefinedNameFilterDatabase, ws.Name) == nil {
+			refersTo, err := ws.absoluteRefs(ws.AutoFilter.Ref)
+			if err == nil {
+				names = append(names, &DefinedName{Name: DefinedNameFilterDatabase, RefersTo: refersTo, Scope: ws.Name, Hidden: true})
+			}
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	xml := "  <definedNames>\n"
+	for _, dn := range names {
+		index := -1
+		if dn.Scope != "" {
+			// 所属工作表已不存在的名称不再写出
+			if index = wb.sheetIndex(dn.Scope); index < 0 {
+				continue
+			}
+		}
+		xml += "    <definedName name=\"" + escapeXML(dn.Name) + "\""
+		if index >= 0 {
+			xml += fmt.Sprintf(" localSheetId=\"%d\"", index)
+		}
+		if dn.Comment != "" {
+			xml += " comment=\"" + escapeXML(dn.Comment) + "\""
+		}
+		if dn.Hidden {
+			xml += " hidden=\"1\""
+		}
+		xml += ">" + escapeXML(dn.RefersTo) + "</definedName>\n"
+	}
+	xml += "  </definedNames>\n"
+	return xml
+}
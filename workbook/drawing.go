@@ -0,0 +1,106 @@
+package workbook
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/landaiqing/go-dockit/opc"
+)
+
+// 绘图和图表部件的内容类型和关系类型
+const (
+	contentTypeDrawing = "application/vnd.openxmlformats-officedocument.drawing+xml"
+	contentTypeChart   = "application/vnd.openxmlformats-officedocument.drawingml.chart+xml"
+	relTypeDrawing     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/drawing"
+	relTypeChart       = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
+)
+
+// anchorPoint 表示绘图对象在工作表中的锚点，行列从0开始，偏移量单位为EMU
+type anchorPoint struct {
+	Col    int
+	Row    int
+	ColOff int64
+	RowOff int64
+}
+
+// parseAnchor 将A1格式的单元格引用解析为锚点
+func parseAnchor(cellRef string) (anchorPoint, error) {
+	if !isValidCellRef(cellRef) {
+		return anchorPoint{}, fmt.Errorf("无效的锚定单元格: %s", cellRef)
+	}
+	row, col, _ := ParseCellRef(cellRef)
+	return anchorPoint{Col: col, Row: row}, nil
+}
+
+// toXML 生成xdr:from或xdr:to元素
+func (a anchorPoint) toXML(tag string) string {
+	return fmt.Sprintf("<xdr:%s><xdr:col>%d</xdr:col><xdr:colOff>%d</xdr:colOff><xdr:row>%d</xdr:row><xdr:rowOff>%d</xdr:rowOff></xdr:%s>",
+		tag, a.Col, a.ColOff, a.Row, a.RowOff, tag)
+}
+
+// hasDrawing 判断工作表是否需要绘图部件
+func (ws *Worksheet) hasDrawing() bool {
+	return len(ws.Charts) > 0 || len(ws.Pictures) > 0
+}
+
+// addDrawingParts 为工作表添加绘图部件及其引用的图表部件和图片，绘图部件内的关系ID记录到state中
+// index为绘图部件的序号，charts为已写出的图表数量，返回写出后的图表数量
+func (wb *Workbook) addDrawingParts(pkg *opc.Package, ws *Worksheet, state *saveState, index, charts int) int {
+	drawingName := fmt.Sprintf("xl/drawings/drawing%d.xml", index)
+	drawingPart := opc.NewPart(drawingName, contentTypeDrawing, nil)
+
+	for _, chart := range ws.Charts {
+		charts++
+		state.charts[chart] = drawingPart.Relationships.Add(relTypeChart, fmt.Sprintf("../charts/chart%d.xml", charts)).ID
+		pkg.AddPart(fmt.Sprintf("xl/charts/chart%d.xml", charts), contentTypeChart, []byte(chart.ToXML(wb)))
+	}
+
+	for _, pic := range ws.Pictures {
+		state.pictures[pic] = drawingPart.Relationships.Add(opc.RelTypeImage, "../"+pic.media.Path).ID
+		switch {
+		case pic.Hyperlink == "":
+		case strings.HasPrefix(pic.Hyperlink, "#"):
+			state.pictureLinks[pic] = drawingPart.Relationships.Add(opc.RelTypeHyperlink, pic.Hyperlink).ID
+		default:
+			state.pictureLinks[pic] = drawingPart.Relationships.AddExternal(opc.RelTypeHyperlink, pic.Hyperlink).ID
+		}
+	}
+
+	drawingPart.Data = []byte(ws.drawingXML(state))
+	pkg.PutPart(drawingPart)
+	return charts
+}
+
+// drawingXML 生成工作表的绘图部件
+func (ws *Worksheet) drawingXML(state *saveState) string {
+	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
+	xml += "<xdr:wsDr xmlns:xdr=\"http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing\" xmlns:a=\"http://schemas.openxmlformats.org/drawingml/2006/main\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\">\n"
+
+	id := 1
+	for i, chart := range ws.Charts {
+		id++
+		name := chart.Spec.Name
+		if name == "" {
+			name = fmt.Sprintf("Chart %d", i+1)
+		}
+		xml += "  <xdr:twoCellAnchor editAs=\"oneCell\">\n"
+		xml += "    " + chart.From.toXML("from") + "\n"
+		xml += "    " + chart.To.toXML("to") + "\n"
+		xml += "    <xdr:graphicFrame macro=\"\">\n"
+		xml += fmt.Sprintf("      <xdr:nvGraphicFramePr><xdr:cNvPr id=\"%d\" name=\"%s\" /><xdr:cNvGraphicFramePr /></xdr:nvGraphicFramePr>\n", id, escapeXML(name))
+		xml += "      <xdr:xfrm><a:off x=\"0\" y=\"0\" /><a:ext cx=\"0\" cy=\"0\" /></xdr:xfrm>\n"
+		xml += "      <a:graphic><a:graphicData uri=\"http://schemas.openxmlformats.org/drawingml/2006/chart\">"
+		xml += "<c:chart xmlns:c=\"http://schemas.openxmlformats.org/drawingml/2006/chart\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\" r:id=\"" + state.charts[chart] + "\" />"
+		xml += "</a:graphicData></a:graphic>\n"
+		xml += "    </xdr:graphicFrame>\n"
+		xml += "    <xdr:clientData />\n"
+		xml += "  </xdr:twoCellAnchor>\n"
+	}
+	for _, pic := range ws.Pictures {
+		id++
+		xml += pic.toXML(id, state)
+	}
+
+	xml += "</xdr:wsDr>"
+	return xml
+}
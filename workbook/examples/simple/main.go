@@ -27,50 +27,71 @@ func main() {
 	ws.AddColumn(6, 6, 15) // F列 - 百分比列
 	ws.AddColumn(7, 7, 15) // G列 - 科学计数列
 
-	// 创建标题样式
-	headerStyleID := wb.Styles.CreateStyle(
-		"Arial", 12, true, false, false, "FF000000", // 字体
-		"solid", "FFD3D3D3", // 填充
-		"thin", "FF000000", // 边框
-		"",                        // 数字格式
-		"center", "center", false, // 对齐
-	)
-
-	// 创建日期格式样式 - 使用标准的Excel内置格式
-	dateStyleID := wb.Styles.CreateStyle(
-		"", 0, false, false, false, "", // 字体
-		"", "", // 填充
-		"thin", "FF000000", // 边框
-		"[$-804]yyyy\"年\"mm\"月\"dd\"日\"", // 中文日期格式
-		"center", "bottom", false,        // 对齐
-	)
+	// 所有数据单元格使用的细边框
+	thinBorder := &workbook.Border{
+		Left:   &workbook.BorderStyle{Style: "thin", Color: "FF000000"},
+		Right:  &workbook.BorderStyle{Style: "thin", Color: "FF000000"},
+		Top:    &workbook.BorderStyle{Style: "thin", Color: "FF000000"},
+		Bottom: &workbook.BorderStyle{Style: "thin", Color: "FF000000"},
+	}
+
+	// 创建标题样式：粗体、灰色填充、边框和居中可以同时生效
+	headerStyleID, err := wb.Styles.NewStyle(&workbook.Style{
+		Font:      &workbook.Font{Name: "Arial", Size: 12, Bold: true, Color: "FF000000"},
+		Fill:      &workbook.Fill{PatternType: "solid", FgColor: "FFD3D3D3"},
+		Border:    thinBorder,
+		Alignment: &workbook.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	if err != nil {
+		fmt.Println("创建样式时出错:", err)
+		return
+	}
+
+	// 创建编号样式
+	idStyleID, _ := wb.Styles.NewStyle(&workbook.Style{
+		Border:    thinBorder,
+		Alignment: &workbook.Alignment{Horizontal: "center"},
+	})
+
+	// 创建日期格式样式
+	dateStyleID, _ := wb.Styles.NewStyle(&workbook.Style{
+		Border:    thinBorder,
+		NumFmt:    "yyyy\"年\"mm\"月\"dd\"日\"", // 中文日期格式
+		Alignment: &workbook.Alignment{Horizontal: "center", Vertical: "bottom"},
+	})
 
 	// 创建人民币货币格式样式
-	currencyStyleID := wb.Styles.CreateStyle(
-		"", 0, false, false, false, "", // 字体
-		"", "", // 填充
-		"thin", "FF000000", // 边框
-		"¥#,##0.00",              // 人民币货币格式，不使用引号
-		"right", "bottom", false, // 对齐
-	)
+	currencyStyleID, _ := wb.Styles.NewStyle(&workbook.Style{
+		Border:    thinBorder,
+		NumFmt:    "¥#,##0.00",
+		Alignment: &workbook.Alignment{Horizontal: "right", Vertical: "bottom"},
+	})
 
 	// 创建百分比格式样式
-	percentStyleID := wb.Styles.CreateStyle(
-		"", 0, false, false, false, "", // 字体
-		"", "", // 填充
-		"thin", "FF000000", // 边框
-		"0.00%",                  // 百分比格式
-		"right", "bottom", false, // 对齐
-	)
-
-	// 创建科学计数格式样式 - 使用正确的内置格式
-	scientificStyleID := wb.Styles.CreateStyle(
-		"", 0, false, false, false, "", // 字体
-		"", "", // 填充
-		"thin", "FF000000", // 边框
-		"0.00E+00",               // 科学计数格式
-		"right", "bottom", false, // 对齐
-	)
+	percentStyleID, _ := wb.Styles.NewStyle(&workbook.Style{
+		Border:    thinBorder,
+		NumFmt:    "0.00%",
+		Alignment: &workbook.Alignment{Horizontal: "right", Vertical: "bottom"},
+	})
+
+	// 创建科学计数格式样式
+	scientificStyleID, _ := wb.Styles.NewStyle(&workbook.Style{
+		Border:    thinBorder,
+		NumFmt:    "0.00E+00",
+		Alignment: &workbook.Alignment{Horizontal: "right", Vertical: "bottom"},
+	})
+
+	// 创建合计行样式，相同的定义会复用已注册的样式
+	totalStyleID, _ := wb.Styles.NewStyle(&workbook.Style{
+		Font:      &workbook.Font{Bold: true},
+		Alignment: &workbook.Alignment{Horizontal: "right"},
+	})
+
+	// 创建合并单元格标题样式
+	titleStyleID, _ := wb.Styles.NewStyle(&workbook.Style{
+		Font:      &workbook.Font{Size: 14, Bold: true},
+		Alignment: &workbook.Alignment{Horizontal: "center", Vertical: "center"},
+	})
 
 	// 添加标题行
 	headers := []string{"编号", "产品名称", "单价(¥)", "数量", "日期", "利润率", "密度"}
@@ -79,16 +100,13 @@ func main() {
 		_ = ws.AddCell(cellRef, header)
 
 		// 设置标题单元格样式
-		_ = ws.SetCellStyle(cellRef, &workbook.CellStyle{
-			FontID:   headerStyleID,
-			BorderID: 0,
-			Alignment: &workbook.Alignment{
-				Horizontal: "center",
-				Vertical:   "center",
-			},
-		})
+		_ = ws.SetCellStyleID(cellRef, headerStyleID)
 	}
 
+	// 冻结标题行，滚动时标题始终可见
+	_ = ws.FreezePanes("A2")
+	_ = ws.SetTabColor("FF4472C4")
+
 	// 添加数据行
 	data := [][]interface{}{
 		{1, "笔记本电脑", 5999.99, 10, time.Now(), 0.15, 2500000},
@@ -110,76 +128,53 @@ func main() {
 			// 根据列类型设置不同的样式
 			switch colIdx {
 			case 0: // 编号列
-				_ = ws.SetCellStyle(cellRef, &workbook.CellStyle{
-					Alignment: &workbook.Alignment{
-						Horizontal: "center",
-					},
-				})
+				_ = ws.SetCellStyleID(cellRef, idStyleID)
 			case 2: // 单价列 - 使用人民币格式
-				_ = ws.SetCellStyle(cellRef, &workbook.CellStyle{
-					NumberFormatID: currencyStyleID,
-					Alignment: &workbook.Alignment{
-						Horizontal: "right",
-					},
-				})
+				_ = ws.SetCellStyleID(cellRef, currencyStyleID)
 			case 4: // 日期列
-				_ = ws.SetCellStyle(cellRef, &workbook.CellStyle{
-					NumberFormatID: dateStyleID,
-					Alignment: &workbook.Alignment{
-						Horizontal: "center",
-					},
-				})
+				_ = ws.SetCellStyleID(cellRef, dateStyleID)
 			case 5: // 利润率列 - 使用百分比格式
-				_ = ws.SetCellStyle(cellRef, &workbook.CellStyle{
-					NumberFormatID: percentStyleID,
-					Alignment: &workbook.Alignment{
-						Horizontal: "right",
-					},
-				})
+				_ = ws.SetCellStyleID(cellRef, percentStyleID)
 			case 6: // 密度列 - 使用科学计数格式
-				_ = ws.SetCellStyle(cellRef, &workbook.CellStyle{
-					NumberFormatID: scientificStyleID,
-					Alignment: &workbook.Alignment{
-						Horizontal: "right",
-					},
-				})
+				_ = ws.SetCellStyleID(cellRef, scientificStyleID)
 			}
 		}
 	}
 
+	// 定义名称，公式中可以使用名称代替区域
+	if _, err := wb.DefineName("销售数量", "数据报表!$D$2:$D$6", ""); err != nil {
+		fmt.Println("定义名称时出错:", err)
+	}
+
 	// 添加合计行
 	ws.AddCell("A7", "合计")
 	ws.SetCellFormula("C7", "SUM(C2:C6)")
-	ws.SetCellFormula("D7", "SUM(D2:D6)")
+	ws.SetCellFormula("D7", "SUM(销售数量)")      // 使用定义的名称
 	ws.SetCellFormula("F7", "AVERAGE(F2:F6)") // 计算平均利润率
 
 	// 设置合计行样式
-	ws.SetCellStyle("A7", &workbook.CellStyle{
-		FontID: 0,
-		Alignment: &workbook.Alignment{
-			Horizontal: "right",
-		},
-	})
-	ws.SetCellStyle("C7", &workbook.CellStyle{
-		FontID:         0,
-		NumberFormatID: currencyStyleID, // 使用人民币格式
-		Alignment: &workbook.Alignment{
-			Horizontal: "right",
-		},
-	})
-	ws.SetCellStyle("D7", &workbook.CellStyle{
-		FontID: 0,
-		Alignment: &workbook.Alignment{
-			Horizontal: "center",
-		},
-	})
-	ws.SetCellStyle("F7", &workbook.CellStyle{
-		FontID:         0,
-		NumberFormatID: percentStyleID, // 使用百分比格式
-		Alignment: &workbook.Alignment{
-			Horizontal: "right",
-		},
-	})
+	ws.SetCellStyleID("A7", totalStyleID)
+	ws.SetCellStyleID("C7", currencyStyleID)
+	ws.SetCellStyleID("D7", totalStyleID)
+	ws.SetCellStyleID("F7", percentStyleID)
+
+	// 打印区域和每页重复打印的标题行
+	_ = ws.SetPrintArea("A1:G9")
+	_ = ws.SetPrintTitles("1:1", "")
+
+	// 页面设置：A4横向，所有列缩放到一页宽，页脚显示页码和打印日期
+	pageSetup := workbook.NewPageSetup().
+		SetPaperSize(workbook.PaperA4).
+		SetOrientation(workbook.OrientationLandscape).
+		SetFitToPage(1, 0).
+		SetCentered(true, false).
+		SetHeaderFooter(
+			workbook.HeaderFooterSections("", "销售数据报表", ""),
+			workbook.HeaderFooterSections("", "第 &P 页，共 &N 页", "&D"),
+		)
+	if err := ws.SetPageSetup(pageSetup); err != nil {
+		fmt.Println("设置页面时出错:", err)
+	}
 
 	// 添加第二个工作表 - 用于额外的测试
 	testSheet := wb.AddWorksheet("格式测试")
@@ -192,12 +187,7 @@ func main() {
 	for i, header := range testHeaders {
 		cellRef := workbook.CellRef(0, i)
 		testSheet.AddCell(cellRef, header)
-		testSheet.SetCellStyle(cellRef, &workbook.CellStyle{
-			FontID: headerStyleID,
-			Alignment: &workbook.Alignment{
-				Horizontal: "center",
-			},
-		})
+		testSheet.SetCellStyleID(cellRef, headerStyleID)
 	}
 
 	// 1. 测试日期格式
@@ -208,9 +198,7 @@ func main() {
 	testSheet.AddCell("D2", fmt.Sprintf("%d", dateStyleID))
 	testSheet.AddCell("E2", "[$-804]yyyy\"年\"mm\"月\"dd\"日\"")
 	testSheet.AddCell("G2", "应显示为中文日期格式")
-	testSheet.SetCellStyle("B2", &workbook.CellStyle{
-		NumberFormatID: dateStyleID,
-	})
+	testSheet.SetCellStyleID("B2", dateStyleID)
 
 	// 2. 测试科学计数格式 - 大数值
 	testSheet.AddCell("A3", "科学计数")
@@ -219,9 +207,7 @@ func main() {
 	testSheet.AddCell("D3", fmt.Sprintf("%d", scientificStyleID))
 	testSheet.AddCell("E3", "0.00E+00")
 	testSheet.AddCell("G3", "应显示为1.23E+07")
-	testSheet.SetCellStyle("B3", &workbook.CellStyle{
-		NumberFormatID: scientificStyleID,
-	})
+	testSheet.SetCellStyleID("B3", scientificStyleID)
 
 	// 3. 测试科学计数格式 - 小数值
 	testSheet.AddCell("A4", "科学计数")
@@ -230,9 +216,7 @@ func main() {
 	testSheet.AddCell("D4", fmt.Sprintf("%d", scientificStyleID))
 	testSheet.AddCell("E4", "0.00E+00")
 	testSheet.AddCell("G4", "应显示为1.23E-06")
-	testSheet.SetCellStyle("B4", &workbook.CellStyle{
-		NumberFormatID: scientificStyleID,
-	})
+	testSheet.SetCellStyleID("B4", scientificStyleID)
 
 	// 4. 测试百分比格式
 	testSheet.AddCell("A5", "百分比")
@@ -241,25 +225,92 @@ func main() {
 	testSheet.AddCell("D5", fmt.Sprintf("%d", percentStyleID))
 	testSheet.AddCell("E5", "0.00%")
 	testSheet.AddCell("G5", "应显示为12.34%")
-	testSheet.SetCellStyle("B5", &workbook.CellStyle{
-		NumberFormatID: percentStyleID,
-	})
+	testSheet.SetCellStyleID("B5", percentStyleID)
+
+	// 将测试数据转换为表格，便于排序和筛选
+	if _, err := testSheet.AddTable("A1:G5", workbook.NewTableSpec("FormatTests").SetStyle(workbook.TableStyleLight9, true, false)); err != nil {
+		fmt.Println("添加表格时出错:", err)
+	}
 
 	// 合并单元格示例
 	ws.MergeCells("A9", "G9")
 	ws.AddCell("A9", "销售数据分析报表")
 
 	// 设置合并单元格的样式
-	ws.SetCellStyle("A9", &workbook.CellStyle{
-		FontID: 0,
-		Alignment: &workbook.Alignment{
-			Horizontal: "center",
-			Vertical:   "center",
+	ws.SetCellStyleID("A9", titleStyleID)
+
+	// 数据验证：产品名称只能从列表中选择，数量必须为1到1000之间的整数
+	productList := workbook.NewListValidation("笔记本电脑", "智能手机", "平板电脑", "智能手表", "无线耳机").
+		SetInputMessage("产品名称", "请从下拉列表中选择产品")
+	if err := ws.AddDataValidation("B2:B6", productList); err != nil {
+		fmt.Println("添加数据验证时出错:", err)
+	}
+	quantityRule := workbook.NewDataValidation(workbook.ValidationTypeWhole).
+		SetCondition(workbook.ValidationOperatorBetween, 1, 1000).
+		SetErrorMessage(workbook.ValidationErrorStop, "数量无效", "数量必须为1到1000之间的整数")
+	if err := ws.AddDataValidation("D2:D6", quantityRule); err != nil {
+		fmt.Println("添加数据验证时出错:", err)
+	}
+
+	// 批注：标记需要核实的数据，鼠标悬停时显示原因
+	if note, err := ws.AddComment("F6", "数据检查", "利润率明显高于其他产品，"); err != nil {
+		fmt.Println("添加批注时出错:", err)
+	} else {
+		note.AddRun("请核实成本数据", &workbook.Font{Bold: true, Color: "FFC00000"})
+	}
+
+	// 超链接：跳转到格式测试工作表和项目主页
+	if err := ws.SetCellHyperlink("A11", "'格式测试'!A1", "查看格式测试", "跳转到格式测试工作表"); err != nil {
+		fmt.Println("添加超链接时出错:", err)
+	}
+	if err := ws.SetCellHyperlink("A12", "https://github.com/landaiqing/go-dockit", "项目主页", ""); err != nil {
+		fmt.Println("添加超链接时出错:", err)
+	}
+
+	// 保护工作表：产品名称、单价和数量可以编辑，合计公式保持锁定
+	_ = ws.SetCellProtection("B2:D6", false, false)
+	protectOptions := workbook.NewSheetProtectionOptions()
+	protectOptions.Sort = true
+	if err := ws.Protect("", protectOptions); err != nil {
+		fmt.Println("保护工作表时出错:", err)
+	}
+
+	// 条件格式：利润率显示数据条，数量低于15的单元格显示为红色
+	if err := ws.AddConditionalFormat("F2:F6", workbook.NewDataBarRule("FF638EC6")); err != nil {
+		fmt.Println("添加条件格式时出错:", err)
+	}
+	lowStock := workbook.NewCellIsRule(workbook.ValidationOperatorLessThan, &workbook.Style{
+		Font: &workbook.Font{Color: "FF9C0006"},
+		Fill: &workbook.Fill{FgColor: "FFFFC7CE"},
+	}, 15)
+	if err := ws.AddConditionalFormat("D2:D6", lowStock); err != nil {
+		fmt.Println("添加条件格式时出错:", err)
+	}
+
+	// 组合图：销售数量为柱形，利润率为次坐标轴上的折线
+	_, err = ws.AddChart("I2", "P18", &workbook.ChartSpec{
+		Type:  workbook.ChartTypeColumn,
+		Title: "销售数量与利润率",
+		Series: []*workbook.ChartSeries{
+			{NameRef: "$D$1", Categories: "$B$2:$B$6", Values: "$D$2:$D$6", Color: "4472C4"},
+			{NameRef: "$F$1", Categories: "$B$2:$B$6", Values: "$F$2:$F$6", Color: "ED7D31",
+				Type: workbook.ChartTypeLine, SecondaryAxis: true, Marker: "circle"},
 		},
+		Legend: workbook.ChartLegendBottom,
+		YAxis:  &workbook.ChartAxis{Title: "数量", MajorGridlines: true},
+		Y2Axis: &workbook.ChartAxis{Title: "利润率", NumFmt: "0%"},
 	})
+	if err != nil {
+		fmt.Println("添加图表时出错:", err)
+	}
+
+	// 计算公式并写入缓存值，不会重新计算的查看器也能显示合计
+	if err := wb.Recalculate(); err != nil {
+		fmt.Println("计算公式时出错:", err)
+	}
 
 	// 保存Excel文件
-	err := wb.Save("./workbook/examples/simple/sales_report.xlsx")
+	err = wb.Save("./workbook/examples/simple/sales_report.xlsx")
 	if err != nil {
 		fmt.Println("保存Excel文件时出错:", err)
 		return
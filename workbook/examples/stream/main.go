@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/landaiqing/go-dockit/workbook"
+)
+
+// 流式写入大表示例，同时输出耗时和内存占用，可以用来对比不同行数下的表现
+// 例如: go run ./workbook/examples/stream -rows 500000
+func main() {
+	rows := flag.Int("rows", 100000, "写入的数据行数")
+	output := flag.String("o", "./workbook/examples/stream/audit_log.xlsx", "输出文件")
+	flag.Parse()
+
+	start := time.Now()
+
+	wb := workbook.NewWorkbook()
+	defer wb.Close()
+
+	sw, err := wb.NewStreamWriter("审计日志")
+	if err != nil {
+		fmt.Println("创建流式写入器时出错:", err)
+		return
+	}
+
+	// 样式和列宽需要在写入数据之前准备好
+	headerStyleID, _ := wb.Styles.NewStyle(&workbook.Style{
+		Font:      &workbook.Font{Bold: true, Color: "FFFFFFFF"},
+		Fill:      &workbook.Fill{FgColor: "FF4472C4"},
+		Alignment: &workbook.Alignment{Horizontal: "center"},
+	})
+	timeStyleID, _ := wb.Styles.NewStyle(&workbook.Style{NumFmt: "yyyy-mm-dd hh:mm:ss"})
+	amountStyleID, _ := wb.Styles.NewStyle(&workbook.Style{NumFmt: "#,##0.00"})
+
+	sw.SetColWidth(1, 1, 10)
+	sw.SetColWidth(2, 2, 20)
+	sw.SetColWidth(3, 4, 15)
+	sw.SetColWidth(5, 5, 40)
+
+	// 标题行
+	sw.MergeCell("A1", "E1")
+	sw.SetRow("A1", []interface{}{workbook.StreamCell{Value: "系统审计日志", StyleID: headerStyleID}}, &workbook.RowOptions{Height: 24})
+
+	header := []interface{}{}
+	for _, title := range []string{"序号", "时间", "用户", "金额", "操作"} {
+		header = append(header, workbook.StreamCell{Value: title, StyleID: headerStyleID})
+	}
+	sw.SetRow("A2", header, nil)
+
+	base := time.Date(2024, 1, 1, 8, 0, 0, 0, time.Local)
+	for i := 1; i <= *rows; i++ {
+		err := sw.WriteRow(
+			i,
+			workbook.StreamCell{Value: base.Add(time.Duration(i) * time.Second), StyleID: timeStyleID},
+			fmt.Sprintf("user%04d", i%1000),
+			workbook.StreamCell{Value: float64(i%10000) / 100, StyleID: amountStyleID},
+			fmt.Sprintf("更新记录 #%d 的状态", i),
+		)
+		if err != nil {
+			fmt.Println("写入数据时出错:", err)
+			return
+		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		fmt.Println("结束写入时出错:", err)
+		return
+	}
+
+	if err := wb.Save(*output); err != nil {
+		fmt.Println("保存Excel文件时出错:", err)
+		return
+	}
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	fmt.Printf("写入%d行，耗时%v，堆内存%.1fMB，累计分配%.1fMB\n",
+		sw.Rows(), time.Since(start).Round(time.Millisecond),
+		float64(m.HeapAlloc)/1024/1024, float64(m.TotalAlloc)/1024/1024)
+	fmt.Println("Excel文件已成功创建:", *output)
+}
@@ -0,0 +1,329 @@
+package workbook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AutoFilter 表示工作表的自动筛选
+type AutoFilter struct {
+	Ref     string // 筛选区域，第一行为标题行，例如 "A1:G100"
+	Columns []*FilterColumn
+}
+
+// FilterColumn 表示自动筛选中一列的筛选条件
+// 按值筛选、自定义筛选和前N项筛选三者只能使用一种
+type FilterColumn struct {
+	Col      int                // 在筛选区域中的列序号，从0开始
+	Values   []string           // 按值筛选时显示的值
+	Blank    bool               // 按值筛选时同时显示空白单元格
+	Criteria []*FilterCriterion // 自定义筛选条件，最多两个
+	MatchAll bool               // 两个自定义条件需要同时满足，否则满足其一即可
+	Top      int                // 前N项筛选的数量
+	Percent  bool               // 前N项筛选按百分比计算
+	Bottom   bool               // 筛选最小的N项
+}
+
+// FilterCriterion 表示一个自定义筛选条件
+type FilterCriterion struct {
+	Operator string // 比较运算符：equal, notEqual, greaterThan, greaterThanOrEqual, lessThan, lessThanOrEqual
+	Value    string // 比较的值，equal和notEqual可以使用通配符*和?
+}
+
+// filterOperators 自定义筛选支持的运算符及其在条件中的写法
+var filterOperators = map[string]string{
+	ValidationOperatorEqual:              "=",
+	ValidationOperatorNotEqual:           "<>",
+	ValidationOperatorGreaterThan:        ">",
+	ValidationOperatorGreaterThanOrEqual: ">=",
+	ValidationOperatorLessThan:           "<",
+	ValidationOperatorLessThanOrEqual:    "<=",
+}
+
+// NewValueFilter 创建按值筛选的条件，只显示col列中等于values之一的行
+func NewValueFilter(col int, values ...string) *FilterColumn {
+	return &FilterColumn{Col: col, Values: values}
+}
+
+// NewCustomFilter 创建自定义筛选条件，可以再用And或Or添加第二个条件
+// 例如: NewCustomFilter(3, ValidationOperatorGreaterThan, 100)
+func NewCustomFilter(col int, operator string, value interface{}) *FilterColumn {
+	fc := &FilterColumn{Col: col}
+	return fc.addCriterion(operator, value)
+}
+
+// NewTopFilter 创建筛选最大的n项的条件，percent为true时筛选最大的n%
+func NewTopFilter(col, n int, percent bool) *FilterColumn {
+	return &FilterColumn{Col: col, Top: n, Percent: percent}
+}
+
+// NewBottomFilter 创建筛选最小的n项的条件，percent为true时筛选最小的n%
+func NewBottomFilter(col, n int, percent bool) *FilterColumn {
+	return &FilterColumn{Col: col, Top: n, Percent: percent, Bottom: true}
+}
+
+// SetBlank 设置按值筛选时是否显示空白单元格
+func (fc *FilterColumn) SetBlank(blank bool) *FilterColumn {
+	fc.Blank = blank
+	return fc
+}
+
+// And 添加第二个自定义条件，两个条件需要同时满足
+func (fc *FilterColumn) And(operator string, value interface{}) *FilterColumn {
+	fc.MatchAll = true
+	return fc.addCriterion(operator, value)
+}
+
+// Or 添加第二个自定义条件，满足其中一个条件即可
+func (fc *FilterColumn) Or(operator string, value interface{}) *FilterColumn {
+	fc.MatchAll = false
+	return fc.addCriterion(operator, value)
+}
+
+// addCriterion 添加一个自定义条件，日期转换为序列号
+func (fc *FilterColumn) addCriterion(operator string, value interface{}) *FilterColumn {
+	text := ""
+	switch v := value.(type) {
+	case time.Time:
+		text = formatNumber(timeToSerial(v))
+	default:
+		if f, ok := toFloat(value); ok {
+			text = formatNumber(f)
+		} else {
+			text = fmt.Sprintf("%v", value)
+		}
+	}
+	fc.Criteria = append(fc.Criteria, &FilterCriterion{Operator: operator, Value: text})
+	return fc
+}
+
+// check 检查筛选条件是否有效，cols为筛选区域的列数
+func (fc *FilterColumn) check(cols int) error {
+	if fc.Col < 0 || fc.Col >= cols {
+		return fmt.Errorf("筛选列序号%d超出筛选区域的%d列", fc.Col, cols)
+	}
+	kinds := 0
+	if len(fc.Values) > 0 || fc.Blank {
+		kinds++
+	}
+	if len(fc.Criteria) > 0 {
+		kinds++
+	}
+	if fc.Top != 0 {
+		kinds++
+	}
+	if kinds != 1 {
+		return fmt.Errorf("第%d列的筛选需要且只能使用按值、自定义或前N项中的一种", fc.Col)
+	}
+	if len(fc.Criteria) > 2 {
+		return fmt.Errorf("第%d列的自定义筛选最多有两个条件", fc.Col)
+	}
+	for _, c := range fc.Criteria {
+		if _, ok := filterOperators[c.Operator]; !ok {
+			return fmt.Errorf("无效的筛选运算符: %s", c.Operator)
+		}
+	}
+	if fc.Top < 0 || fc.Top > 500 || fc.Percent && fc.Top > 100 {
+		return fmt.Errorf("无效的前N项数量: %d", fc.Top)
+	}
+	return nil
+}
+
+// matcher 返回判断单元格值是否满足筛选条件的函数，values为该列所有数据行的值，用于前N项筛选
+func (fc *FilterColumn) matcher(values []interface{}) func(value interface{}) bool {
+	switch {
+	case len(fc.Criteria) > 0:
+		matches := make([]func(interface{}) bool, len(fc.Criteria))
+		for i, c := range fc.Criteria {
+			matches[i] = parseCriteria(filterOperators[c.Operator] + c.Value)
+		}
+		return func(value interface{}) bool {
+			result := matches[0](value)
+			if len(matches) == 2 {
+				if fc.MatchAll {
+					result = result && matches[1](value)
+				} else {
+					result = result || matches[1](value)
+				}
+			}
+			return result
+		}
+	case fc.Top != 0:
+		var numbers []float64
+		for _, v := range values {
+			if f, ok := v.(float64); ok {
+				numbers = append(numbers, f)
+			}
+		}
+		if len(numbers) == 0 {
+			return func(value interface{}) bool { return false }
+		}
+		sort.Float64s(numbers)
+		n := fc.Top
+		if fc.Percent {
+			n = max(len(numbers)*fc.Top/100, 1)
+		}
+		n = min(n, len(numbers))
+		if fc.Bottom {
+			limit := numbers[n-1]
+			return func(value interface{}) bool {
+				f, ok := value.(float64)
+				return ok && f <= limit
+			}
+		}
+		limit := numbers[len(numbers)-n]
+		return func(value interface{}) bool {
+			f, ok := value.(float64)
+			return ok && f >= limit
+		}
+	}
+	return func(value interface{}) bool {
+		text := toText(value)
+		if text == "" {
+			return fc.Blank
+		}
+		for _, v := range fc.Values {
+			if strings.EqualFold(v, text) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SetAutoFilter 为区域设置自动筛选，区域的第一行为标题行，ref为空时取消自动筛选
+// 设置了筛选条件时，根据当前的单元格值隐藏不满足条件的行
+// 例如: ws.SetAutoFilter("A1:G100", NewValueFilter(1, "笔记本电脑", "智能手机"))
+func (ws *Worksheet) SetAutoFilter(ref string, filters ...*FilterColumn) error {
+	if err := ws.checkWritable(); err != nil {
+		return err
+	}
+	if ref == "" {
+		ws.AutoFilter = nil
+		return nil
+	}
+	area, ok := parseRangeRef(ref)
+	if !ok || !area.IsRange || strings.ContainsAny(ref, "!$") {
+		return fmt.Errorf("无效的自动筛选区域: %s", ref)
+	}
+	for _, table := range ws.Tables {
+		if overlaps(area, table.Ref) {
+			return fmt.Errorf("自动筛选区域 %s 与表格 %s 重叠", ref, table.Spec.Name)
+		}
+	}
+	row1, col1, row2, col2 := area.bounds()
+	for _, fc := range filters {
+		if fc == nil {
+			return fmt.Errorf("筛选条件不能为空")
+		}
+		if err := fc.check(col2 - col1 + 1); err != nil {
+			return err
+		}
+	}
+
+	ws.AutoFilter = &AutoFilter{Ref: area.areaString(), Columns: filters}
+	ws.applyFilter(row1+1, row2, col1, filters)
+	return nil
+}
+
+// applyFilter 根据筛选条件隐藏第row1到row2行（从0开始）中不满足条件的行
+// 满足条件的行保持原样，不会取消其他筛选或手动隐藏的行
+func (ws *Worksheet) applyFilter(row1, row2, col1 int, filters []*FilterColumn) {
+	// 不属于工作簿的工作表无法计算跨工作表的公式
+	if len(filters) == 0 || ws.wb == nil || len(ws.Rows) == 0 {
+		return
+	}
+	// 最后一行之后没有数据，不需要逐行判断
+	row2 = min(row2, ws.Rows[len(ws.Rows)-1].Index-1)
+	if row1 > row2 {
+		return
+	}
+	e := newCalcEngine(ws.wb)
+	visible := make([]bool, row2-row1+1)
+	for i := range visible {
+		visible[i] = true
+	}
+	for _, fc := range filters {
+		values := make([]interface{}, len(visible))
+		for i := range values {
+			values[i] = e.cellValue(ws, row1+i, col1+fc.Col)
+		}
+		match := fc.matcher(values)
+		for i, value := range values {
+			visible[i] = visible[i] && match(value)
+		}
+	}
+	for i, show := range visible {
+		if !show {
+			ws.row(row1+i+1, true).Hidden = true
+		}
+	}
+}
+
+// overlaps 判断区域与A1格式的区域ref是否有重叠
+func overlaps(area *formulaRef, ref string) bool {
+	other, ok := parseRangeRef(ref)
+	if !ok {
+		return false
+	}
+	r1, c1, r2, c2 := area.bounds()
+	o1, p1, o2, p2 := other.bounds()
+	return r1 <= o2 && o1 <= r2 && c1 <= p2 && p1 <= c2
+}
+
+// toXML 生成autoFilter元素
+func (af *AutoFilter) toXML() string {
+	if len(af.Columns) == 0 {
+		return "<autoFilter ref=\"" + af.Ref + "\" />"
+	}
+	xml := "<autoFilter ref=\"" + af.Ref + "\">"
+	for _, fc := range af.Columns {
+		xml += fc.toXML()
+	}
+	xml += "</autoFilter>"
+	return xml
+}
+
+// toXML 生成filterColumn元素
+func (fc *FilterColumn) toXML() string {
+	xml := fmt.Sprintf("<filterColumn colId=\"%d\">", fc.Col)
+	switch {
+	case len(fc.Criteria) > 0:
+		xml += "<customFilters"
+		if fc.MatchAll && len(fc.Criteria) > 1 {
+			xml += " and=\"1\""
+		}
+		xml += ">"
+		for _, c := range fc.Criteria {
+			xml += "<customFilter"
+			if c.Operator != ValidationOperatorEqual {
+				xml += " operator=\"" + c.Operator + "\""
+			}
+			xml += " val=\"" + escapeXML(c.Value) + "\" />"
+		}
+		xml += "</customFilters>"
+	case fc.Top != 0:
+		xml += fmt.Sprintf("<top10 val=\"%d\"", fc.Top)
+		if fc.Bottom {
+			xml += " top=\"0\""
+		}
+		if fc.Percent {
+			xml += " percent=\"1\""
+		}
+		xml += " />"
+	default:
+		xml += "<filters"
+		if fc.Blank {
+			xml += " blank=\"1\""
+		}
+		xml += ">"
+		for _, v := range fc.Values {
+			xml += "<filter val=\"" + escapeXML(v) + "\" />"
+		}
+		xml += "</filters>"
+	}
+	xml += "</filterColumn>"
+	return xml
+}
@@ -0,0 +1,621 @@
+package workbook
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FormulaError 表示公式的错误值，如#DIV/0!
+type FormulaError string
+
+// 公式错误值
+const (
+	FormulaErrorNull  FormulaError = "#NULL!"
+	FormulaErrorDiv0  FormulaError = "#DIV/0!"
+	FormulaErrorValue FormulaError = "#VALUE!"
+	FormulaErrorRef   FormulaError = "#REF!"
+	FormulaErrorName  FormulaError = "#NAME?"
+	FormulaErrorNum   FormulaError = "#NUM!"
+	FormulaErrorNA    FormulaError = "#N/A"
+)
+
+// Error 实现error接口
+func (e FormulaError) Error() string {
+	return string(e)
+}
+
+// formulaErrors 公式中可以直接书写的错误值
+var formulaErrors = []FormulaError{
+	FormulaErrorNull, FormulaErrorDiv0, FormulaErrorValue, FormulaErrorRef,
+	FormulaErrorName, FormulaErrorNum, FormulaErrorNA,
+}
+
+// 公式词法单元类型
+const (
+	tokenEOF = iota
+	tokenNumber
+	tokenString
+	tokenBool
+	tokenError
+	tokenRef
+	tokenName
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// formulaToken 表示公式中的一个词法单元，Pos和End为它在公式中的字节位置
+type formulaToken struct {
+	Type  int
+	Text  string
+	Pos   int
+	End   int
+	Ref   *formulaRef // 引用单元的解析结果
+	Value interface{} // 常量单元的值
+}
+
+// refPoint 表示引用中的一个端点，Row或Col为-1表示整列或整行
+type refPoint struct {
+	Row    int // 行索引，从0开始
+	Col    int // 列索引，从0开始
+	AbsRow bool
+	AbsCol bool
+}
+
+// formulaRef 表示公式中的单元格或区域引用
+type formulaRef struct {
+	Sheet   string // 工作表名称，为空表示公式所在的工作表
+	From    refPoint
+	To      refPoint
+	IsRange bool
+}
+
+var (
+	cellRangePattern  = regexp.MustCompile(`^(\$?)([A-Za-z]{1,3})(\$?)([0-9]+)(?::(\$?)([A-Za-z]{1,3})(\$?)([0-9]+))?`)
+	colRangePattern   = regexp.MustCompile(`^(\$?)([A-Za-z]{1,3}):(\$?)([A-Za-z]{1,3})`)
+	rowRangePattern   = regexp.MustCompile(`^(\$?)([0-9]+):(\$?)([0-9]+)`)
+	numberPattern     = regexp.MustCompile(`^([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?`)
+	sheetPrefixQuoted = regexp.MustCompile(`^'((?:[^']|'')+)'!`)
+)
+
+// tokenizeFormula 将公式拆分为词法单元，公式可以带前导等号
+func tokenizeFormula(formula string) ([]formulaToken, error) {
+	tokens := make([]formulaToken, 0)
+	i := 0
+	if strings.HasPrefix(formula, "=") {
+		i = 1
+	}
+
+	for i < len(formula) {
+		c := formula[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '"':
+			end := i + 1
+			var sb strings.Builder
+			for {
+				if end >= len(formula) {
+					return nil, fmt.Errorf("字符串没有结束: %s", formula[i:])
+				}
+				if formula[end] == '"' {
+					if end+1 < len(formula) && formula[end+1] == '"' {
+						sb.WriteByte('"')
+						end += 2
+						continue
+					}
+					end++
+					break
+				}
+				sb.WriteByte(formula[end])
+				end++
+			}
+			tokens = append(tokens, formulaToken{Type: tokenString, Text: formula[i:end], Pos: i, End: end, Value: sb.String()})
+			i = end
+			continue
+		case c == '#':
+			found := false
+			for _, e := range formulaErrors {
+				if strings.HasPrefix(strings.ToUpper(formula[i:]), string(e)) {
+					end := i + len(e)
+					tokens = append(tokens, formulaToken{Type: tokenError, Text: formula[i:end], Pos: i, End: end, Value: e})
+					i = end
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("无效的错误值: %s", formula[i:])
+			}
+			continue
+		case c == '(':
+			tokens = append(tokens, formulaToken{Type: tokenLParen, Text: "(", Pos: i, End: i + 1})
+			i++
+			continue
+		case c == ')':
+			tokens = append(tokens, formulaToken{Type: tokenRParen, Text: ")", Pos: i, End: i + 1})
+			i++
+			continue
+		case c == ',':
+			tokens = append(tokens, formulaToken{Type: tokenComma, Text: ",", Pos: i, End: i + 1})
+			i++
+			continue
+		case strings.IndexByte("+-*/^&%=<>", c) >= 0:
+			end := i + 1
+			if end < len(formula) && (c == '<' && (formula[end] == '=' || formula[end] == '>') || c == '>' && formula[end] == '=') {
+				end++
+			}
+			tokens = append(tokens, formulaToken{Type: tokenOperator, Text: formula[i:end], Pos: i, End: end})
+			i = end
+			continue
+		}
+
+		token, err := scanFormulaOperand(formula, i)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+		i = token.End
+	}
+
+	tokens = append(tokens, formulaToken{Type: tokenEOF, Pos: len(formula), End: len(formula)})
+	return tokens, nil
+}
+
+// scanFormulaOperand 从位置i开始读取数字、引用、名称或逻辑值
+func scanFormulaOperand(formula string, i int) (formulaToken, error) {
+	rest := formula[i:]
+
+	// 带工作表名称的引用
+	sheet, prefixLen := "", 0
+	if m := sheetPrefixQuoted.FindStringSubmatch(rest); m != nil {
+		sheet, prefixLen = strings.ReplaceAll(m[1], "''", "'"), len(m[0])
+	} else if n := scanIdentifier(rest); n > 0 && n < len(rest) && rest[n] == '!' {
+		sheet, prefixLen = rest[:n], n+1
+	}
+	if prefixLen > 0 {
+		ref, n := parseRefText(rest[prefixLen:])
+		if ref == nil {
+			return formulaToken{}, fmt.Errorf("无效的引用: %s", rest)
+		}
+		ref.Sheet = sheet
+		end := i + prefixLen + n
+		return formulaToken{Type: tokenRef, Text: formula[i:end], Pos: i, End: end, Ref: ref}, nil
+	}
+
+	// 不带工作表名称的引用，函数名（后面紧跟括号）不是引用，如LOG10(
+	if ref, n := parseRefText(rest); ref != nil && !isFunctionCall(rest[n:]) {
+		return formulaToken{Type: tokenRef, Text: rest[:n], Pos: i, End: i + n, Ref: ref}, nil
+	}
+
+	if m := numberPattern.FindString(rest); m != "" {
+		value, err := strconv.ParseFloat(m, 64)
+		if err != nil {
+			return formulaToken{}, fmt.Errorf("无效的数字: %s", m)
+		}
+		return formulaToken{Type: tokenNumber, Text: m, Pos: i, End: i + len(m), Value: value}, nil
+	}
+
+	n := scanIdentifier(rest)
+	if n == 0 {
+		return formulaToken{}, fmt.Errorf("无法识别的字符: %s", rest)
+	}
+	name := rest[:n]
+	switch strings.ToUpper(name) {
+	case "TRUE":
+		if !isFunctionCall(rest[n:]) {
+			return formulaToken{Type: tokenBool, Text: name, Pos: i, End: i + n, Value: true}, nil
+		}
+	case "FALSE":
+		if !isFunctionCall(rest[n:]) {
+			return formulaToken{Type: tokenBool, Text: name, Pos: i, End: i + n, Value: false}, nil
+		}
+	}
+	return formulaToken{Type: tokenName, Text: name, Pos: i, End: i + n}, nil
+}
+
+// scanIdentifier 返回s开头的名称长度，名称由字母、数字、下划线和点组成，不能以数字开头
+func scanIdentifier(s string) int {
+	n := 0
+	for j, r := range s {
+		if unicode.IsLetter(r) || r == '_' || r == '\\' || j > 0 && (unicode.IsDigit(r) || r == '.') {
+			n = j + len(string(r))
+			continue
+		}
+		break
+	}
+	return n
+}
+
+// isFunctionCall 判断名称之后是否紧跟左括号
+func isFunctionCall(rest string) bool {
+	return strings.HasPrefix(strings.TrimLeft(rest, " "), "(")
+}
+
+// parseRefText 解析s开头的A1、A1:B2、A:B或1:2格式的引用，返回引用和它的长度
+func parseRefText(s string) (*formulaRef, int) {
+	isBoundary := func(n int) bool {
+		if n >= len(s) {
+			return true
+		}
+		return scanIdentifier("A"+s[n:]) == 1 && s[n] != '!'
+	}
+
+	if m := cellRangePattern.FindStringSubmatch(s); m != nil && isBoundary(len(m[0])) {
+		from, ok := newRefPoint(m[1], m[2], m[3], m[4])
+		if !ok {
+			return nil, 0
+		}
+		ref := &formulaRef{From: from, To: from}
+		if m[6] != "" {
+			to, ok := newRefPoint(m[5], m[6], m[7], m[8])
+			if !ok {
+				return nil, 0
+			}
+			ref.To, ref.IsRange = to, true
+		}
+		return ref, len(m[0])
+	}
+	if m := colRangePattern.FindStringSubmatch(s); m != nil && isBoundary(len(m[0])) {
+		from, ok1 := newRefPoint(m[1], m[2], "", "")
+		to, ok2 := newRefPoint(m[3], m[4], "", "")
+		if !ok1 || !ok2 {
+			return nil, 0
+		}
+		return &formulaRef{From: from, To: to, IsRange: true}, len(m[0])
+	}
+	if m := rowRangePattern.FindStringSubmatch(s); m != nil && isBoundary(len(m[0])) {
+		from, ok1 := newRefPoint("", "", m[1], m[2])
+		to, ok2 := newRefPoint("", "", m[3], m[4])
+		if !ok1 || !ok2 {
+			return nil, 0
+		}
+		return &formulaRef{From: from, To: to, IsRange: true}, len(m[0])
+	}
+	return nil, 0
+}
+
+// newRefPoint 根据列名和行号创建引用端点，列名或行号为空表示整行或整列
+func newRefPoint(absCol, col, absRow, row string) (refPoint, bool) {
+	p := refPoint{Row: -1, Col: -1, AbsCol: absCol != "", AbsRow: absRow != ""}
+	if col != "" {
+		p.Col = ColNameToIndex(col)
+		if p.Col < 0 || p.Col >= MaxColumns {
+			return p, false
+		}
+	}
+	if row != "" {
+		n, err := strconv.Atoi(row)
+		if err != nil || n < 1 || n > MaxRows {
+			return p, false
+		}
+		p.Row = n - 1
+	}
+	return p, true
+}
+
+// String 将引用端点转换为A1格式
+func (p refPoint) String() string {
+	s := ""
+	if p.Col >= 0 {
+		if p.AbsCol {
+			s += "$"
+		}
+		s += ColIndexToName(p.Col)
+	}
+	if p.Row >= 0 {
+		if p.AbsRow {
+			s += "$"
+		}
+		s += strconv.Itoa(p.Row + 1)
+	}
+	return s
+}
+
+// String 将引用转换为公式中的文本
+func (r *formulaRef) String() string {
+	if r.Sheet != "" {
+		return quoteSheetName(r.Sheet) + "!" + r.areaString()
+	}
+	return r.areaString()
+}
+
+// areaString 返回不带工作表名称的引用文本
+func (r *formulaRef) areaString() string {
+	if r.IsRange {
+		return r.From.String() + ":" + r.To.String()
+	}
+	return r.From.String()
+}
+
+// parseSheetRef 解析可以带工作表名称的单个引用，例如 "'Sheet 1'!$A$2:$A$10"
+func parseSheetRef(s string) (*formulaRef, bool) {
+	tokens, err := tokenizeFormula(s)
+	if err != nil || len(tokens) != 2 || tokens[0].Type != tokenRef {
+		return nil, false
+	}
+	return tokens[0].Ref, true
+}
+
+// quoteSheetName 在需要时为公式中的工作表名称加上单引号
+func quoteSheetName(name string) string {
+	if scanIdentifier(name) == len(name) {
+		if ref, _ := parseRefText(name); ref == nil {
+			return name
+		}
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// bounds 返回引用覆盖的行列范围，整行或整列引用扩展到工作表边界
+func (r *formulaRef) bounds() (row1, col1, row2, col2 int) {
+	row1, col1, row2, col2 = r.From.Row, r.From.Col, r.To.Row, r.To.Col
+	if row1 < 0 || row2 < 0 {
+		row1, row2 = 0, MaxRows-1
+	}
+	if col1 < 0 || col2 < 0 {
+		col1, col2 = 0, MaxColumns-1
+	}
+	if row1 > row2 {
+		row1, row2 = row2, row1
+	}
+	if col1 > col2 {
+		col1, col2 = col2, col1
+	}
+	return
+}
+
+// 公式语法树的节点
+type (
+	formulaNode interface{}
+
+	// literalNode 常量，包括数字、字符串、逻辑值、错误值和省略的参数（nil）
+	literalNode struct{ value interface{} }
+
+	// refNode 单元格或区域引用
+	refNode struct{ ref *formulaRef }
+
+	// nameNode 定义的名称
+	nameNode struct{ name string }
+
+	// unaryNode 一元运算，op为+、-或%
+	unaryNode struct {
+		op      string
+		operand formulaNode
+	}
+
+	// binaryNode 二元运算
+	binaryNode struct {
+		op          string
+		left, right formulaNode
+	}
+
+	// funcNode 函数调用，name为大写的函数名
+	funcNode struct {
+		name string
+		args []formulaNode
+	}
+)
+
+// formulaParser 按运算符优先级将词法单元解析为语法树
+type formulaParser struct {
+	tokens []formulaToken
+	pos    int
+}
+
+// binaryPrecedence 二元运算符的优先级，数字越大越先计算
+var binaryPrecedence = map[string]int{
+	"=": 1, "<>": 1, "<": 1, ">": 1, "<=": 1, ">=": 1,
+	"&": 2,
+	"+": 3, "-": 3,
+	"*": 4, "/": 4,
+	"^": 5,
+}
+
+// parseFormula 将公式解析为语法树
+func parseFormula(formula string) (formulaNode, error) {
+	tokens, err := tokenizeFormula(formula)
+	if err != nil {
+		return nil, err
+	}
+	p := &formulaParser{tokens: tokens}
+	node, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.Type != tokenEOF {
+		return nil, fmt.Errorf("位置%d处有多余的内容: %s", token.Pos, token.Text)
+	}
+	return node, nil
+}
+
+// peek 返回当前词法单元
+func (p *formulaParser) peek() formulaToken {
+	return p.tokens[p.pos]
+}
+
+// next 返回当前词法单元并前进
+func (p *formulaParser) next() formulaToken {
+	token := p.tokens[p.pos]
+	if token.Type != tokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+// parseExpression 解析优先级不低于minPrecedence的表达式
+func (p *formulaParser) parseExpression(minPrecedence int) (formulaNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := p.peek()
+		if token.Type != tokenOperator {
+			return left, nil
+		}
+		precedence, ok := binaryPrecedence[token.Text]
+		if !ok || precedence < minPrecedence {
+			return left, nil
+		}
+		p.next()
+
+		// ^左结合，与Excel一致
+		right, err := p.parseExpression(precedence + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: token.Text, left: left, right: right}
+	}
+}
+
+// parseUnary 解析一元正负号和百分号，负号的优先级高于乘方
+func (p *formulaParser) parseUnary() (formulaNode, error) {
+	token := p.peek()
+	if token.Type == tokenOperator && (token.Text == "-" || token.Text == "+") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: token.Text, operand: operand}, nil
+	}
+
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().Type == tokenOperator && p.peek().Text == "%" {
+		p.next()
+		node = &unaryNode{op: "%", operand: node}
+	}
+	return node, nil
+}
+
+// parsePrimary 解析常量、引用、名称、函数调用和括号表达式
+func (p *formulaParser) parsePrimary() (formulaNode, error) {
+	token := p.next()
+	switch token.Type {
+	case tokenNumber, tokenString, tokenBool, tokenError:
+		return &literalNode{value: token.Value}, nil
+	case tokenRef:
+		return &refNode{ref: token.Ref}, nil
+	case tokenLParen:
+		node, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		if p.next().Type != tokenRParen {
+			return nil, fmt.Errorf("缺少右括号")
+		}
+		return node, nil
+	case tokenName:
+		if p.peek().Type != tokenLParen {
+			return &nameNode{name: token.Text}, nil
+		}
+		p.next()
+		return p.parseArguments(strings.ToUpper(token.Text))
+	case tokenEOF:
+		return nil, fmt.Errorf("公式不完整")
+	}
+	return nil, fmt.Errorf("位置%d处有意外的内容: %s", token.Pos, token.Text)
+}
+
+// parseArguments 解析函数参数直到右括号，省略的参数用nil常量表示
+func (p *formulaParser) parseArguments(name string) (formulaNode, error) {
+	// Excel 2010之后的新函数在文件中带有_xlfn.前缀，动态数组函数还带有_xlws.前缀
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "_XLFN."), "_XLWS.")
+	fn := &funcNode{name: name, args: make([]formulaNode, 0)}
+	if p.peek().Type == tokenRParen {
+		p.next()
+		return fn, nil
+	}
+
+	for {
+		if t := p.peek().Type; t == tokenComma || t == tokenRParen {
+			fn.args = append(fn.args, &literalNode{})
+		} else {
+			arg, err := p.parseExpression(0)
+			if err != nil {
+				return nil, err
+			}
+			fn.args = append(fn.args, arg)
+		}
+
+		switch token := p.next(); token.Type {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return fn, nil
+		default:
+			return nil, fmt.Errorf("函数%s缺少右括号", name)
+		}
+	}
+}
+
+// futureFunctions Excel 2010之后新增的函数，写入文件时需要带_xlfn.前缀，否则旧版本和Excel都显示#NAME?
+// 值为函数名之前的完整前缀
+var futureFunctions = func() map[string]string {
+	names := []string{
+		"ACOT", "ACOTH", "AGGREGATE", "ARABIC", "BASE", "BETA.DIST", "BETA.INV",
+		"BINOM.DIST", "BINOM.DIST.RANGE", "BINOM.INV", "BITAND", "BITLSHIFT", "BITOR", "BITRSHIFT", "BITXOR",
+		"CEILING.MATH", "CEILING.PRECISE", "CHISQ.DIST", "CHISQ.DIST.RT", "CHISQ.INV", "CHISQ.INV.RT", "CHISQ.TEST",
+		"CHOOSECOLS", "CHOOSEROWS", "COMBINA", "CONCAT", "CONFIDENCE.NORM", "CONFIDENCE.T",
+		"COT", "COTH", "COVARIANCE.P", "COVARIANCE.S", "CSC", "CSCH", "DAYS", "DECIMAL", "DROP",
+		"ERF.PRECISE", "ERFC.PRECISE", "EXPAND", "EXPON.DIST", "F.DIST", "F.DIST.RT", "F.INV", "F.INV.RT", "F.TEST",
+		"FLOOR.MATH", "FLOOR.PRECISE", "FORECAST.LINEAR", "FORMULATEXT", "GAMMA", "GAMMA.DIST", "GAMMA.INV",
+		"GAMMALN.PRECISE", "GAUSS", "HSTACK", "HYPGEOM.DIST", "IFNA", "IFS",
+		"IMCOSH", "IMCOT", "IMCSC", "IMCSCH", "IMSEC", "IMSECH", "IMSINH", "IMTAN", "ISFORMULA", "ISOWEEKNUM",
+		"LOGNORM.DIST", "LOGNORM.INV", "MAXIFS", "MINIFS", "MODE.MULT", "MODE.SNGL", "MUNIT",
+		"NEGBINOM.DIST", "NETWORKDAYS.INTL", "NORM.DIST", "NORM.INV", "NORM.S.DIST", "NORM.S.INV", "NUMBERVALUE",
+		"PDURATION", "PERCENTILE.EXC", "PERCENTILE.INC", "PERCENTRANK.EXC", "PERCENTRANK.INC", "PERMUTATIONA",
+		"PHI", "POISSON.DIST", "QUARTILE.EXC", "QUARTILE.INC", "RANDARRAY", "RANK.AVG", "RANK.EQ", "RRI",
+		"SEC", "SECH", "SEQUENCE", "SHEET", "SHEETS", "SKEW.P", "SORTBY", "STDEV.P", "STDEV.S", "SWITCH",
+		"T.DIST", "T.DIST.2T", "T.DIST.RT", "T.INV", "T.INV.2T", "T.TEST", "TAKE", "TEXTAFTER", "TEXTBEFORE",
+		"TEXTJOIN", "TEXTSPLIT", "TOCOL", "TOROW", "UNICHAR", "UNICODE", "UNIQUE", "VAR.P", "VAR.S", "VSTACK",
+		"WEIBULL.DIST", "WORKDAY.INTL", "WRAPCOLS", "WRAPROWS", "XLOOKUP", "XMATCH", "XOR", "Z.TEST",
+	}
+	m := make(map[string]string, len(names)+2)
+	for _, name := range names {
+		m[name] = "_xlfn."
+	}
+	m["FILTER"] = "_xlfn._xlws."
+	m["SORT"] = "_xlfn._xlws."
+	return m
+}()
+
+// storedFormula 返回写入文件的公式文本：去掉前导等号，并为Excel 2010之后新增的函数加上_xlfn.前缀
+// 已带前缀的函数、字符串和引用保持原样；无法解析的公式不做修改
+func storedFormula(formula string) string {
+	formula = strings.TrimPrefix(formula, "=")
+	tokens, err := tokenizeFormula(formula)
+	if err != nil {
+		return formula
+	}
+
+	var sb strings.Builder
+	last, changed := 0, false
+	for i, token := range tokens {
+		if token.Type != tokenName || tokens[i+1].Type != tokenLParen {
+			continue
+		}
+		prefix, ok := futureFunctions[strings.ToUpper(token.Text)]
+		if !ok {
+			continue
+		}
+		sb.WriteString(formula[last:token.Pos])
+		sb.WriteString(prefix)
+		last, changed = token.Pos, true
+	}
+	if !changed {
+		return formula
+	}
+	sb.WriteString(formula[last:])
+	return sb.String()
+}
@@ -0,0 +1,111 @@
+package workbook
+
+import (
+	"math"
+	"testing"
+)
+
+// TestFormulaPrecedence 检查运算符的优先级和结合性与Excel一致
+func TestFormulaPrecedence(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    interface{}
+	}{
+		{"1+2*3", 7.0},
+		{"(1+2)*3", 9.0},
+		{"10-4-3", 3.0},
+		{"8/2/2", 2.0},
+		{"2^3^2", 64.0}, // 乘方从左向右结合
+		{"-2^2", 4.0},   // 负号优先于乘方
+		{"2^-1", 0.5},
+		{"-(1+2)*2", -6.0},
+		{"50%*2", 1.0},
+		{"2*3%", 0.06},
+		{"1+2&3", "33"}, // 连接的优先级低于算术运算
+		{"\"a\"&1+1", "a2"},
+		{"1+1>1", true}, // 比较的优先级最低
+		{"1+2=3", true},
+		{"2*3<>6", false},
+		{"1<2=TRUE", true},
+		{"SUM(1,2)*2^2", 12.0},
+		{"IF(1>2,1,2)+1", 3.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formula, func(t *testing.T) {
+			wb := NewWorkbook()
+			ws := wb.AddWorksheet("Sheet1")
+			ws.SetCellFormula("A1", tt.formula)
+
+			got, err := wb.CalcCellValue("Sheet1", "A1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f, ok := got.(float64); ok {
+				if want, ok := tt.want.(float64); ok && math.Abs(f-want) < 1e-12 {
+					return
+				}
+			}
+			if got != tt.want {
+				t.Errorf("%s = %#v, 期望 %#v", tt.formula, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormulaReferences 检查引用其他单元格、区域和工作表的计算结果
+func TestFormulaReferences(t *testing.T) {
+	wb := NewWorkbook()
+	ws := wb.AddWorksheet("Sheet1")
+	data := wb.AddWorksheet("数据 表")
+	for i, v := range []float64{1, 2, 3, 4} {
+		ws.SetCellValue(i, 0, v)
+		data.SetCellValue(i, 1, v*10)
+	}
+
+	tests := []struct {
+		formula string
+		want    interface{}
+	}{
+		{"A1+A2*A3", 7.0},
+		{"SUM(A1:A4)", 10.0},
+		{"SUM($A$1:A4)/COUNT(A:A)", 2.5},
+		{"'数据 表'!B2+A1", 21.0},
+		{"SUM('数据 表'!B1:B4)", 100.0},
+		{"A1/0", FormulaErrorDiv0},
+	}
+
+	for _, tt := range tests {
+		ws.SetCellFormula("C1", tt.formula)
+		got, err := wb.CalcCellValue("Sheet1", "C1")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.formula, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s = %#v, 期望 %#v", tt.formula, got, tt.want)
+		}
+	}
+}
+
+// TestStoredFormula 检查写入文件的公式为新函数添加_xlfn.前缀，字符串和已有前缀保持不变
+func TestStoredFormula(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    string
+	}{
+		{"=SUM(A1:A3)", "SUM(A1:A3)"},
+		{"XLOOKUP(A1,B:B,C:C)", "_xlfn.XLOOKUP(A1,B:B,C:C)"},
+		{"concat(A1,\"x\")&TEXTJOIN(\",\",TRUE,A1:A3)", "_xlfn.concat(A1,\"x\")&_xlfn.TEXTJOIN(\",\",TRUE,A1:A3)"},
+		{"\"IFS(\"&A1", "\"IFS(\"&A1"},
+		{"_xlfn.IFS(A1>0,1)", "_xlfn.IFS(A1>0,1)"},
+		{"SORT(A1:A3)", "_xlfn._xlws.SORT(A1:A3)"},
+		{"IFS", "IFS"}, // 不是函数调用的名称保持不变
+		{"SUM(", "SUM("},
+	}
+
+	for _, tt := range tests {
+		if got := storedFormula(tt.formula); got != tt.want {
+			t.Errorf("storedFormula(%q) = %q, 期望 %q", tt.formula, got, tt.want)
+		}
+	}
+}
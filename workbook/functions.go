@@ -0,0 +1,1199 @@
+package workbook
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// formulaFunc 表示一个工作表函数，参数已经计算，引用参数为*rangeValue
+type formulaFunc func(e *calcEngine, args []interface{}) interface{}
+
+// formulaFunction 描述一个工作表函数及其参数个数，maxArgs为-1表示不限
+type formulaFunction struct {
+	minArgs, maxArgs int
+	fn               formulaFunc
+}
+
+// formulaFunctions 支持的工作表函数，IF、IFERROR和IFNA按需计算参数，在evalFunction中处理
+var formulaFunctions map[string]formulaFunction
+
+func init() {
+	formulaFunctions = map[string]formulaFunction{
+		// 数学和统计
+		"SUM":       {1, -1, fnSum},
+		"AVERAGE":   {1, -1, fnAverage},
+		"MIN":       {1, -1, fnMin},
+		"MAX":       {1, -1, fnMax},
+		"COUNT":     {1, -1, fnCount},
+		"COUNTA":    {1, -1, fnCountA},
+		"COUNTIF":   {2, 2, fnCountIf},
+		"SUMIF":     {2, 3, fnSumIf},
+		"AVERAGEIF": {2, 3, fnAverageIf},
+		"ROUND":     {2, 2, roundFunc(math.Round)},
+		"ROUNDUP":   {2, 2, roundFunc(roundUp)},
+		"ROUNDDOWN": {2, 2, roundFunc(math.Trunc)},
+		"INT":       {1, 1, mathFunc(math.Floor)},
+		"ABS":       {1, 1, mathFunc(math.Abs)},
+		"SQRT":      {1, 1, fnSqrt},
+		"MOD":       {2, 2, fnMod},
+		"POWER":     {2, 2, fnPower},
+		"SUBTOTAL":  {2, -1, fnSubtotal},
+
+		// 逻辑
+		"AND":   {1, -1, fnAnd},
+		"OR":    {1, -1, fnOr},
+		"NOT":   {1, 1, fnNot},
+		"TRUE":  {0, 0, func(e *calcEngine, args []interface{}) interface{} { return true }},
+		"FALSE": {0, 0, func(e *calcEngine, args []interface{}) interface{} { return false }},
+
+		// 查找和引用
+		"VLOOKUP": {3, 4, fnVLookup},
+		"HLOOKUP": {3, 4, fnHLookup},
+		"XLOOKUP": {3, 6, fnXLookup},
+		"INDEX":   {2, 3, fnIndex},
+		"MATCH":   {2, 3, fnMatch},
+
+		// 文本
+		"CONCAT":      {1, -1, fnConcat},
+		"CONCATENATE": {1, -1, fnConcat},
+		"TEXT":        {2, 2, fnText},
+		"LEN":         {1, 1, fnLen},
+		"LEFT":        {1, 2, fnLeft},
+		"RIGHT":       {1, 2, fnRight},
+		"MID":         {3, 3, fnMid},
+		"UPPER":       {1, 1, textFunc(strings.ToUpper)},
+		"LOWER":       {1, 1, textFunc(strings.ToLower)},
+		"TRIM":        {1, 1, textFunc(func(s string) string { return strings.Join(strings.Fields(s), " ") })},
+
+		// 日期和时间
+		"DATE":    {3, 3, fnDate},
+		"TIME":    {3, 3, fnTime},
+		"TODAY":   {0, 0, fnToday},
+		"NOW":     {0, 0, fnNow},
+		"YEAR":    {1, 1, datePartFunc(func(t time.Time) int { return t.Year() })},
+		"MONTH":   {1, 1, datePartFunc(func(t time.Time) int { return int(t.Month()) })},
+		"DAY":     {1, 1, datePartFunc(func(t time.Time) int { return t.Day() })},
+		"HOUR":    {1, 1, datePartFunc(func(t time.Time) int { return t.Hour() })},
+		"MINUTE":  {1, 1, datePartFunc(func(t time.Time) int { return t.Minute() })},
+		"SECOND":  {1, 1, datePartFunc(func(t time.Time) int { return t.Second() })},
+		"WEEKDAY": {1, 2, fnWeekday},
+		"EDATE":   {2, 2, fnEDate},
+		"EOMONTH": {2, 2, fnEOMonth},
+		"DAYS":    {2, 2, fnDays},
+	}
+}
+
+// evalFunction 计算函数调用
+func (e *calcEngine) evalFunction(n *funcNode, ws *Worksheet) interface{} {
+	switch n.name {
+	case "IF":
+		if len(n.args) < 2 || len(n.args) > 3 {
+			return FormulaErrorValue
+		}
+		cond, errValue := toBool(e.scalar(e.eval(n.args[0], ws)))
+		if errValue != nil {
+			return errValue
+		}
+		if cond {
+			return e.eval(n.args[1], ws)
+		}
+		if len(n.args) == 3 {
+			return e.eval(n.args[2], ws)
+		}
+		return false
+	case "IFERROR", "IFNA":
+		if len(n.args) != 2 {
+			return FormulaErrorValue
+		}
+		value := e.scalar(e.eval(n.args[0], ws))
+		if err, ok := value.(FormulaError); ok && (n.name == "IFERROR" || err == FormulaErrorNA) {
+			return e.eval(n.args[1], ws)
+		}
+		return value
+	}
+
+	f, ok := formulaFunctions[n.name]
+	if !ok {
+		return FormulaErrorName
+	}
+	if len(n.args) < f.minArgs || f.maxArgs >= 0 && len(n.args) > f.maxArgs {
+		return FormulaErrorValue
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		args[i] = e.eval(arg, ws)
+	}
+	return f.fn(e, args)
+}
+
+// numberArg 将参数转换为数字
+func numberArg(e *calcEngine, arg interface{}) (float64, interface{}) {
+	return toNumber(e.scalar(arg))
+}
+
+// eachNumber 依次访问参数中的数字
+// 直接给出的参数按数字转换，区域中只计算数字，忽略文本和逻辑值；遇到错误值时返回该错误
+func eachNumber(e *calcEngine, args []interface{}, fn func(f float64)) interface{} {
+	for _, arg := range args {
+		if r, ok := arg.(*rangeValue); ok {
+			var errValue interface{}
+			r.each(func(i, j int, value interface{}) {
+				switch v := value.(type) {
+				case float64:
+					fn(v)
+				case FormulaError:
+					if errValue == nil {
+						errValue = v
+					}
+				}
+			})
+			if errValue != nil {
+				return errValue
+			}
+			continue
+		}
+		if arg == nil {
+			continue
+		}
+		f, errValue := toNumber(arg)
+		if errValue != nil {
+			return errValue
+		}
+		fn(f)
+	}
+	return nil
+}
+
+func fnSum(e *calcEngine, args []interface{}) interface{} {
+	sum := 0.0
+	if errValue := eachNumber(e, args, func(f float64) { sum += f }); errValue != nil {
+		return errValue
+	}
+	return sum
+}
+
+func fnAverage(e *calcEngine, args []interface{}) interface{} {
+	sum, count := 0.0, 0
+	if errValue := eachNumber(e, args, func(f float64) { sum += f; count++ }); errValue != nil {
+		return errValue
+	}
+	if count == 0 {
+		return FormulaErrorDiv0
+	}
+	return sum / float64(count)
+}
+
+func fnMin(e *calcEngine, args []interface{}) interface{} {
+	result, found := 0.0, false
+	errValue := eachNumber(e, args, func(f float64) {
+		if !found || f < result {
+			result, found = f, true
+		}
+	})
+	if errValue != nil {
+		return errValue
+	}
+	return result
+}
+
+func fnMax(e *calcEngine, args []interface{}) interface{} {
+	result, found := 0.0, false
+	errValue := eachNumber(e, args, func(f float64) {
+		if !found || f > result {
+			result, found = f, true
+		}
+	})
+	if errValue != nil {
+		return errValue
+	}
+	return result
+}
+
+func fnCount(e *calcEngine, args []interface{}) interface{} {
+	count := 0
+	for _, arg := range args {
+		if r, ok := arg.(*rangeValue); ok {
+			r.each(func(i, j int, value interface{}) {
+				if _, ok := value.(float64); ok {
+					count++
+				}
+			})
+			continue
+		}
+		if _, errValue := toNumber(arg); arg != nil && errValue == nil {
+			count++
+		}
+	}
+	return float64(count)
+}
+
+func fnCountA(e *calcEngine, args []interface{}) interface{} {
+	count := 0
+	for _, arg := range args {
+		if r, ok := arg.(*rangeValue); ok {
+			r.each(func(i, j int, value interface{}) { count++ })
+			continue
+		}
+		if arg != nil {
+			count++
+		}
+	}
+	return float64(count)
+}
+
+// subtotalFunctions SUBTOTAL的功能号对应的函数，加100的功能号忽略隐藏的行
+var subtotalFunctions = map[int]formulaFunc{
+	1:  fnAverage,
+	2:  fnCount,
+	3:  fnCountA,
+	4:  fnMax,
+	5:  fnMin,
+	6:  fnProduct,
+	7:  varianceFunc(true, true),
+	8:  varianceFunc(false, true),
+	9:  fnSum,
+	10: varianceFunc(true, false),
+	11: varianceFunc(false, false),
+}
+
+func fnSubtotal(e *calcEngine, args []interface{}) interface{} {
+	code, errValue := numberArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	fn, ok := subtotalFunctions[int(code)%100]
+	if !ok || code < 1 || code >= 112 || code > 11 && code < 101 {
+		return FormulaErrorValue
+	}
+	refs := make([]interface{}, len(args)-1)
+	for i, arg := range args[1:] {
+		r, ok := arg.(*rangeValue)
+		if !ok {
+			return FormulaErrorValue
+		}
+		visible := *r
+		visible.visibleOnly = code > 100
+		refs[i] = &visible
+	}
+	return fn(e, refs)
+}
+
+func fnProduct(e *calcEngine, args []interface{}) interface{} {
+	product, found := 1.0, false
+	if errValue := eachNumber(e, args, func(f float64) { product *= f; found = true }); errValue != nil {
+		return errValue
+	}
+	if !found {
+		return 0.0
+	}
+	return product
+}
+
+// varianceFunc 返回计算方差或标准差的函数，sample表示样本方差，stdDev表示开平方得到标准差
+func varianceFunc(sample, stdDev bool) formulaFunc {
+	return func(e *calcEngine, args []interface{}) interface{} {
+		var values []float64
+		sum := 0.0
+		if errValue := eachNumber(e, args, func(f float64) { values = append(values, f); sum += f }); errValue != nil {
+			return errValue
+		}
+		n := float64(len(values))
+		if sample && n < 2 || n < 1 {
+			return FormulaErrorDiv0
+		}
+		mean, squares := sum/n, 0.0
+		for _, v := range values {
+			squares += (v - mean) * (v - mean)
+		}
+		if sample {
+			n--
+		}
+		if stdDev {
+			return math.Sqrt(squares / n)
+		}
+		return squares / n
+	}
+}
+
+func fnCountIf(e *calcEngine, args []interface{}) interface{} {
+	r, ok := args[0].(*rangeValue)
+	if !ok {
+		return FormulaErrorValue
+	}
+	match := parseCriteria(e.scalar(args[1]))
+	count := 0
+	r.each(func(i, j int, value interface{}) {
+		if match(value) {
+			count++
+		}
+	})
+	return float64(count)
+}
+
+// sumIf 计算满足条件的单元格的和与个数，sum_range省略时对条件区域本身求和
+func sumIf(e *calcEngine, args []interface{}) (float64, int, interface{}) {
+	r, ok := args[0].(*rangeValue)
+	if !ok {
+		return 0, 0, FormulaErrorValue
+	}
+	sumRange := r
+	if len(args) == 3 && args[2] != nil {
+		if sumRange, ok = args[2].(*rangeValue); !ok {
+			return 0, 0, FormulaErrorValue
+		}
+	}
+
+	match := parseCriteria(e.scalar(args[1]))
+	sum, count := 0.0, 0
+	r.each(func(i, j int, value interface{}) {
+		if !match(value) {
+			return
+		}
+		if f, ok := sumRange.at(i, j).(float64); ok {
+			sum += f
+			count++
+		}
+	})
+	return sum, count, nil
+}
+
+func fnSumIf(e *calcEngine, args []interface{}) interface{} {
+	sum, _, errValue := sumIf(e, args)
+	if errValue != nil {
+		return errValue
+	}
+	return sum
+}
+
+func fnAverageIf(e *calcEngine, args []interface{}) interface{} {
+	sum, count, errValue := sumIf(e, args)
+	if errValue != nil {
+		return errValue
+	}
+	if count == 0 {
+		return FormulaErrorDiv0
+	}
+	return sum / float64(count)
+}
+
+// parseCriteria 解析COUNTIF等函数的条件，如">5"、"<>完成"、"A*"
+func parseCriteria(criteria interface{}) func(value interface{}) bool {
+	text, ok := criteria.(string)
+	if !ok {
+		return func(value interface{}) bool {
+			return compareValues(value, criteria) == 0 && sameKind(value, criteria)
+		}
+	}
+
+	op := "="
+	for _, prefix := range []string{"<=", ">=", "<>", "<", ">", "="} {
+		if strings.HasPrefix(text, prefix) {
+			op, text = prefix, text[len(prefix):]
+			break
+		}
+	}
+
+	var operand interface{} = text
+	if f, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+		operand = f
+	} else if b, errValue := toBool(text); errValue == nil {
+		operand = b
+	}
+
+	var pattern *regexp.Regexp
+	if s, ok := operand.(string); ok && (op == "=" || op == "<>") && strings.ContainsAny(s, "*?") {
+		pattern = wildcardPattern(s)
+	}
+
+	return func(value interface{}) bool {
+		equal := false
+		if pattern != nil {
+			s, ok := value.(string)
+			equal = ok && pattern.MatchString(s)
+		} else {
+			equal = sameKind(value, operand) && compareValues(value, operand) == 0
+		}
+
+		switch op {
+		case "=":
+			return equal
+		case "<>":
+			return !equal
+		}
+		if !sameKind(value, operand) {
+			return false
+		}
+		c := compareValues(value, operand)
+		switch op {
+		case "<":
+			return c < 0
+		case ">":
+			return c > 0
+		case "<=":
+			return c <= 0
+		}
+		return c >= 0
+	}
+}
+
+// sameKind 判断两个值是否为同一类型
+func sameKind(a, b interface{}) bool {
+	switch a.(type) {
+	case float64:
+		_, ok := b.(float64)
+		return ok
+	case string:
+		_, ok := b.(string)
+		return ok
+	case bool:
+		_, ok := b.(bool)
+		return ok
+	}
+	return false
+}
+
+// wildcardPattern 将带*和?通配符的文本转换为不区分大小写的正则表达式，~用于转义通配符
+func wildcardPattern(s string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("(?is)^")
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '~':
+			if i+1 < len(runes) {
+				i++
+			}
+			sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// roundUp 远离0方向取整
+func roundUp(f float64) float64 {
+	if f < 0 {
+		return math.Floor(f)
+	}
+	return math.Ceil(f)
+}
+
+// roundFunc 创建按小数位数取整的函数
+func roundFunc(round func(float64) float64) formulaFunc {
+	return func(e *calcEngine, args []interface{}) interface{} {
+		f, errValue := numberArg(e, args[0])
+		if errValue != nil {
+			return errValue
+		}
+		digits, errValue := numberArg(e, args[1])
+		if errValue != nil {
+			return errValue
+		}
+		scale := math.Pow(10, math.Trunc(digits))
+		// 先消除二进制表示误差，使2.675这类数字按十进制取整
+		v := math.Round(f*scale*1e9) / 1e9
+		return round(v) / scale
+	}
+}
+
+// mathFunc 创建单参数的数学函数
+func mathFunc(fn func(float64) float64) formulaFunc {
+	return func(e *calcEngine, args []interface{}) interface{} {
+		f, errValue := numberArg(e, args[0])
+		if errValue != nil {
+			return errValue
+		}
+		return fn(f)
+	}
+}
+
+func fnSqrt(e *calcEngine, args []interface{}) interface{} {
+	f, errValue := numberArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	if f < 0 {
+		return FormulaErrorNum
+	}
+	return math.Sqrt(f)
+}
+
+func fnMod(e *calcEngine, args []interface{}) interface{} {
+	a, errValue := numberArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	b, errValue := numberArg(e, args[1])
+	if errValue != nil {
+		return errValue
+	}
+	if b == 0 {
+		return FormulaErrorDiv0
+	}
+	// 结果与除数同号
+	return a - b*math.Floor(a/b)
+}
+
+func fnPower(e *calcEngine, args []interface{}) interface{} {
+	a, errValue := numberArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	b, errValue := numberArg(e, args[1])
+	if errValue != nil {
+		return errValue
+	}
+	result := math.Pow(a, b)
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return FormulaErrorNum
+	}
+	return result
+}
+
+// eachBool 依次访问参数中的逻辑值，区域中的文本被忽略
+func eachBool(e *calcEngine, args []interface{}, fn func(b bool)) interface{} {
+	found := false
+	for _, arg := range args {
+		if r, ok := arg.(*rangeValue); ok {
+			var errValue interface{}
+			r.each(func(i, j int, value interface{}) {
+				if _, ok := value.(string); ok {
+					return
+				}
+				b, err := toBool(value)
+				if err != nil {
+					if errValue == nil {
+						errValue = err
+					}
+					return
+				}
+				found = true
+				fn(b)
+			})
+			if errValue != nil {
+				return errValue
+			}
+			continue
+		}
+		b, errValue := toBool(arg)
+		if errValue != nil {
+			return errValue
+		}
+		found = true
+		fn(b)
+	}
+	if !found {
+		return FormulaErrorValue
+	}
+	return nil
+}
+
+func fnAnd(e *calcEngine, args []interface{}) interface{} {
+	result := true
+	if errValue := eachBool(e, args, func(b bool) { result = result && b }); errValue != nil {
+		return errValue
+	}
+	return result
+}
+
+func fnOr(e *calcEngine, args []interface{}) interface{} {
+	result := false
+	if errValue := eachBool(e, args, func(b bool) { result = result || b }); errValue != nil {
+		return errValue
+	}
+	return result
+}
+
+func fnNot(e *calcEngine, args []interface{}) interface{} {
+	b, errValue := toBool(e.scalar(args[0]))
+	if errValue != nil {
+		return errValue
+	}
+	return !b
+}
+
+// lookupVector 在列表中查找值的位置，matchType与MATCH相同：
+// 0精确匹配（文本支持通配符），1查找小于等于查找值的最大值（升序），-1查找大于等于查找值的最小值（降序）
+func lookupVector(values []interface{}, lookup interface{}, matchType int) int {
+	if matchType == 0 {
+		match := parseCriteria(lookup)
+		if s, ok := lookup.(string); ok {
+			match = parseCriteria("=" + s)
+		}
+		for i, value := range values {
+			if value != nil && match(value) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	found := -1
+	for i, value := range values {
+		if value == nil || !sameKind(value, lookup) {
+			continue
+		}
+		c := compareValues(value, lookup)
+		if c == 0 {
+			return i
+		}
+		if matchType > 0 && c < 0 || matchType < 0 && c > 0 {
+			found = i
+			continue
+		}
+		break
+	}
+	return found
+}
+
+// clip 将区域裁剪到工作表中实际存储数据的范围，避免整列引用逐个访问空单元格
+func (r *rangeValue) clip() *rangeValue {
+	clipped := *r
+	if n := len(r.ws.Rows); n == 0 {
+		clipped.row2 = clipped.row1
+	} else if last := r.ws.Rows[n-1].Index - 1; clipped.row2 > last {
+		clipped.row2 = max(last, clipped.row1)
+	}
+
+	lastCol := 0
+	for _, row := range r.ws.Rows {
+		if n := len(row.Cells); n > 0 && row.Cells[n-1].Col > lastCol {
+			lastCol = row.Cells[n-1].Col
+		}
+	}
+	if clipped.col2 > lastCol {
+		clipped.col2 = max(lastCol, clipped.col1)
+	}
+	return &clipped
+}
+
+// column 返回区域中第j列组成的列表
+func (r *rangeValue) column(j int) []interface{} {
+	values := make([]interface{}, r.rows())
+	for i := range values {
+		values[i] = r.at(i, j)
+	}
+	return values
+}
+
+// row 返回区域中第i行组成的列表
+func (r *rangeValue) row(i int) []interface{} {
+	values := make([]interface{}, r.cols())
+	for j := range values {
+		values[j] = r.at(i, j)
+	}
+	return values
+}
+
+// tableLookup 实现VLOOKUP和HLOOKUP，vertical为true时在首列查找
+func tableLookup(e *calcEngine, args []interface{}, vertical bool) interface{} {
+	lookup := e.scalar(args[0])
+	if errValue, ok := lookup.(FormulaError); ok {
+		return errValue
+	}
+	table, ok := args[1].(*rangeValue)
+	if !ok {
+		return FormulaErrorValue
+	}
+	table = table.clip()
+
+	index, errValue := numberArg(e, args[2])
+	if errValue != nil {
+		return errValue
+	}
+	approximate := true
+	if len(args) == 4 && args[3] != nil {
+		if approximate, errValue = toBool(e.scalar(args[3])); errValue != nil {
+			return errValue
+		}
+	}
+
+	n := int(index) - 1
+	if n < 0 {
+		return FormulaErrorValue
+	}
+	if vertical && n >= table.cols() || !vertical && n >= table.rows() {
+		return FormulaErrorRef
+	}
+
+	matchType := 0
+	if approximate {
+		matchType = 1
+	}
+	if vertical {
+		i := lookupVector(table.column(0), lookup, matchType)
+		if i < 0 {
+			return FormulaErrorNA
+		}
+		return table.at(i, n)
+	}
+	j := lookupVector(table.row(0), lookup, matchType)
+	if j < 0 {
+		return FormulaErrorNA
+	}
+	return table.at(n, j)
+}
+
+func fnVLookup(e *calcEngine, args []interface{}) interface{} {
+	return tableLookup(e, args, true)
+}
+
+func fnHLookup(e *calcEngine, args []interface{}) interface{} {
+	return tableLookup(e, args, false)
+}
+
+func fnMatch(e *calcEngine, args []interface{}) interface{} {
+	lookup := e.scalar(args[0])
+	if errValue, ok := lookup.(FormulaError); ok {
+		return errValue
+	}
+	r, ok := args[1].(*rangeValue)
+	if !ok {
+		return FormulaErrorNA
+	}
+	values, ok := r.clip().vector()
+	if !ok {
+		return FormulaErrorNA
+	}
+
+	matchType := 1.0
+	if len(args) == 3 && args[2] != nil {
+		var errValue interface{}
+		if matchType, errValue = numberArg(e, args[2]); errValue != nil {
+			return errValue
+		}
+	}
+
+	i := lookupVector(values, lookup, int(math.Copysign(math.Min(math.Abs(matchType), 1), matchType)))
+	if i < 0 {
+		return FormulaErrorNA
+	}
+	return float64(i + 1)
+}
+
+func fnIndex(e *calcEngine, args []interface{}) interface{} {
+	r, ok := args[0].(*rangeValue)
+	if !ok {
+		if len(args) == 2 {
+			// 对常量取索引
+			return e.scalar(args[0])
+		}
+		return FormulaErrorValue
+	}
+
+	rowNum, errValue := numberArg(e, args[1])
+	if errValue != nil {
+		return errValue
+	}
+	colNum := 0.0
+	if len(args) == 3 && args[2] != nil {
+		if colNum, errValue = numberArg(e, args[2]); errValue != nil {
+			return errValue
+		}
+	} else if r.rows() == 1 {
+		// 单行区域只给出一个索引时按列取值
+		rowNum, colNum = 1, rowNum
+	}
+
+	i, j := int(rowNum), int(colNum)
+	if i < 0 || j < 0 || i > r.rows() || j > r.cols() {
+		return FormulaErrorRef
+	}
+
+	// 行号或列号为0时返回整列或整行
+	sub := *r
+	if i > 0 {
+		sub.row1, sub.row2 = r.row1+i-1, r.row1+i-1
+	}
+	if j > 0 {
+		sub.col1, sub.col2 = r.col1+j-1, r.col1+j-1
+	} else if r.cols() > 1 && i > 0 && len(args) < 3 {
+		return FormulaErrorRef
+	}
+	return &sub
+}
+
+func fnXLookup(e *calcEngine, args []interface{}) interface{} {
+	lookup := e.scalar(args[0])
+	if errValue, ok := lookup.(FormulaError); ok {
+		return errValue
+	}
+	lookupRange, ok1 := args[1].(*rangeValue)
+	returnRange, ok2 := args[2].(*rangeValue)
+	if !ok1 || !ok2 {
+		return FormulaErrorValue
+	}
+	lookupRange = lookupRange.clip()
+	values, ok := lookupRange.vector()
+	if !ok {
+		return FormulaErrorValue
+	}
+
+	matchMode, searchMode := 0.0, 1.0
+	var errValue interface{}
+	if len(args) >= 5 && args[4] != nil {
+		if matchMode, errValue = numberArg(e, args[4]); errValue != nil {
+			return errValue
+		}
+	}
+	if len(args) == 6 && args[5] != nil {
+		if searchMode, errValue = numberArg(e, args[5]); errValue != nil {
+			return errValue
+		}
+	}
+
+	// 按搜索方向依次比较，精确匹配优先，否则取最接近的较小或较大值
+	found := -1
+	var match func(value interface{}) bool
+	if matchMode == 2 {
+		match = parseCriteria(lookup)
+	} else if s, ok := lookup.(string); ok {
+		match = parseCriteria("=" + strings.NewReplacer("~", "~~", "*", "~*", "?", "~?").Replace(s))
+	} else {
+		match = parseCriteria(lookup)
+	}
+	for k := range values {
+		i := k
+		if searchMode < 0 {
+			i = len(values) - 1 - k
+		}
+		value := values[i]
+		if value == nil {
+			continue
+		}
+		if match(value) {
+			found = i
+			break
+		}
+		if matchMode != -1 && matchMode != 1 || !sameKind(value, lookup) {
+			continue
+		}
+		c := compareValues(value, lookup)
+		if matchMode == -1 && c < 0 && (found < 0 || compareValues(value, values[found]) > 0) ||
+			matchMode == 1 && c > 0 && (found < 0 || compareValues(value, values[found]) < 0) {
+			found = i
+		}
+	}
+
+	if found < 0 {
+		if len(args) >= 4 && args[3] != nil {
+			return args[3]
+		}
+		return FormulaErrorNA
+	}
+
+	// 返回区域与查找区域方向一致时取对应的行或列
+	result := *returnRange
+	if lookupRange.cols() == 1 && lookupRange.rows() > 1 || lookupRange.rows() == 1 && returnRange.rows() > 1 && returnRange.cols() == 1 {
+		result.row1, result.row2 = returnRange.row1+found, returnRange.row1+found
+	} else {
+		result.col1, result.col2 = returnRange.col1+found, returnRange.col1+found
+	}
+	return &result
+}
+
+func fnConcat(e *calcEngine, args []interface{}) interface{} {
+	var sb strings.Builder
+	for _, arg := range args {
+		if r, ok := arg.(*rangeValue); ok {
+			var errValue interface{}
+			r.each(func(i, j int, value interface{}) {
+				if err, ok := value.(FormulaError); ok && errValue == nil {
+					errValue = err
+				}
+				sb.WriteString(toText(value))
+			})
+			if errValue != nil {
+				return errValue
+			}
+			continue
+		}
+		if errValue, ok := arg.(FormulaError); ok {
+			return errValue
+		}
+		sb.WriteString(toText(arg))
+	}
+	return sb.String()
+}
+
+// textArg 将参数转换为文本
+func textArg(e *calcEngine, arg interface{}) (string, interface{}) {
+	value := e.scalar(arg)
+	if errValue, ok := value.(FormulaError); ok {
+		return "", errValue
+	}
+	return toText(value), nil
+}
+
+// textFunc 创建单参数的文本函数
+func textFunc(fn func(string) string) formulaFunc {
+	return func(e *calcEngine, args []interface{}) interface{} {
+		s, errValue := textArg(e, args[0])
+		if errValue != nil {
+			return errValue
+		}
+		return fn(s)
+	}
+}
+
+func fnLen(e *calcEngine, args []interface{}) interface{} {
+	s, errValue := textArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	return float64(len([]rune(s)))
+}
+
+// countArg 读取可选的字符个数参数，默认为1
+func countArg(e *calcEngine, args []interface{}, i int) (int, interface{}) {
+	if len(args) <= i || args[i] == nil {
+		return 1, nil
+	}
+	n, errValue := numberArg(e, args[i])
+	if errValue != nil {
+		return 0, errValue
+	}
+	if n < 0 {
+		return 0, FormulaErrorValue
+	}
+	return int(n), nil
+}
+
+func fnLeft(e *calcEngine, args []interface{}) interface{} {
+	s, errValue := textArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	n, errValue := countArg(e, args, 1)
+	if errValue != nil {
+		return errValue
+	}
+	runes := []rune(s)
+	return string(runes[:min(n, len(runes))])
+}
+
+func fnRight(e *calcEngine, args []interface{}) interface{} {
+	s, errValue := textArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	n, errValue := countArg(e, args, 1)
+	if errValue != nil {
+		return errValue
+	}
+	runes := []rune(s)
+	return string(runes[len(runes)-min(n, len(runes)):])
+}
+
+func fnMid(e *calcEngine, args []interface{}) interface{} {
+	s, errValue := textArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	start, errValue := numberArg(e, args[1])
+	if errValue != nil {
+		return errValue
+	}
+	n, errValue := countArg(e, args, 2)
+	if errValue != nil {
+		return errValue
+	}
+	if start < 1 {
+		return FormulaErrorValue
+	}
+	runes := []rune(s)
+	from := min(int(start)-1, len(runes))
+	return string(runes[from:min(from+n, len(runes))])
+}
+
+func fnText(e *calcEngine, args []interface{}) interface{} {
+	value := e.scalar(args[0])
+	if errValue, ok := value.(FormulaError); ok {
+		return errValue
+	}
+	format, errValue := textArg(e, args[1])
+	if errValue != nil {
+		return errValue
+	}
+	f, errValue := toNumber(value)
+	if errValue != nil {
+		// 文本原样返回
+		return toText(value)
+	}
+	return formatValue(f, format)
+}
+
+// excelEpoch Excel序列日期的零点
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// serialToTime 将Excel序列日期转换为UTC时间
+// 序列号60是Excel为兼容Lotus保留的1900年2月29日，之前的日期需要后移一天
+func serialToTime(serial float64) time.Time {
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 86400)
+	if days < 61 {
+		days++
+	}
+	return excelEpoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+}
+
+// timeToSerial 将时间转换为Excel序列日期，忽略时区
+func timeToSerial(t time.Time) float64 {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	days := math.Round(date.Sub(excelEpoch).Hours() / 24)
+	if days < 61 {
+		days--
+	}
+	return days + float64(t.Hour()*3600+t.Minute()*60+t.Second())/86400
+}
+
+// dateArg 将参数转换为日期
+func dateArg(e *calcEngine, arg interface{}) (time.Time, interface{}) {
+	value := e.scalar(arg)
+	if s, ok := value.(string); ok {
+		for _, layout := range []string{"2006-01-02", "2006/01/02", "2006-01-02 15:04:05", "2006/1/2"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+	}
+	f, errValue := toNumber(value)
+	if errValue != nil {
+		return time.Time{}, errValue
+	}
+	if f < 0 {
+		return time.Time{}, FormulaErrorNum
+	}
+	return serialToTime(f), nil
+}
+
+func fnDate(e *calcEngine, args []interface{}) interface{} {
+	parts := make([]int, 3)
+	for i := range parts {
+		f, errValue := numberArg(e, args[i])
+		if errValue != nil {
+			return errValue
+		}
+		parts[i] = int(f)
+	}
+	year := parts[0]
+	if year < 1900 {
+		year += 1900
+	}
+	if year < 1900 || year > 9999 {
+		return FormulaErrorNum
+	}
+	return timeToSerial(time.Date(year, time.Month(parts[1]), parts[2], 0, 0, 0, 0, time.UTC))
+}
+
+func fnTime(e *calcEngine, args []interface{}) interface{} {
+	seconds := 0.0
+	for i, unit := range []float64{3600, 60, 1} {
+		f, errValue := numberArg(e, args[i])
+		if errValue != nil {
+			return errValue
+		}
+		seconds += math.Trunc(f) * unit
+	}
+	if seconds < 0 {
+		return FormulaErrorNum
+	}
+	return math.Mod(seconds, 86400) / 86400
+}
+
+func fnToday(e *calcEngine, args []interface{}) interface{} {
+	return math.Floor(timeToSerial(e.now))
+}
+
+func fnNow(e *calcEngine, args []interface{}) interface{} {
+	return timeToSerial(e.now)
+}
+
+// datePartFunc 创建返回日期中某一部分的函数
+func datePartFunc(part func(t time.Time) int) formulaFunc {
+	return func(e *calcEngine, args []interface{}) interface{} {
+		t, errValue := dateArg(e, args[0])
+		if errValue != nil {
+			return errValue
+		}
+		return float64(part(t))
+	}
+}
+
+func fnWeekday(e *calcEngine, args []interface{}) interface{} {
+	t, errValue := dateArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	returnType := 1.0
+	if len(args) == 2 && args[1] != nil {
+		if returnType, errValue = numberArg(e, args[1]); errValue != nil {
+			return errValue
+		}
+	}
+
+	weekday := int(t.Weekday()) // 星期日为0
+	switch int(returnType) {
+	case 1:
+		return float64(weekday + 1)
+	case 2:
+		return float64((weekday+6)%7 + 1)
+	case 3:
+		return float64((weekday + 6) % 7)
+	}
+	return FormulaErrorNum
+}
+
+// addMonths 在日期上增加若干个月，日期超出目标月份的天数时取该月最后一天
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(t.Day(), lastDay), 0, 0, 0, 0, time.UTC)
+}
+
+func fnEDate(e *calcEngine, args []interface{}) interface{} {
+	t, errValue := dateArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	months, errValue := numberArg(e, args[1])
+	if errValue != nil {
+		return errValue
+	}
+	return timeToSerial(addMonths(t, int(months)))
+}
+
+func fnEOMonth(e *calcEngine, args []interface{}) interface{} {
+	t, errValue := dateArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	months, errValue := numberArg(e, args[1])
+	if errValue != nil {
+		return errValue
+	}
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	return timeToSerial(first.AddDate(0, 1, -1))
+}
+
+func fnDays(e *calcEngine, args []interface{}) interface{} {
+	end, errValue := dateArg(e, args[0])
+	if errValue != nil {
+		return errValue
+	}
+	start, errValue := dateArg(e, args[1])
+	if errValue != nil {
+		return errValue
+	}
+	return math.Floor(timeToSerial(end)) - math.Floor(timeToSerial(start))
+}
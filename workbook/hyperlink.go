@@ -0,0 +1,155 @@
+package workbook
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/landaiqing/go-dockit/opc"
+)
+
+// 超链接的长度限制，与Excel一致
+const (
+	maxHyperlinkTargetLength  = 2079
+	maxHyperlinkTooltipLength = 255
+)
+
+// Hyperlink 表示单元格的超链接
+// Target和Location只设置其中一个：Target为外部链接，Location为工作簿内的位置
+type Hyperlink struct {
+	Ref      string // 超链接所在的单元格，如 "A2"
+	Target   string // 外部链接，如 "https://example.com" 或 "mailto:someone@example.com"
+	Location string // 工作簿内的位置，如 "'Sheet 2'!A1"，也可以是定义的名称
+	Display  string // 单元格中显示的文本
+	Tooltip  string // 鼠标悬停时显示的提示
+}
+
+// SetCellHyperlink 为单元格设置超链接，单元格已有超链接时替换原超链接，并应用内置的超链接样式
+// target为URL、mailto链接或工作簿内的位置，位置可以写为 "'Sheet 2'!A1" 或 "#'Sheet 2'!A1"
+// display不为空时作为单元格的值，为空时空单元格显示target；tooltip为鼠标悬停时显示的提示，可以为空
+// 例如: ws.SetCellHyperlink("A2", "'明细'!A1", "查看明细", "")
+func (ws *Worksheet) SetCellHyperlink(cellRef, target, display, tooltip string) error {
+	if ws.stream != nil {
+		return fmt.Errorf("流式写入的工作表 %s 不支持超链接", ws.Name)
+	}
+	if ws.wb == nil {
+		return fmt.Errorf("工作表 %s 不属于任何工作簿，无法设置样式", ws.Name)
+	}
+	if !isValidCellRef(cellRef) {
+		return fmt.Errorf("无效的单元格引用: %s", cellRef)
+	}
+	if target == "" {
+		return fmt.Errorf("超链接的目标不能为空")
+	}
+
+	link := &Hyperlink{Ref: cellRef, Display: display, Tooltip: tooltip}
+	if location, ok := hyperlinkLocation(target); ok {
+		link.Location = location
+	} else {
+		link.Target = target
+	}
+	if err := link.check(); err != nil {
+		return err
+	}
+
+	cell := ws.cell(cellRef)
+	if display != "" {
+		cell.setValue(display)
+	} else if cell.Formula == "" && (cell.Value == nil || cell.Value == "") {
+		cell.setValue(strings.TrimPrefix(target, "#"))
+	}
+	cell.StyleID = ws.wb.Styles.withHyperlinkStyle(cell.StyleID)
+
+	for i, existing := range ws.Hyperlinks {
+		if existing.Ref == cellRef {
+			ws.Hyperlinks[i] = link
+			return nil
+		}
+	}
+	ws.Hyperlinks = append(ws.Hyperlinks, link)
+	return nil
+}
+
+// GetHyperlink 返回单元格的超链接，不存在时返回nil
+func (ws *Worksheet) GetHyperlink(cellRef string) *Hyperlink {
+	for _, link := range ws.Hyperlinks {
+		if link.Ref == cellRef {
+			return link
+		}
+	}
+	return nil
+}
+
+// DeleteHyperlink 删除单元格的超链接，单元格的值和样式保持不变
+func (ws *Worksheet) DeleteHyperlink(cellRef string) {
+	for i, link := range ws.Hyperlinks {
+		if link.Ref == cellRef {
+			ws.Hyperlinks = append(ws.Hyperlinks[:i], ws.Hyperlinks[i+1:]...)
+			return
+		}
+	}
+}
+
+// hyperlinkLocation 判断target是否为工作簿内的位置，返回去掉#前缀的位置
+// 以#开头的target总是位置，否则只有单元格或区域引用才是位置
+func hyperlinkLocation(target string) (string, bool) {
+	if strings.HasPrefix(target, "#") {
+		return target[1:], true
+	}
+	if _, ok := parseSheetRef(target); ok {
+		return target, true
+	}
+	return "", false
+}
+
+// check 检查超链接是否有效
+func (h *Hyperlink) check() error {
+	if !isValidCellRef(h.Ref) {
+		return fmt.Errorf("无效的单元格引用: %s", h.Ref)
+	}
+	if (h.Target == "") == (h.Location == "") {
+		return fmt.Errorf("超链接必须且只能设置外部链接和工作簿内的位置之一")
+	}
+	if utf8.RuneCountInString(h.Target) > maxHyperlinkTargetLength {
+		return fmt.Errorf("超链接的长度不能超过%d个字符", maxHyperlinkTargetLength)
+	}
+	if utf8.RuneCountInString(h.Tooltip) > maxHyperlinkTooltipLength {
+		return fmt.Errorf("超链接提示的长度不能超过%d个字符", maxHyperlinkTooltipLength)
+	}
+	return nil
+}
+
+// addHyperlinkRels 为外部链接添加TargetMode为External的工作表关系
+func (ws *Worksheet) addHyperlinkRels(sheetRels *Relationships, state *saveState) {
+	for _, link := range ws.Hyperlinks {
+		if link.Target != "" {
+			state.hyperlinks[link] = sheetRels.AddExternal(opc.RelTypeHyperlink, link.Target).ID
+		}
+	}
+}
+
+// hyperlinksXML 生成hyperlinks元素
+func (ws *Worksheet) hyperlinksXML(state *saveState) string {
+	if len(ws.Hyperlinks) == 0 {
+		return ""
+	}
+	xml := "  <hyperlinks>\n"
+	for _, link := range ws.Hyperlinks {
+		xml += "    <hyperlink ref=\"" + link.Ref + "\""
+		if id := state.hyperlinks[link]; id != "" {
+			xml += " r:id=\"" + id + "\""
+		}
+		if link.Location != "" {
+			xml += " location=\"" + escapeXML(link.Location) + "\""
+		}
+		if link.Display != "" {
+			xml += " display=\"" + escapeXML(link.Display) + "\""
+		}
+		if link.Tooltip != "" {
+			xml += " tooltip=\"" + escapeXML(link.Tooltip) + "\""
+		}
+		xml += " />\n"
+	}
+	xml += "  </hyperlinks>\n"
+	return xml
+}
@@ -0,0 +1,14 @@
+package workbook
+
+import "github.com/landaiqing/go-dockit/opc"
+
+// MediaFile 表示工作簿中的一个媒体文件
+type MediaFile = opc.MediaFile
+
+// MediaStore 管理工作簿中的媒体文件
+type MediaStore = opc.MediaStore
+
+// NewMediaStore 创建一个新的媒体文件集合，dir为相对于xl目录的媒体目录
+func NewMediaStore(dir string) *MediaStore {
+	return opc.NewMediaStore(dir)
+}
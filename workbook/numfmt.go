@@ -0,0 +1,302 @@
+package workbook
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// formatValue 按Excel数字格式将数字格式化为文本，用于TEXT函数
+// 支持常用的数字格式（0、#、千位分隔符、百分比、小数位和文字）和日期时间格式
+func formatValue(f float64, format string) string {
+	sections := splitFormatSections(format)
+	section := sections[0]
+	if f < 0 && len(sections) > 1 {
+		section, f = sections[1], -f
+	} else if f == 0 && len(sections) > 2 {
+		section = sections[2]
+	}
+
+	switch strings.ToLower(section) {
+	case "", "general", "@":
+		return formatNumber(f)
+	}
+	if isDateFormat(section) {
+		return formatDate(f, section)
+	}
+	return formatDecimal(f, section)
+}
+
+// splitFormatSections 按分号拆分格式的正数、负数和零部分，引号中的分号不拆分
+func splitFormatSections(format string) []string {
+	sections := make([]string, 0, 1)
+	start, quoted := 0, false
+	for i := 0; i < len(format); i++ {
+		switch format[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			i++
+		case ';':
+			if !quoted {
+				sections = append(sections, format[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(sections, format[start:])
+}
+
+// isDateFormat 判断格式是否为日期时间格式，引号中的文字和[$-804]这样的区域标记不计
+func isDateFormat(format string) bool {
+	quoted := false
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '\\':
+			i++
+		case c == '[':
+			if end := strings.IndexByte(format[i:], ']'); end > 0 {
+				i += end
+			}
+		case strings.IndexByte("yYdDhHsS", c) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+// formatDate 按日期时间格式格式化序列日期
+func formatDate(serial float64, format string) string {
+	t := serialToTime(serial)
+	hasAMPM := strings.Contains(strings.ToUpper(format), "AM/PM")
+
+	var sb strings.Builder
+	lastWasHour := false
+	for i := 0; i < len(format); {
+		c := format[i]
+		switch {
+		case c == '"':
+			end := strings.IndexByte(format[i+1:], '"')
+			if end < 0 {
+				end = len(format) - i - 1
+			}
+			sb.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		case c == '\\' && i+1 < len(format):
+			sb.WriteByte(format[i+1])
+			i += 2
+			continue
+		case c == '[':
+			if end := strings.IndexByte(format[i:], ']'); end > 0 {
+				i += end + 1
+				continue
+			}
+		case strings.HasPrefix(strings.ToUpper(format[i:]), "AM/PM"):
+			if t.Hour() < 12 {
+				sb.WriteString("AM")
+			} else {
+				sb.WriteString("PM")
+			}
+			i += 5
+			continue
+		}
+
+		lower := c | 0x20
+		if strings.IndexByte("ymdhs", lower) < 0 {
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+
+		n := 1
+		for i+n < len(format) && format[i+n]|0x20 == lower {
+			n++
+		}
+		switch lower {
+		case 'y':
+			if n <= 2 {
+				sb.WriteString(pad(t.Year()%100, 2))
+			} else {
+				sb.WriteString(pad(t.Year(), 4))
+			}
+		case 'm':
+			// h之后或s之前的m表示分钟
+			if lastWasHour || nextDatePart(format[i+n:]) == 's' {
+				sb.WriteString(pad(t.Minute(), min(n, 2)))
+			} else {
+				switch n {
+				case 1, 2:
+					sb.WriteString(pad(int(t.Month()), n))
+				case 3:
+					sb.WriteString(t.Month().String()[:3])
+				default:
+					sb.WriteString(t.Month().String())
+				}
+			}
+		case 'd':
+			switch n {
+			case 1, 2:
+				sb.WriteString(pad(t.Day(), n))
+			case 3:
+				sb.WriteString(t.Weekday().String()[:3])
+			default:
+				sb.WriteString(t.Weekday().String())
+			}
+		case 'h':
+			hour := t.Hour()
+			if hasAMPM {
+				hour = (hour+11)%12 + 1
+			}
+			sb.WriteString(pad(hour, min(n, 2)))
+		case 's':
+			sb.WriteString(pad(t.Second(), min(n, 2)))
+		}
+		lastWasHour = lower == 'h'
+		i += n
+	}
+	return sb.String()
+}
+
+// nextDatePart 返回格式中下一个日期时间占位符的小写字母
+func nextDatePart(format string) byte {
+	for i := 0; i < len(format); i++ {
+		if c := format[i] | 0x20; strings.IndexByte("ymdhs", c) >= 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// pad 将整数格式化为至少width位，不足时补0
+func pad(n, width int) string {
+	s := strconv.Itoa(n)
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
+
+// formatDecimal 按数字格式格式化数字，占位符之前和之后的内容作为文字原样输出
+func formatDecimal(f float64, format string) string {
+	var prefix, pattern, suffix strings.Builder
+	percent := false
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		out := &prefix
+		if pattern.Len() > 0 {
+			out = &suffix
+		}
+		switch {
+		case c == '"':
+			end := strings.IndexByte(format[i+1:], '"')
+			if end < 0 {
+				end = len(format) - i - 1
+			}
+			out.WriteString(format[i+1 : i+1+end])
+			i += end + 1
+		case c == '\\' && i+1 < len(format):
+			out.WriteByte(format[i+1])
+			i++
+		case c == '[':
+			if end := strings.IndexByte(format[i:], ']'); end > 0 {
+				// [$¥-804]这样的货币符号标记只输出符号部分
+				tag := format[i+1 : i+end]
+				if strings.HasPrefix(tag, "$") {
+					symbol := tag[1:]
+					if k := strings.IndexByte(symbol, '-'); k >= 0 {
+						symbol = symbol[:k]
+					}
+					out.WriteString(symbol)
+				}
+				i += end
+			}
+		case c == '_' || c == '*':
+			// 对齐用的占位字符
+			i++
+		case c == '%':
+			percent = true
+			out.WriteByte(c)
+		case strings.IndexByte("0#?,.", c) >= 0 && suffix.Len() == 0:
+			pattern.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	if percent {
+		f *= 100
+	}
+
+	p := pattern.String()
+	intPart, fracPart := p, ""
+	if k := strings.IndexByte(p, '.'); k >= 0 {
+		intPart, fracPart = p[:k], p[k+1:]
+	}
+	thousands := strings.Contains(intPart, ",")
+	// 整数部分末尾的逗号表示除以1000
+	for strings.HasSuffix(intPart, ",") {
+		intPart = intPart[:len(intPart)-1]
+		f /= 1000
+	}
+	decimals := strings.Count(fracPart, "0") + strings.Count(fracPart, "#") + strings.Count(fracPart, "?")
+	minInt := strings.Count(intPart, "0")
+
+	negative := f < 0
+	scale := math.Pow(10, float64(decimals))
+	f = math.Round(math.Abs(f)*scale*1e9) / 1e9
+	f = math.Round(f) / scale
+	s := strconv.FormatFloat(f, 'f', decimals, 64)
+
+	digits, frac := s, ""
+	if k := strings.IndexByte(s, '.'); k >= 0 {
+		digits, frac = s[:k], s[k+1:]
+	}
+	// #表示的可选小数位去掉末尾的0
+	optional := decimals - strings.Count(fracPart, "0")
+	for optional > 0 && strings.HasSuffix(frac, "0") {
+		frac = frac[:len(frac)-1]
+		optional--
+	}
+
+	digits = strings.TrimLeft(digits, "0")
+	for len(digits) < minInt {
+		digits = "0" + digits
+	}
+	if thousands {
+		digits = groupThousands(digits)
+	}
+
+	result := digits
+	if fracPart != "" && (frac != "" || strings.Contains(fracPart, "0")) {
+		result += "." + frac
+	}
+	sign := ""
+	if negative && strings.Trim(result, "0.,") != "" {
+		sign = "-"
+	}
+	return sign + prefix.String() + result + suffix.String()
+}
+
+// groupThousands 为整数部分添加千位分隔符
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var sb strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		sb.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(digits[i : i+3])
+	}
+	return sb.String()
+}
@@ -0,0 +1,414 @@
+package workbook
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// 纸张大小
+const (
+	PaperLetter = 1  // Letter 8.5 x 11 英寸
+	PaperLegal  = 5  // Legal 8.5 x 14 英寸
+	PaperA3     = 8  // A3 297 x 420 毫米
+	PaperA4     = 9  // A4 210 x 297 毫米
+	PaperA5     = 11 // A5 148 x 210 毫米
+	PaperB4     = 12 // B4 (JIS) 257 x 364 毫米
+	PaperB5     = 13 // B5 (JIS) 182 x 257 毫米
+)
+
+// 页面方向
+const (
+	OrientationPortrait  = "portrait"  // 纵向
+	OrientationLandscape = "landscape" // 横向
+)
+
+// 页眉页脚中可以使用的代码，文本中的&需要写为&&
+const (
+	HeaderFooterPageNumber = "&P" // 页码
+	HeaderFooterPageCount  = "&N" // 总页数
+	HeaderFooterDate       = "&D" // 打印日期
+	HeaderFooterTime       = "&T" // 打印时间
+	HeaderFooterSheetName  = "&A" // 工作表名称
+	HeaderFooterFileName   = "&F" // 文件名
+)
+
+// maxHeaderFooterLength 页眉或页脚的最大长度
+const maxHeaderFooterLength = 255
+
+// PageSetup 表示工作表的页面设置和打印选项
+type PageSetup struct {
+	PaperSize          int    // 纸张大小，0表示使用打印机的默认纸张
+	Orientation        string // 页面方向，为空表示纵向
+	Scale              int    // 缩放比例，10~400，0表示100
+	FitToPage          bool   // 按页数缩放，设置后忽略Scale
+	FitToWidth         int    // 按页数缩放时调整为几页宽，0表示不限制
+	FitToHeight        int    // 按页数缩放时调整为几页高，0表示不限制
+	FirstPageNumber    int    // 起始页码，0表示自动
+	Margins            *PageMargins
+	HorizontalCentered bool // 水平居中
+	VerticalCentered   bool // 垂直居中
+	PrintGridLines     bool // 打印网格线
+	PrintHeadings      bool // 打印行号和列标
+	HeaderFooter       *HeaderFooter
+	RowBreaks          []int // 手动分页符，为新页第一行的索引，从0开始
+	ColBreaks          []int // 手动分页符，为新页第一列的索引，从0开始
+}
+
+// PageMargins 表示页边距，单位为英寸
+type PageMargins struct {
+	Left   float64
+	Right  float64
+	Top    float64
+	Bottom float64
+	Header float64 // 页眉到页面顶端的距离
+	Footer float64 // 页脚到页面底端的距离
+}
+
+// HeaderFooter 表示页眉和页脚，内容可以用HeaderFooterSections生成
+type HeaderFooter struct {
+	OddHeader        string // 页眉，设置了DifferentOddEven时只用于奇数页
+	OddFooter        string // 页脚，设置了DifferentOddEven时只用于奇数页
+	EvenHeader       string
+	EvenFooter       string
+	FirstHeader      string
+	FirstFooter      string
+	DifferentOddEven bool // 偶数页使用EvenHeader和EvenFooter
+	DifferentFirst   bool // 首页使用FirstHeader和FirstFooter
+}
+
+// NewPageSetup 创建默认的页面设置，使用Excel的默认页边距
+func NewPageSetup() *PageSetup {
+	return &PageSetup{Margins: defaultPageMargins()}
+}
+
+// defaultPageMargins 返回Excel的默认页边距
+func defaultPageMargins() *PageMargins {
+	return &PageMargins{Left: 0.7, Right: 0.7, Top: 0.75, Bottom: 0.75, Header: 0.3, Footer: 0.3}
+}
+
+// HeaderFooterSections 将左、中、右三部分组合为页眉或页脚，空的部分省略
+// 例如: HeaderFooterSections("", "第 &P 页，共 &N 页", "&D")
+func HeaderFooterSections(left, center, right string) string {
+	text := ""
+	if left != "" {
+		text += "&L" + left
+	}
+	if center != "" {
+		text += "&C" + center
+	}
+	if right != "" {
+		text += "&R" + right
+	}
+	return text
+}
+
+// SetPaperSize 设置纸张大小，例如 PaperA4
+func (ps *PageSetup) SetPaperSize(size int) *PageSetup {
+	ps.PaperSize = size
+	return ps
+}
+
+// SetOrientation 设置页面方向
+func (ps *PageSetup) SetOrientation(orientation string) *PageSetup {
+	ps.Orientation = orientation
+	return ps
+}
+
+// SetScale 设置缩放比例，并取消按页数缩放
+func (ps *PageSetup) SetScale(percent int) *PageSetup {
+	ps.Scale = percent
+	ps.FitToPage = false
+	return ps
+}
+
+// SetFitToPage 将打印内容缩放为width页宽、height页高，0表示该方向不限制
+// 例如 SetFitToPage(1, 0) 将所有列缩放到一页宽
+func (ps *PageSetup) SetFitToPage(width, height int) *PageSetup {
+	ps.FitToPage = true
+	ps.FitToWidth = width
+	ps.FitToHeight = height
+	return ps
+}
+
+// SetFirstPageNumber 设置起始页码
+func (ps *PageSetup) SetFirstPageNumber(number int) *PageSetup {
+	ps.FirstPageNumber = number
+	return ps
+}
+
+// SetMargins 设置上下左右的页边距，单位为英寸
+func (ps *PageSetup) SetMargins(left, right, top, bottom float64) *PageSetup {
+	margins := ps.margins()
+	margins.Left, margins.Right, margins.Top, margins.Bottom = left, right, top, bottom
+	return ps
+}
+
+// SetHeaderFooterMargins 设置页眉和页脚到页面边缘的距离，单位为英寸
+func (ps *PageSetup) SetHeaderFooterMargins(header, footer float64) *PageSetup {
+	margins := ps.margins()
+	margins.Header, margins.Footer = header, footer
+	return ps
+}
+
+// SetCentered 设置打印内容在页面上水平和垂直居中
+func (ps *PageSetup) SetCentered(horizontal, vertical bool) *PageSetup {
+	ps.HorizontalCentered = horizontal
+	ps.VerticalCentered = vertical
+	return ps
+}
+
+// SetPrintGridLines 设置是否打印网格线
+func (ps *PageSetup) SetPrintGridLines(show bool) *PageSetup {
+	ps.PrintGridLines = show
+	return ps
+}
+
+// SetPrintHeadings 设置是否打印行号和列标
+func (ps *PageSetup) SetPrintHeadings(show bool) *PageSetup {
+	ps.PrintHeadings = show
+	return ps
+}
+
+// SetHeaderFooter 设置所有页的页眉和页脚，设置了不同的奇偶页时只用于奇数页
+func (ps *PageSetup) SetHeaderFooter(header, footer string) *PageSetup {
+	hf := ps.headerFooter()
+	hf.OddHeader, hf.OddFooter = header, footer
+	return ps
+}
+
+// SetEvenHeaderFooter 设置偶数页的页眉和页脚，奇数页使用SetHeaderFooter设置的内容
+func (ps *PageSetup) SetEvenHeaderFooter(header, footer string) *PageSetup {
+	hf := ps.headerFooter()
+	hf.EvenHeader, hf.EvenFooter = header, footer
+	hf.DifferentOddEven = true
+	return ps
+}
+
+// SetFirstHeaderFooter 设置首页的页眉和页脚，两者都为空时首页不显示页眉页脚
+func (ps *PageSetup) SetFirstHeaderFooter(header, footer string) *PageSetup {
+	hf := ps.headerFooter()
+	hf.FirstHeader, hf.FirstFooter = header, footer
+	hf.DifferentFirst = true
+	return ps
+}
+
+// margins 返回页边距，不存在时创建默认页边距
+func (ps *PageSetup) margins() *PageMargins {
+	if ps.Margins == nil {
+		ps.Margins = defaultPageMargins()
+	}
+	return ps.Margins
+}
+
+// headerFooter 返回页眉页脚，不存在时创建
+func (ps *PageSetup) headerFooter() *HeaderFooter {
+	if ps.HeaderFooter == nil {
+		ps.HeaderFooter = &HeaderFooter{}
+	}
+	return ps.HeaderFooter
+}
+
+// check 检查页面设置是否有效
+func (ps *PageSetup) check() error {
+	if ps.PaperSize < 0 {
+		return fmt.Errorf("无效的纸张大小: %d", ps.PaperSize)
+	}
+	if ps.Orientation != "" && ps.Orientation != OrientationPortrait && ps.Orientation != OrientationLandscape {
+		return fmt.Errorf("无效的页面方向: %s", ps.Orientation)
+	}
+	if ps.Scale != 0 && (ps.Scale < 10 || ps.Scale > 400) {
+		return fmt.Errorf("缩放比例必须在10到400之间: %d", ps.Scale)
+	}
+	if ps.FitToWidth < 0 || ps.FitToWidth > 32767 || ps.FitToHeight < 0 || ps.FitToHeight > 32767 {
+		return fmt.Errorf("无效的缩放页数: %d x %d", ps.FitToWidth, ps.FitToHeight)
+	}
+	if m := ps.Margins; m != nil {
+		if m.Left < 0 || m.Right < 0 || m.Top < 0 || m.Bottom < 0 || m.Header < 0 || m.Footer < 0 {
+			return fmt.Errorf("页边距不能为负数")
+		}
+	}
+	if hf := ps.HeaderFooter; hf != nil {
+		for _, text := range []string{hf.OddHeader, hf.OddFooter, hf.EvenHeader, hf.EvenFooter, hf.FirstHeader, hf.FirstFooter} {
+			if utf8.RuneCountInString(text) > maxHeaderFooterLength {
+				return fmt.Errorf("页眉或页脚超过%d个字符", maxHeaderFooterLength)
+			}
+		}
+	}
+	for _, row := range ps.RowBreaks {
+		if row < 1 || row >= MaxRows {
+			return fmt.Errorf("无效的分页行: %d", row)
+		}
+	}
+	for _, col := range ps.ColBreaks {
+		if col < 1 || col >= MaxColumns {
+			return fmt.Errorf("无效的分页列: %d", col)
+		}
+	}
+	return nil
+}
+
+// SetPageSetup 设置工作表的页面设置，替换之前的设置和分页符，ps为nil时恢复默认设置
+// 例如: ws.SetPageSetup(NewPageSetup().SetPaperSize(PaperA4).SetOrientation(OrientationLandscape).SetFitToPage(1, 0))
+func (ws *Worksheet) SetPageSetup(ps *PageSetup) error {
+	if err := ws.checkWritable(); err != nil {
+		return err
+	}
+	if ps != nil {
+		if err := ps.check(); err != nil {
+			return err
+		}
+	}
+	ws.PageSetup = ps
+	return nil
+}
+
+// pageSetup 返回工作表的页面设置，不存在时创建默认设置
+func (ws *Worksheet) pageSetup() *PageSetup {
+	if ws.PageSetup == nil {
+		ws.PageSetup = NewPageSetup()
+	}
+	return ws.PageSetup
+}
+
+// AddRowBreak 在第row行（从1开始）之前插入手动分页符
+func (ws *Worksheet) AddRowBreak(row int) error {
+	if err := ws.checkWritable(); err != nil {
+		return err
+	}
+	if row < 2 || row > MaxRows {
+		return fmt.Errorf("无效的分页行: %d", row)
+	}
+	ps := ws.pageSetup()
+	ps.RowBreaks = addBreak(ps.RowBreaks, row-1)
+	return nil
+}
+
+// AddColBreak 在第col列（从1开始）之前插入手动分页符
+func (ws *Worksheet) AddColBreak(col int) error {
+	if err := ws.checkWritable(); err != nil {
+		return err
+	}
+	if col < 2 || col > MaxColumns {
+		return fmt.Errorf("无效的分页列: %d", col)
+	}
+	ps := ws.pageSetup()
+	ps.ColBreaks = addBreak(ps.ColBreaks, col-1)
+	return nil
+}
+
+// addBreak 将分页符插入有序列表，已存在时不重复添加
+func addBreak(breaks []int, index int) []int {
+	i := sort.SearchInts(breaks, index)
+	if i < len(breaks) && breaks[i] == index {
+		return breaks
+	}
+	breaks = append(breaks, 0)
+	copy(breaks[i+1:], breaks[i:])
+	breaks[i] = index
+	return breaks
+}
+
+// pageSetupXML 生成printOptions、pageMargins、pageSetup、headerFooter和分页符元素
+func (ws *Worksheet) pageSetupXML() string {
+	ps := ws.PageSetup
+	if ps == nil {
+		return ""
+	}
+
+	xml := ""
+	if ps.HorizontalCentered || ps.VerticalCentered || ps.PrintHeadings || ps.PrintGridLines {
+		xml += "  <printOptions"
+		if ps.HorizontalCentered {
+			xml += " horizontalCentered=\"1\""
+		}
+		if ps.VerticalCentered {
+			xml += " verticalCentered=\"1\""
+		}
+		if ps.PrintHeadings {
+			xml += " headings=\"1\""
+		}
+		if ps.PrintGridLines {
+			xml += " gridLines=\"1\""
+		}
+		xml += " />\n"
+	}
+
+	m := ps.Margins
+	if m == nil {
+		m = defaultPageMargins()
+	}
+	xml += "  <pageMargins left=\"" + formatNumber(m.Left) + "\" right=\"" + formatNumber(m.Right) +
+		"\" top=\"" + formatNumber(m.Top) + "\" bottom=\"" + formatNumber(m.Bottom) +
+		"\" header=\"" + formatNumber(m.Header) + "\" footer=\"" + formatNumber(m.Footer) + "\" />\n"
+
+	attrs := ""
+	if ps.PaperSize > 0 {
+		attrs += fmt.Sprintf(" paperSize=\"%d\"", ps.PaperSize)
+	}
+	if ps.Scale != 0 && !ps.FitToPage {
+		attrs += fmt.Sprintf(" scale=\"%d\"", ps.Scale)
+	}
+	if ps.FirstPageNumber != 0 {
+		attrs += fmt.Sprintf(" firstPageNumber=\"%d\" useFirstPageNumber=\"1\"", ps.FirstPageNumber)
+	}
+	if ps.FitToPage {
+		attrs += fmt.Sprintf(" fitToWidth=\"%d\" fitToHeight=\"%d\"", ps.FitToWidth, ps.FitToHeight)
+	}
+	if ps.Orientation != "" {
+		attrs += " orientation=\"" + ps.Orientation + "\""
+	}
+	if attrs != "" {
+		xml += "  <pageSetup" + attrs + " />\n"
+	}
+
+	if hf := ps.HeaderFooter; hf != nil {
+		xml += hf.toXML()
+	}
+	xml += breaksXML("rowBreaks", ps.RowBreaks, MaxColumns-1)
+	xml += breaksXML("colBreaks", ps.ColBreaks, MaxRows-1)
+	return xml
+}
+
+// toXML 生成headerFooter元素
+func (hf *HeaderFooter) toXML() string {
+	xml := "  <headerFooter"
+	if hf.DifferentOddEven {
+		xml += " differentOddEven=\"1\""
+	}
+	if hf.DifferentFirst {
+		xml += " differentFirst=\"1\""
+	}
+	xml += ">"
+	parts := []struct {
+		name, text string
+		used       bool
+	}{
+		{"oddHeader", hf.OddHeader, true},
+		{"oddFooter", hf.OddFooter, true},
+		{"evenHeader", hf.EvenHeader, hf.DifferentOddEven},
+		{"evenFooter", hf.EvenFooter, hf.DifferentOddEven},
+		{"firstHeader", hf.FirstHeader, hf.DifferentFirst},
+		{"firstFooter", hf.FirstFooter, hf.DifferentFirst},
+	}
+	for _, part := range parts {
+		if part.used && part.text != "" {
+			xml += "<" + part.name + ">" + escapeXML(part.text) + "</" + part.name + ">"
+		}
+	}
+	xml += "</headerFooter>\n"
+	return xml
+}
+
+// breaksXML 生成rowBreaks或colBreaks元素，last为分页符延伸到的最后一列或一行的索引
+func breaksXML(name string, breaks []int, last int) string {
+	if len(breaks) == 0 {
+		return ""
+	}
+	xml := fmt.Sprintf("  <%s count=\"%d\" manualBreakCount=\"%d\">", name, len(breaks), len(breaks))
+	for _, id := range breaks {
+		xml += fmt.Sprintf("<brk id=\"%d\" max=\"%d\" man=\"1\" />", id, last)
+	}
+	xml += "</" + name + ">\n"
+	return xml
+}
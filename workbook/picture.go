@@ -0,0 +1,245 @@
+package workbook
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"  // 注册GIF解码器，用于读取图片尺寸
+	_ "image/jpeg" // 注册JPEG解码器，用于读取图片尺寸
+	_ "image/png"  // 注册PNG解码器，用于读取图片尺寸
+	"strings"
+)
+
+// 图片的定位方式
+const (
+	PositioningOneCell = "oneCell" // 随单元格移动，大小不变
+	PositioningTwoCell = "twoCell" // 随单元格移动并调整大小
+)
+
+// 尺寸换算
+const (
+	emuPerPixel        = 9525
+	defaultColWidthPx  = 64 // 默认列宽8.43个字符对应的像素数
+	defaultRowHeightPt = 15
+	pixelsPerPoint     = 96.0 / 72.0
+	maxDigitWidthPx    = 7 // 默认字体Calibri 11中数字的宽度
+	columnPaddingPx    = 5
+)
+
+// ImageOptions 表示插入图片的选项，零值字段使用默认值
+type ImageOptions struct {
+	Format      string  // 图片格式：png, jpeg, gif等，为空时根据图片内容识别
+	Name        string  // 绘图对象的名称，为空时自动生成
+	AltText     string  // 替代文字
+	Hyperlink   string  // 点击图片打开的链接，以#开头表示工作簿内的位置，例如 "#Sheet2!A1"
+	OffsetX     int     // 相对于锚定单元格左上角的水平偏移，单位为像素
+	OffsetY     int     // 相对于锚定单元格左上角的垂直偏移，单位为像素
+	Width       int     // 宽度，单位为像素，为0时使用图片的原始宽度
+	Height      int     // 高度，单位为像素，为0时使用图片的原始高度
+	ScaleX      float64 // 水平缩放比例，为0时为1
+	ScaleY      float64 // 垂直缩放比例，为0时为1
+	Positioning string  // 定位方式：oneCell(默认), twoCell
+	ToCell      string  // 右下角所在的单元格，设置时图片拉伸填满从锚定单元格到该单元格左上角的区域
+}
+
+// Picture 表示工作表中的一张图片
+type Picture struct {
+	From      anchorPoint
+	To        anchorPoint // 双单元格锚定时右下角的位置
+	Width     int64       // 单单元格锚定时的宽度，单位为EMU
+	Height    int64       // 单单元格锚定时的高度，单位为EMU
+	TwoCell   bool        // 使用双单元格锚定
+	Name      string
+	AltText   string
+	Hyperlink string
+	media     *MediaFile
+}
+
+// AddImage 在cellRef处插入一张图片，图片文件保存到xl/media目录，相同的图片只保存一次
+// 例如: ws.AddImage("A2", data, &ImageOptions{Width: 48, Height: 48, AltText: "商品图片"})
+func (ws *Worksheet) AddImage(cellRef string, data []byte, opts *ImageOptions) (*Picture, error) {
+	if ws.stream != nil {
+		return nil, fmt.Errorf("流式写入的工作表 %s 不支持插入图片", ws.Name)
+	}
+	if ws.wb == nil {
+		return nil, fmt.Errorf("工作表 %s 不属于任何工作簿，无法保存图片", ws.Name)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("图片数据不能为空")
+	}
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	if opts.OffsetX < 0 || opts.OffsetY < 0 || opts.Width < 0 || opts.Height < 0 || opts.ScaleX < 0 || opts.ScaleY < 0 {
+		return nil, fmt.Errorf("图片的偏移、尺寸和缩放比例不能为负数")
+	}
+	if opts.Positioning != "" && opts.Positioning != PositioningOneCell && opts.Positioning != PositioningTwoCell {
+		return nil, fmt.Errorf("无效的图片定位方式: %s", opts.Positioning)
+	}
+
+	from, err := parseAnchor(cellRef)
+	if err != nil {
+		return nil, err
+	}
+	from.ColOff = int64(opts.OffsetX) * emuPerPixel
+	from.RowOff = int64(opts.OffsetY) * emuPerPixel
+
+	// 识别图片格式和原始尺寸
+	format := strings.ToLower(strings.TrimPrefix(opts.Format, "."))
+	width, height := opts.Width, opts.Height
+	if cfg, decoded, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		if format == "" {
+			format = decoded
+		}
+		if width == 0 {
+			width = cfg.Width
+		}
+		if height == 0 {
+			height = cfg.Height
+		}
+	}
+	if format == "jpg" {
+		format = "jpeg"
+	}
+	if format == "" {
+		return nil, fmt.Errorf("无法识别图片格式，请在ImageOptions.Format中指定")
+	}
+	if ws.wb.ContentTypes.GetDefault(format) == nil {
+		return nil, fmt.Errorf("不支持的图片格式: %s", format)
+	}
+	if opts.ToCell == "" && (width == 0 || height == 0) {
+		return nil, fmt.Errorf("无法读取图片尺寸，请在ImageOptions中指定Width和Height")
+	}
+
+	pic := &Picture{
+		From:      from,
+		Name:      opts.Name,
+		AltText:   opts.AltText,
+		Hyperlink: opts.Hyperlink,
+	}
+
+	scaleX, scaleY := opts.ScaleX, opts.ScaleY
+	if scaleX == 0 {
+		scaleX = 1
+	}
+	if scaleY == 0 {
+		scaleY = 1
+	}
+	pic.Width = int64(float64(width) * scaleX * emuPerPixel)
+	pic.Height = int64(float64(height) * scaleY * emuPerPixel)
+
+	switch {
+	case opts.ToCell != "":
+		to, err := parseAnchor(opts.ToCell)
+		if err != nil {
+			return nil, err
+		}
+		if to.Col < from.Col || to.Row < from.Row || to.Col == from.Col && to.Row == from.Row {
+			return nil, fmt.Errorf("图片的右下角 %s 必须在锚定单元格 %s 的右下方", opts.ToCell, cellRef)
+		}
+		pic.TwoCell, pic.To = true, to
+	case opts.Positioning == PositioningTwoCell:
+		pic.TwoCell = true
+		pic.To = ws.anchorEnd(from, pic.Width, pic.Height)
+	}
+
+	name := fmt.Sprintf("image%d.%s", len(ws.wb.Media.Files)+1, format)
+	pic.media = ws.wb.Media.Add(name, data)
+
+	ws.Pictures = append(ws.Pictures, pic)
+	return pic, nil
+}
+
+// anchorEnd 根据列宽和行高计算从from开始、宽高为width和height（EMU）的区域右下角的位置
+func (ws *Worksheet) anchorEnd(from anchorPoint, width, height int64) anchorPoint {
+	to := from
+	to.ColOff += width
+	for to.Col < MaxColumns-1 {
+		size := int64(ws.colWidthPx(to.Col)) * emuPerPixel
+		if to.ColOff < size {
+			break
+		}
+		to.ColOff -= size
+		to.Col++
+	}
+	to.RowOff += height
+	for to.Row < MaxRows-1 {
+		size := int64(ws.rowHeightPx(to.Row)) * emuPerPixel
+		if to.RowOff < size {
+			break
+		}
+		to.RowOff -= size
+		to.Row++
+	}
+	return to
+}
+
+// colWidthPx 返回第col列（从0开始）的宽度，单位为像素
+func (ws *Worksheet) colWidthPx(col int) int {
+	for _, c := range ws.Columns {
+		if col+1 >= c.Min && col+1 <= c.Max {
+			if c.Hidden {
+				return 0
+			}
+			// 按Excel的换算方式将字符数转换为像素
+			return int(c.Width*maxDigitWidthPx + columnPaddingPx)
+		}
+	}
+	return defaultColWidthPx
+}
+
+// rowHeightPx 返回第row行（从0开始）的高度，单位为像素
+func (ws *Worksheet) rowHeightPx(row int) int {
+	height := float64(defaultRowHeightPt)
+	if r := ws.row(row+1, false); r != nil {
+		if r.Hidden {
+			return 0
+		}
+		if r.Height > 0 {
+			height = r.Height
+		}
+	}
+	return int(height * pixelsPerPoint)
+}
+
+// toXML 生成图片的锚定元素，图片和超链接的关系ID从state中查找
+func (pic *Picture) toXML(id int, state *saveState) string {
+	name := pic.Name
+	if name == "" {
+		name = fmt.Sprintf("Picture %d", id-1)
+	}
+
+	xml := ""
+	if pic.TwoCell {
+		xml += "  <xdr:twoCellAnchor editAs=\"twoCell\">\n"
+		xml += "    " + pic.From.toXML("from") + "\n"
+		xml += "    " + pic.To.toXML("to") + "\n"
+	} else {
+		xml += "  <xdr:oneCellAnchor>\n"
+		xml += "    " + pic.From.toXML("from") + "\n"
+		xml += fmt.Sprintf("    <xdr:ext cx=\"%d\" cy=\"%d\" />\n", pic.Width, pic.Height)
+	}
+
+	xml += "    <xdr:pic>\n"
+	xml += fmt.Sprintf("      <xdr:nvPicPr><xdr:cNvPr id=\"%d\" name=\"%s\"", id, escapeXML(name))
+	if pic.AltText != "" {
+		xml += " descr=\"" + escapeXML(pic.AltText) + "\""
+	}
+	if linkID := state.pictureLinks[pic]; linkID != "" {
+		xml += "><a:hlinkClick r:id=\"" + linkID + "\" /></xdr:cNvPr>"
+	} else {
+		xml += " />"
+	}
+	xml += "<xdr:cNvPicPr><a:picLocks noChangeAspect=\"1\" /></xdr:cNvPicPr></xdr:nvPicPr>\n"
+	xml += "      <xdr:blipFill><a:blip r:embed=\"" + state.pictures[pic] + "\" /><a:stretch><a:fillRect /></a:stretch></xdr:blipFill>\n"
+	xml += fmt.Sprintf("      <xdr:spPr><a:xfrm><a:off x=\"0\" y=\"0\" /><a:ext cx=\"%d\" cy=\"%d\" /></a:xfrm><a:prstGeom prst=\"rect\"><a:avLst /></a:prstGeom></xdr:spPr>\n", pic.Width, pic.Height)
+	xml += "    </xdr:pic>\n"
+	xml += "    <xdr:clientData />\n"
+
+	if pic.TwoCell {
+		xml += "  </xdr:twoCellAnchor>\n"
+	} else {
+		xml += "  </xdr:oneCellAnchor>\n"
+	}
+	return xml
+}
@@ -0,0 +1,215 @@
+package workbook
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/landaiqing/go-dockit/opc"
+)
+
+// PasswordHash 表示保护设置中加盐迭代的SHA-512密码哈希
+type PasswordHash = opc.PasswordHash
+
+// SheetProtection 表示工作表保护
+type SheetProtection struct {
+	Password *PasswordHash // 为nil表示不设置密码
+	Options  SheetProtectionOptions
+	password string // Protect设置的密码，确定性输出时用于在保存时生成盐值
+}
+
+// SheetProtectionOptions 表示工作表受保护时仍然允许的操作
+// 只有锁定的单元格受保护，单元格默认锁定，可以通过Style.Protection或SetCellProtection解除锁定
+type SheetProtectionOptions struct {
+	SelectLockedCells   bool // 选定锁定的单元格
+	SelectUnlockedCells bool // 选定解除锁定的单元格
+	FormatCells         bool // 设置单元格格式
+	FormatColumns       bool // 设置列格式
+	FormatRows          bool // 设置行格式
+	InsertColumns       bool // 插入列
+	InsertRows          bool // 插入行
+	InsertHyperlinks    bool // 插入超链接
+	DeleteColumns       bool // 删除列
+	DeleteRows          bool // 删除行
+	Sort                bool // 排序
+	AutoFilter          bool // 使用自动筛选
+	PivotTables         bool // 使用数据透视表
+	EditObjects         bool // 编辑对象，如图表和图片
+	EditScenarios       bool // 编辑方案
+}
+
+// WorkbookProtection 表示工作簿保护
+type WorkbookProtection struct {
+	LockStructure bool          // 禁止添加、删除、移动、隐藏和重命名工作表
+	LockWindows   bool          // 禁止移动和调整工作簿窗口
+	Password      *PasswordHash // 为nil表示不设置密码
+	password      string        // Protect设置的密码，确定性输出时用于在保存时生成盐值
+}
+
+// NewSheetProtectionOptions 创建与Excel默认设置相同的选项，只允许选定单元格
+func NewSheetProtectionOptions() *SheetProtectionOptions {
+	return &SheetProtectionOptions{SelectLockedCells: true, SelectUnlockedCells: true}
+}
+
+// newPasswordHash 使用随机盐值计算密码的哈希，password为空时返回nil
+func newPasswordHash(password string) (*PasswordHash, error) {
+	if password == "" {
+		return nil, nil
+	}
+	return opc.NewPasswordHash(password)
+}
+
+// savedPasswordHash 返回保存时写入的密码哈希
+// 开启确定性输出时盐值由密码和受保护的内容content生成，与Protect和SetDeterministic的调用顺序无关
+func (wb *Workbook) savedPasswordHash(hash *PasswordHash, password string, content []byte) *PasswordHash {
+	if password == "" || wb == nil || !wb.SaveOptions.Deterministic {
+		return hash
+	}
+	return opc.NewPasswordHashWithSalt(password, opc.DeterministicSalt(password, content))
+}
+
+// Protect 保护工作表，锁定的单元格不能编辑，options为nil时使用NewSheetProtectionOptions的默认设置
+// password为空表示不设置密码；开启确定性输出时盐值在保存时由单元格数据和密码生成
+// 例如模板中公式单元格保持默认的锁定状态，输入单元格用SetCellProtection解除锁定
+func (ws *Worksheet) Protect(password string, options *SheetProtectionOptions) error {
+	if err := ws.checkWritable(); err != nil {
+		return err
+	}
+	if options == nil {
+		options = NewSheetProtectionOptions()
+	}
+	hash, err := newPasswordHash(password)
+	if err != nil {
+		return err
+	}
+	ws.Protection = &SheetProtection{Password: hash, Options: *options, password: password}
+	return nil
+}
+
+// Unprotect 取消工作表保护
+func (ws *Worksheet) Unprotect() *Worksheet {
+	ws.Protection = nil
+	return ws
+}
+
+// SetCellProtection 设置区域中单元格的锁定和隐藏公式属性，单元格的其他格式保持不变
+// 不存在的单元格会被创建，例如: ws.SetCellProtection("B2:B10", false, false) 允许在保护后编辑B2:B10
+func (ws *Worksheet) SetCellProtection(ref string, locked, hidden bool) error {
+	if ws.wb == nil {
+		return fmt.Errorf("工作表 %s 不属于任何工作簿，无法设置样式", ws.Name)
+	}
+	area, ok := parseRangeRef(ref)
+	if !ok || area.From.Row < 0 || area.From.Col < 0 {
+		return fmt.Errorf("无效的区域: %s", ref)
+	}
+	row1, col1, row2, col2 := area.bounds()
+	protection := &Protection{Locked: locked, Hidden: hidden}
+	for row := row1; row <= row2; row++ {
+		for col := col1; col <= col2; col++ {
+			cell := ws.cell(CellRef(row, col))
+			cell.StyleID = ws.wb.Styles.withProtection(cell.StyleID, protection)
+		}
+	}
+	return nil
+}
+
+// sheetProtectionXML 生成sheetProtection元素，属性为1表示禁止该操作，hash为写入的密码哈希
+func (ws *Worksheet) sheetProtectionXML(hash *PasswordHash) string {
+	p := ws.Protection
+	if p == nil {
+		return ""
+	}
+
+	xml := "  <sheetProtection"
+	if hash != nil {
+		xml += passwordXML("", hash)
+	}
+	xml += " sheet=\"1\""
+	if !p.Options.EditObjects {
+		xml += " objects=\"1\""
+	}
+	if !p.Options.EditScenarios {
+		xml += " scenarios=\"1\""
+	}
+	if !p.Options.SelectLockedCells {
+		xml += " selectLockedCells=\"1\""
+	}
+	if !p.Options.SelectUnlockedCells {
+		xml += " selectUnlockedCells=\"1\""
+	}
+	// 以下操作默认禁止，允许时写为0
+	allowed := []struct {
+		name  string
+		allow bool
+	}{
+		{"formatCells", p.Options.FormatCells},
+		{"formatColumns", p.Options.FormatColumns},
+		{"formatRows", p.Options.FormatRows},
+		{"insertColumns", p.Options.InsertColumns},
+		{"insertRows", p.Options.InsertRows},
+		{"insertHyperlinks", p.Options.InsertHyperlinks},
+		{"deleteColumns", p.Options.DeleteColumns},
+		{"deleteRows", p.Options.DeleteRows},
+		{"sort", p.Options.Sort},
+		{"autoFilter", p.Options.AutoFilter},
+		{"pivotTables", p.Options.PivotTables},
+	}
+	for _, a := range allowed {
+		if a.allow {
+			xml += " " + a.name + "=\"0\""
+		}
+	}
+	xml += " />\n"
+	return xml
+}
+
+// Protect 保护工作簿的结构和窗口，password为空表示不设置密码
+// 开启确定性输出时盐值在保存时由工作簿结构和密码生成
+func (wb *Workbook) Protect(password string, lockStructure, lockWindows bool) error {
+	hash, err := newPasswordHash(password)
+	if err != nil {
+		return err
+	}
+	wb.Protection = &WorkbookProtection{LockStructure: lockStructure, LockWindows: lockWindows, Password: hash, password: password}
+	return nil
+}
+
+// Unprotect 取消工作簿保护
+func (wb *Workbook) Unprotect() *Workbook {
+	wb.Protection = nil
+	return wb
+}
+
+// workbookProtectionXML 生成workbookProtection元素，content为工作簿中其他元素的XML，用于确定性输出的盐值
+func (wb *Workbook) workbookProtectionXML(content string) string {
+	p := wb.Protection
+	if p == nil {
+		return ""
+	}
+	xml := "  <workbookProtection"
+	if hash := wb.savedPasswordHash(p.Password, p.password, []byte(content)); hash != nil {
+		xml += passwordXML("workbook", hash)
+	}
+	if p.LockStructure {
+		xml += " lockStructure=\"1\""
+	}
+	if p.LockWindows {
+		xml += " lockWindows=\"1\""
+	}
+	xml += " />\n"
+	return xml
+}
+
+// passwordXML 生成密码哈希的属性，prefix为属性名前缀，工作簿保护为workbook
+func passwordXML(prefix string, hash *PasswordHash) string {
+	name := func(attr string) string {
+		if prefix == "" {
+			return attr
+		}
+		return prefix + strings.ToUpper(attr[:1]) + attr[1:]
+	}
+	return fmt.Sprintf(" %s=\"%s\" %s=\"%s\" %s=\"%s\" %s=\"%d\"",
+		name("algorithmName"), escapeXML(hash.AlgorithmName),
+		name("hashValue"), hash.HashValue,
+		name("saltValue"), hash.SaltValue,
+		name("spinCount"), hash.SpinCount)
+}
@@ -1,71 +1,40 @@
 package workbook
 
+import "github.com/landaiqing/go-dockit/opc"
+
 // Relationships 表示Excel文档中的关系集合
-type Relationships struct {
-	Relationships []*Relationship
-}
+type Relationships = opc.Relationships
 
 // Relationship 表示Excel文档中的关系
-type Relationship struct {
-	ID         string
-	Type       string
-	Target     string
-	TargetMode string // 目标模式：Internal, External
-}
+type Relationship = opc.Relationship
 
 // NewRelationships 创建一个新的关系集合
 func NewRelationships() *Relationships {
-	return &Relationships{
-		Relationships: make([]*Relationship, 0),
-	}
+	return opc.NewRelationships()
 }
 
-// AddRelationship 添加一个关系
-func (r *Relationships) AddRelationship(id, relType, target string) *Relationship {
-	rel := &Relationship{
-		ID:     id,
-		Type:   relType,
-		Target: target,
-	}
-	r.Relationships = append(r.Relationships, rel)
-	return rel
+// saveState 保存一次打包中分配的关系ID，打包不修改工作簿，生成XML时从这里查找
+type saveState struct {
+	sheets         map[*Worksheet]string // 工作簿到工作表
+	drawings       map[*Worksheet]string // 工作表到绘图部件
+	legacyDrawings map[*Worksheet]string // 工作表到批注VML绘图部件
+	tables         map[*Table]string     // 工作表到表格部件
+	hyperlinks     map[*Hyperlink]string // 工作表到外部链接
+	charts         map[*Chart]string     // 绘图部件到图表部件
+	pictures       map[*Picture]string   // 绘图部件到图片
+	pictureLinks   map[*Picture]string   // 绘图部件到图片的超链接
 }
 
-// AddExternalRelationship 添加一个外部关系
-func (r *Relationships) AddExternalRelationship(id, relType, target string) *Relationship {
-	rel := &Relationship{
-		ID:         id,
-		Type:       relType,
-		Target:     target,
-		TargetMode: "External",
+// newSaveState 创建一个空的saveState
+func newSaveState() *saveState {
+	return &saveState{
+		sheets:         make(map[*Worksheet]string),
+		drawings:       make(map[*Worksheet]string),
+		legacyDrawings: make(map[*Worksheet]string),
+		tables:         make(map[*Table]string),
+		hyperlinks:     make(map[*Hyperlink]string),
+		charts:         make(map[*Chart]string),
+		pictures:       make(map[*Picture]string),
+		pictureLinks:   make(map[*Picture]string),
 	}
-	r.Relationships = append(r.Relationships, rel)
-	return rel
-}
-
-// GetRelationshipByID 根据ID获取关系
-func (r *Relationships) GetRelationshipByID(id string) *Relationship {
-	for _, rel := range r.Relationships {
-		if rel.ID == id {
-			return rel
-		}
-	}
-	return nil
-}
-
-// ToXML 将关系转换为XML
-func (r *Relationships) ToXML() string {
-	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
-	xml += "<Relationships xmlns=\"http://schemas.openxmlformats.org/package/2006/relationships\">\n"
-
-	for _, rel := range r.Relationships {
-		if rel.TargetMode == "External" {
-			xml += "  <Relationship Id=\"" + rel.ID + "\" Type=\"" + rel.Type + "\" Target=\"" + rel.Target + "\" TargetMode=\"External\"/>\n"
-		} else {
-			xml += "  <Relationship Id=\"" + rel.ID + "\" Type=\"" + rel.Type + "\" Target=\"" + rel.Target + "\"/>\n"
-		}
-	}
-
-	xml += "</Relationships>"
-	return xml
 }
@@ -0,0 +1,486 @@
+package workbook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// refShift 描述一次插入或删除行列的操作
+type refShift struct {
+	sheet *Worksheet
+	rows  bool // true表示行，false表示列
+	at    int  // 插入或删除的起始索引，从0开始
+	n     int  // 正数为插入的数量，负数为删除的数量
+}
+
+// InsertRows 在第at行（从1开始）之前插入n个空行
+// 之后的单元格、行属性和合并区域下移，工作簿中所有引用这些单元格的公式随之调整
+func (ws *Worksheet) InsertRows(at, n int) error {
+	if at < 1 || at > MaxRows || n < 1 {
+		return fmt.Errorf("无效的插入位置: 第%d行插入%d行", at, n)
+	}
+	if last := len(ws.Rows); last > 0 && ws.Rows[last-1].Index >= at && ws.Rows[last-1].Index+n > MaxRows {
+		return fmt.Errorf("插入%d行后数据将超出最大行数%d", n, MaxRows)
+	}
+	return ws.shift(refShift{sheet: ws, rows: true, at: at - 1, n: n})
+}
+
+// DeleteRows 从第at行（从1开始）起删除n行
+// 之后的单元格上移，引用被删除单元格的公式变为#REF!，跨越删除区域的引用范围随之缩小
+func (ws *Worksheet) DeleteRows(at, n int) error {
+	if at < 1 || at > MaxRows || n < 1 {
+		return fmt.Errorf("无效的删除位置: 第%d行删除%d行", at, n)
+	}
+	return ws.shift(refShift{sheet: ws, rows: true, at: at - 1, n: -min(n, MaxRows-at+1)})
+}
+
+// InsertCols 在第at列（从1开始）之前插入n个空列
+func (ws *Worksheet) InsertCols(at, n int) error {
+	if at < 1 || at > MaxColumns || n < 1 {
+		return fmt.Errorf("无效的插入位置: 第%d列插入%d列", at, n)
+	}
+	for _, row := range ws.Rows {
+		if last := len(row.Cells); last > 0 && row.Cells[last-1].Col >= at-1 && row.Cells[last-1].Col+n >= MaxColumns {
+			return fmt.Errorf("插入%d列后数据将超出最大列数%d", n, MaxColumns)
+		}
+	}
+	return ws.shift(refShift{sheet: ws, rows: false, at: at - 1, n: n})
+}
+
+// DeleteCols 从第at列（从1开始）起删除n列
+func (ws *Worksheet) DeleteCols(at, n int) error {
+	if at < 1 || at > MaxColumns || n < 1 {
+		return fmt.Errorf("无效的删除位置: 第%d列删除%d列", at, n)
+	}
+	return ws.shift(refShift{sheet: ws, rows: false, at: at - 1, n: -min(n, MaxColumns-at+1)})
+}
+
+// shift 执行插入或删除，移动单元格和工作表元数据并调整公式
+func (ws *Worksheet) shift(s refShift) error {
+	if ws.stream != nil {
+		return fmt.Errorf("流式写入的工作表 %s 不支持插入或删除行列", ws.Name)
+	}
+
+	if s.rows {
+		ws.shiftRows(s)
+	} else {
+		ws.shiftCols(s)
+	}
+
+	// 调整合并区域，完全被删除的合并区域被移除
+	merged := ws.MergedCells[:0]
+	for _, mc := range ws.MergedCells {
+		ref, ok := parseRangeRef(mc.TopLeftRef + ":" + mc.BottomRightRef)
+		if !ok {
+			merged = append(merged, mc)
+			continue
+		}
+		if s.adjustRef(ref) {
+			mc.TopLeftRef, mc.BottomRightRef = ref.From.String(), ref.To.String()
+			if mc.TopLeftRef != mc.BottomRightRef {
+				merged = append(merged, mc)
+			}
+		}
+	}
+	ws.MergedCells = merged
+
+	// 调整自动筛选和表格的区域，筛选条件和表格列随列移动
+	if ws.AutoFilter != nil {
+		if ref, cols, ok := s.adjustArea(ws.AutoFilter.Ref); ok {
+			ws.AutoFilter.Ref = ref
+			ws.AutoFilter.Columns = moveFilters(ws.AutoFilter.Columns, cols)
+		} else {
+			ws.AutoFilter = nil
+		}
+	}
+	tables := ws.Tables[:0]
+	for _, table := range ws.Tables {
+		if s.adjustTable(ws, table) {
+			tables = append(tables, table)
+		}
+	}
+	ws.Tables = tables
+
+	// 调整数据验证的区域，区域全部被删除的规则被移除
+	validations := ws.DataValidations[:0]
+	for _, dv := range ws.DataValidations {
+		if sqref, ok := s.adjustSqref(dv.Sqref); ok {
+			dv.Sqref = sqref
+			validations = append(validations, dv)
+		}
+	}
+	ws.DataValidations = validations
+
+	formats := ws.ConditionalFormats[:0]
+	for _, cf := range ws.ConditionalFormats {
+		if sqref, ok := s.adjustSqref(cf.Sqref); ok {
+			cf.Sqref = sqref
+			formats = append(formats, cf)
+		}
+	}
+	ws.ConditionalFormats = formats
+
+	// 移动批注，所在单元格被删除的批注被移除
+	comments := ws.Comments[:0]
+	for _, comment := range ws.Comments {
+		if ref, ok := parseRangeRef(comment.Ref); !ok {
+			comments = append(comments, comment)
+		} else if s.adjustRef(ref) {
+			comment.Ref = ref.From.String()
+			comments = append(comments, comment)
+		}
+	}
+	ws.Comments = comments
+
+	// 移动超链接，所在单元格被删除的超链接被移除
+	links := ws.Hyperlinks[:0]
+	for _, link := range ws.Hyperlinks {
+		if ref, ok := parseRangeRef(link.Ref); !ok {
+			links = append(links, link)
+		} else if s.adjustRef(ref) {
+			link.Ref = ref.From.String()
+			links = append(links, link)
+		}
+	}
+	ws.Hyperlinks = links
+
+	// 移动手动分页符，所在行列被删除的分页符被移除
+	if ps := ws.PageSetup; ps != nil {
+		if s.rows {
+			ps.RowBreaks = s.moveBreaks(ps.RowBreaks, MaxRows)
+		} else {
+			ps.ColBreaks = s.moveBreaks(ps.ColBreaks, MaxColumns)
+		}
+	}
+
+	for _, chart := range ws.Charts {
+		s.moveAnchor(&chart.From)
+		s.moveAnchor(&chart.To)
+	}
+	for _, pic := range ws.Pictures {
+		s.moveAnchor(&pic.From)
+		if pic.TwoCell {
+			s.moveAnchor(&pic.To)
+		}
+	}
+
+	// 调整工作簿中所有公式对该工作表的引用
+	sheets := []*Worksheet{ws}
+	if ws.wb != nil {
+		sheets = ws.wb.Worksheets
+	}
+	for _, sheet := range sheets {
+		for _, row := range sheet.Rows {
+			for _, cell := range row.Cells {
+				if cell.Formula != "" {
+					cell.Formula = s.adjustFormula(cell.Formula, sheet)
+				}
+			}
+		}
+		for _, dv := range sheet.DataValidations {
+			dv.Formula1 = s.adjustFormula(dv.Formula1, sheet)
+			dv.Formula2 = s.adjustFormula(dv.Formula2, sheet)
+		}
+		for _, chart := range sheet.Charts {
+			for _, series := range chart.Spec.Series {
+				for _, ref := range []*string{&series.NameRef, &series.Categories, &series.Values} {
+					*ref = s.adjustFormula(*ref, sheet)
+				}
+			}
+		}
+		for _, cf := range sheet.ConditionalFormats {
+			for _, rule := range cf.Rules {
+				for i, f := range rule.Formulas {
+					rule.Formulas[i] = s.adjustFormula(f, sheet)
+				}
+			}
+		}
+		for _, link := range sheet.Hyperlinks {
+			if link.Location != "" {
+				link.Location = s.adjustFormula(link.Location, sheet)
+			}
+		}
+	}
+	if ws.wb != nil {
+		for _, dn := range ws.wb.DefinedNames {
+			dn.RefersTo = s.adjustFormula(dn.RefersTo, ws.wb.GetWorksheet(dn.Scope))
+		}
+	}
+	return nil
+}
+
+// shiftRows 移动行，删除范围内的行被移除
+func (ws *Worksheet) shiftRows(s refShift) {
+	rows := ws.Rows[:0]
+	for _, row := range ws.Rows {
+		if index, ok := s.point(row.Index - 1); ok {
+			row.Index = index + 1
+			rows = append(rows, row)
+		}
+	}
+	// 插入时被移出最大行数的行丢弃
+	for len(rows) > 0 && rows[len(rows)-1].Index > MaxRows {
+		rows = rows[:len(rows)-1]
+	}
+	ws.Rows = rows
+}
+
+// shiftCols 移动每行中的单元格和列定义
+func (ws *Worksheet) shiftCols(s refShift) {
+	for _, row := range ws.Rows {
+		cells := row.Cells[:0]
+		for _, cell := range row.Cells {
+			if col, ok := s.point(cell.Col); ok && col < MaxColumns {
+				cell.Col = col
+				cells = append(cells, cell)
+			}
+		}
+		row.Cells = cells
+	}
+
+	columns := ws.Columns[:0]
+	for _, col := range ws.Columns {
+		lo, hi, ok := s.span(col.Min-1, col.Max-1)
+		if !ok || lo >= MaxColumns {
+			continue
+		}
+		col.Min, col.Max = lo+1, min(hi, MaxColumns-1)+1
+		columns = append(columns, col)
+	}
+	ws.Columns = columns
+}
+
+// point 移动单个索引，索引被删除时返回false
+func (s refShift) point(i int) (int, bool) {
+	switch {
+	case i < s.at:
+		return i, true
+	case s.n > 0:
+		return i + s.n, true
+	case i < s.at-s.n:
+		return 0, false
+	}
+	return i + s.n, true
+}
+
+// span 移动索引范围[a, b]：插入在范围内部时范围扩大，删除范围的一部分时范围缩小，全部删除时返回false
+func (s refShift) span(a, b int) (int, int, bool) {
+	if a > b {
+		a, b = b, a
+	}
+	if s.n > 0 {
+		if a >= s.at {
+			return a + s.n, b + s.n, true
+		}
+		if b >= s.at {
+			return a, b + s.n, true
+		}
+		return a, b, true
+	}
+
+	end := s.at - s.n // 删除范围之后的第一个索引
+	if a >= s.at && b < end {
+		return 0, 0, false
+	}
+	if a >= end {
+		a += s.n
+	} else if a >= s.at {
+		a = s.at
+	}
+	if b >= end {
+		b += s.n
+	} else if b >= s.at {
+		b = s.at - 1
+	}
+	return a, b, true
+}
+
+// adjustRef 调整引用，返回false表示引用的单元格已被删除
+func (s refShift) adjustRef(ref *formulaRef) bool {
+	from, to, limit := &ref.From.Row, &ref.To.Row, MaxRows
+	if !s.rows {
+		from, to, limit = &ref.From.Col, &ref.To.Col, MaxColumns
+	}
+	// 整行引用不受插入列影响，整列引用不受插入行影响
+	if *from < 0 || *to < 0 {
+		return true
+	}
+
+	if !ref.IsRange {
+		i, ok := s.point(*from)
+		*from, *to = i, i
+		return ok && i < limit
+	}
+	a, b, ok := s.span(*from, *to)
+	if !ok || a >= limit {
+		return false
+	}
+	// 插入时范围的末端超出工作表时截断到最后一行或最后一列
+	*from, *to = a, min(b, limit-1)
+	return true
+}
+
+// adjustArea 调整区域ref，并返回原区域中每一列移动后在新区域中的列序号，被删除的列为-1
+// 区域全部被删除时返回false
+func (s refShift) adjustArea(ref string) (string, []int, bool) {
+	area, ok := parseRangeRef(ref)
+	if !ok {
+		return ref, nil, true
+	}
+	_, col1, _, col2 := area.bounds()
+	if !s.adjustRef(area) {
+		return "", nil, false
+	}
+	cols := make([]int, col2-col1+1)
+	for i := range cols {
+		cols[i] = i
+		if !s.rows {
+			col, ok := s.point(col1 + i)
+			cols[i] = -1
+			if ok && col <= area.To.Col {
+				cols[i] = col - area.From.Col
+			}
+		}
+	}
+	return area.areaString(), cols, true
+}
+
+// moveFilters 按列的移动调整筛选条件的列序号，被删除的列上的筛选条件被移除
+func moveFilters(filters []*FilterColumn, cols []int) []*FilterColumn {
+	kept := filters[:0]
+	for _, fc := range filters {
+		if fc.Col < len(cols) && cols[fc.Col] >= 0 {
+			fc.Col = cols[fc.Col]
+			kept = append(kept, fc)
+		}
+	}
+	return kept
+}
+
+// adjustTable 调整表格的区域和列，表格全部被删除时返回false
+// 标题行或汇总行被删除时表格不再有标题行或汇总行，插入的列使用自动生成的列名称
+func (s refShift) adjustTable(ws *Worksheet, table *Table) bool {
+	row1, _, row2, _ := table.bounds()
+	ref, cols, ok := s.adjustArea(table.Ref)
+	if !ok {
+		return false
+	}
+	if s.rows {
+		if _, ok := s.point(row1); !ok {
+			table.Spec.HeaderRow = false
+			table.Spec.Filters = nil
+		}
+		if _, ok := s.point(row2); !ok {
+			table.Spec.TotalsRow = false
+		}
+	}
+	table.Ref = ref
+	table.Spec.Filters = moveFilters(table.Spec.Filters, cols)
+	if !s.rows {
+		_, col1, _, col2 := table.bounds()
+		columns := make([]*TableColumn, col2-col1+1)
+		for i, col := range cols {
+			if col >= 0 && i < len(table.Spec.Columns) {
+				columns[col] = table.Spec.Columns[i]
+			}
+		}
+		table.Spec.Columns = columns
+		table.resolveColumns(ws)
+	}
+	return true
+}
+
+// moveBreaks 移动分页符，limit为行数或列数的上限
+func (s refShift) moveBreaks(breaks []int, limit int) []int {
+	kept := breaks[:0]
+	for _, id := range breaks {
+		if index, ok := s.point(id); ok && index > 0 && index < limit && (len(kept) == 0 || kept[len(kept)-1] != index) {
+			kept = append(kept, index)
+		}
+	}
+	return kept
+}
+
+// moveAnchor 移动绘图对象的锚点，锚点所在的行或列被删除时移到删除位置
+func (s refShift) moveAnchor(a *anchorPoint) {
+	index, offset := &a.Row, &a.RowOff
+	if !s.rows {
+		index, offset = &a.Col, &a.ColOff
+	}
+	if i, ok := s.point(*index); ok {
+		*index = i
+	} else {
+		*index, *offset = s.at, 0
+	}
+}
+
+// adjustSqref 调整以空格分隔的区域列表，被完全删除的区域被移除，全部被删除时返回false
+func (s refShift) adjustSqref(sqref string) (string, bool) {
+	refs := strings.Fields(sqref)
+	kept := refs[:0]
+	for _, text := range refs {
+		ref, ok := parseRangeRef(text)
+		if !ok {
+			kept = append(kept, text)
+			continue
+		}
+		if s.adjustRef(ref) {
+			kept = append(kept, ref.areaString())
+		}
+	}
+	return strings.Join(kept, " "), len(kept) > 0
+}
+
+// adjustFormula 调整公式中引用目标工作表的部分，sheet为公式所在的工作表
+// 只替换发生变化的引用，公式的其他部分保持原样；无法解析的公式不做修改
+func (s refShift) adjustFormula(formula string, sheet *Worksheet) string {
+	tokens, err := tokenizeFormula(formula)
+	if err != nil {
+		return formula
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, token := range tokens {
+		if token.Type != tokenRef {
+			continue
+		}
+		target := sheet
+		if token.Ref.Sheet != "" {
+			target = nil
+			if strings.EqualFold(token.Ref.Sheet, s.sheet.Name) {
+				target = s.sheet
+			}
+		}
+		if target != s.sheet {
+			continue
+		}
+
+		ref := *token.Ref
+		text := string(FormulaErrorRef)
+		if s.adjustRef(&ref) {
+			if ref == *token.Ref {
+				continue
+			}
+			// 保留原公式中工作表名称的写法
+			text = token.Text[:strings.LastIndex(token.Text, "!")+1] + ref.areaString()
+		}
+		sb.WriteString(formula[last:token.Pos])
+		sb.WriteString(text)
+		last = token.End
+	}
+	if last == 0 {
+		return formula
+	}
+	sb.WriteString(formula[last:])
+	return sb.String()
+}
+
+// parseRangeRef 解析A1或A1:B2格式的区域引用
+func parseRangeRef(s string) (*formulaRef, bool) {
+	ref, n := parseRefText(s)
+	if ref == nil || n != len(s) {
+		return nil, false
+	}
+	return ref, true
+}
@@ -0,0 +1,131 @@
+package workbook
+
+import "testing"
+
+// TestShiftReferences 检查插入和删除行列后，本工作表、其他工作表和定义名称中的引用随之调整
+func TestShiftReferences(t *testing.T) {
+	type expect struct {
+		sum, local, cross, quoted, other, name, scoped, otherScoped string
+	}
+	tests := []struct {
+		name  string
+		shift func(ws *Worksheet) error
+		want  expect
+	}{
+		{
+			name:  "InsertRows",
+			shift: func(ws *Worksheet) error { return ws.InsertRows(3, 2) },
+			want: expect{
+				sum: "SUM(A2:A7)", local: "A1+A5+Other!A3",
+				cross: "Sheet1!A5*2", quoted: "'Sheet1'!$A$6", other: "A3",
+				name: "Sheet1!$A$2:$A$7", scoped: "$A$5", otherScoped: "$A$3",
+			},
+		},
+		{
+			name:  "DeleteRows",
+			shift: func(ws *Worksheet) error { return ws.DeleteRows(3, 1) },
+			want: expect{
+				sum: "SUM(A2:A4)", local: "A1+#REF!+Other!A3",
+				cross: "#REF!*2", quoted: "'Sheet1'!$A$3", other: "A3",
+				name: "Sheet1!$A$2:$A$4", scoped: "#REF!", otherScoped: "$A$3",
+			},
+		},
+		{
+			name:  "InsertCols",
+			shift: func(ws *Worksheet) error { return ws.InsertCols(1, 1) },
+			want: expect{
+				sum: "SUM(B2:B5)", local: "B1+B3+Other!A3",
+				cross: "Sheet1!B3*2", quoted: "'Sheet1'!$B$4", other: "A3",
+				name: "Sheet1!$B$2:$B$5", scoped: "$B$3", otherScoped: "$A$3",
+			},
+		},
+		{
+			name:  "DeleteCols",
+			shift: func(ws *Worksheet) error { return ws.DeleteCols(1, 1) },
+			want: expect{
+				sum: "SUM(#REF!)", local: "#REF!+#REF!+Other!A3",
+				cross: "#REF!*2", quoted: "#REF!", other: "A3",
+				name: "#REF!", scoped: "#REF!", otherScoped: "$A$3",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wb := NewWorkbook()
+			ws := wb.AddWorksheet("Sheet1")
+			other := wb.AddWorksheet("Other")
+			for row := 0; row < 6; row++ {
+				ws.SetCellValue(row, 0, float64(row+1))
+				other.SetCellValue(row, 0, float64(row+1))
+			}
+
+			sum := ws.SetCellFormula("C1", "SUM(A2:A5)")
+			local := ws.SetCellFormula("C2", "A1+A3+Other!A3")
+			cross := other.SetCellFormula("C1", "Sheet1!A3*2")
+			quoted := other.SetCellFormula("C2", "'Sheet1'!$A$4")
+			otherLocal := other.SetCellFormula("C3", "A3")
+			name := mustDefineName(t, wb, "Total", "Sheet1!$A$2:$A$5", "")
+			scoped := mustDefineName(t, wb, "Local", "$A$3", "Sheet1")
+			otherScoped := mustDefineName(t, wb, "Local", "$A$3", "Other")
+
+			if err := tt.shift(ws); err != nil {
+				t.Fatal(err)
+			}
+
+			checks := []struct {
+				what, got, want string
+			}{
+				{"本工作表的区域", sum.Formula, tt.want.sum},
+				{"本工作表的引用", local.Formula, tt.want.local},
+				{"其他工作表的引用", cross.Formula, tt.want.cross},
+				{"带引号的工作表名称", quoted.Formula, tt.want.quoted},
+				{"其他工作表自身的引用", otherLocal.Formula, tt.want.other},
+				{"全局名称", name.RefersTo, tt.want.name},
+				{"工作表级名称", scoped.RefersTo, tt.want.scoped},
+				{"其他工作表的名称", otherScoped.RefersTo, tt.want.otherScoped},
+			}
+			for _, c := range checks {
+				if c.got != c.want {
+					t.Errorf("%s: %q, 期望 %q", c.what, c.got, c.want)
+				}
+			}
+		})
+	}
+}
+
+// TestShiftRecalculate 检查插入行之后公式仍然引用原来的数据
+func TestShiftRecalculate(t *testing.T) {
+	wb := NewWorkbook()
+	ws := wb.AddWorksheet("Sheet1")
+	other := wb.AddWorksheet("Other")
+	for row := 0; row < 4; row++ {
+		ws.SetCellValue(row, 0, float64(row+1))
+	}
+	other.SetCellFormula("A1", "SUM(Sheet1!A1:A4)*Sheet1!A2")
+
+	before, err := wb.CalcCellValue("Other", "A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.InsertRows(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	after, err := wb.CalcCellValue("Other", "A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before != 20.0 || after != before {
+		t.Errorf("插入行前后的结果为 %v 和 %v, 期望都为 20", before, after)
+	}
+}
+
+// mustDefineName 定义名称，失败时结束测试
+func mustDefineName(t *testing.T, wb *Workbook, name, refersTo, scope string) *DefinedName {
+	t.Helper()
+	dn, err := wb.DefineName(name, refersTo, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dn
+}
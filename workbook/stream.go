@@ -0,0 +1,370 @@
+package workbook
+
+import (
+	"bufio"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/landaiqing/go-dockit/opc"
+)
+
+// StreamWriter 按行顺序写入一个工作表，用于导出行数很多的大表
+// 已写入的行直接编码为XML并写入临时文件，保存时复制到zip条目中，内存占用与行数无关
+// 工作簿在保存时才生成zip，行不能直接写入zip条目，因此需要临时文件：
+// 临时目录（Workbook.TempDir，默认为系统临时目录）需要可写，并且有约为未压缩工作表大小的空闲空间
+// 保存不会删除临时文件，保存失败后可以修正问题再次保存，也可以多次保存；用完后调用Workbook.Close删除临时文件
+// 列宽、视图（如Sheet.FreezePanes）和按页数缩放需要在写入第一行之前设置；
+// 合并单元格、自动筛选、条件格式、数据验证、保护和其他页面设置在Flush之前设置即可，写入完成后必须调用Flush
+// 保护密码的盐值由写入的内容和密码生成，相同的内容总是得到相同的哈希
+// 流式写入的工作表不支持需要工作表关系的内容，添加表格、图表、图片、批注和超链接时返回错误
+type StreamWriter struct {
+	Sheet *Worksheet
+
+	wb       *Workbook
+	file     *os.File
+	writer   *bufio.Writer
+	digest   hash.Hash // 已写入内容的摘要，用于生成保护密码的盐值
+	started  bool      // 是否已写入sheetData之前的部分
+	flushed  bool
+	closed   bool
+	lastRow  int // 最后写入的行号，从1开始
+	rowCount int
+}
+
+// StreamCell 表示流式写入的一个单元格，可以同时指定值、样式和公式
+// 直接写入的值等价于只设置Value的StreamCell；日期需要指定带日期格式的样式，否则显示为序列号
+type StreamCell struct {
+	Value   interface{}
+	StyleID int    // Styles.NewStyle返回的XF索引
+	Formula string // 公式，不带前导等号
+}
+
+// RowOptions 表示流式写入的行属性
+type RowOptions struct {
+	Height  float64
+	Hidden  bool
+	StyleID int // 整行的默认样式
+}
+
+// streamBufferSize 流式写入的缓冲区大小
+const streamBufferSize = 32 * 1024
+
+// NewStreamWriter 添加一个名为sheetName的工作表，并返回向它按行写入的StreamWriter
+func (wb *Workbook) NewStreamWriter(sheetName string) (*StreamWriter, error) {
+	if err := ValidateSheetName(sheetName); err != nil {
+		return nil, err
+	}
+	for _, ws := range wb.Worksheets {
+		if strings.EqualFold(ws.Name, sheetName) {
+			return nil, fmt.Errorf("工作表 %s 已存在", sheetName)
+		}
+	}
+
+	file, err := os.CreateTemp(wb.TempDir, "go-dockit-sheet-*.xml")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+
+	digest := sha512.New()
+	sw := &StreamWriter{
+		Sheet:  wb.AddWorksheet(sheetName),
+		wb:     wb,
+		file:   file,
+		writer: bufio.NewWriterSize(io.MultiWriter(file, digest), streamBufferSize),
+		digest: digest,
+	}
+	sw.Sheet.stream = sw
+	return sw, nil
+}
+
+// SetColWidth 设置从min到max列（从1开始）的宽度，必须在写入第一行之前调用
+func (sw *StreamWriter) SetColWidth(min, max int, width float64) error {
+	if sw.started {
+		return fmt.Errorf("列宽必须在写入第一行之前设置")
+	}
+	if min < 1 || max < min || max > MaxColumns {
+		return fmt.Errorf("无效的列范围: %d-%d", min, max)
+	}
+	sw.Sheet.AddColumn(min, max, width)
+	return nil
+}
+
+// MergeCell 合并单元格，合并区域在Flush时写入，可以在任意时刻调用
+func (sw *StreamWriter) MergeCell(topLeftRef, bottomRightRef string) error {
+	if sw.flushed || sw.closed {
+		return fmt.Errorf("流式写入器已经完成写入")
+	}
+	if !isValidCellRef(topLeftRef) || !isValidCellRef(bottomRightRef) {
+		return fmt.Errorf("无效的合并区域: %s:%s", topLeftRef, bottomRightRef)
+	}
+	sw.Sheet.MergeCells(topLeftRef, bottomRightRef)
+	return nil
+}
+
+// WriteRow 在最后写入的行之后写入一行，从A列开始
+func (sw *StreamWriter) WriteRow(values ...interface{}) error {
+	return sw.SetRow(CellRef(sw.lastRow, 0), values, nil)
+}
+
+// SetRow 从cellRef开始向右写入一行，行号必须大于已写入的行
+// values中的元素可以是AddCell支持的任意值、StreamCell或nil（跳过该单元格）
+// opts可以为nil
+func (sw *StreamWriter) SetRow(cellRef string, values []interface{}, opts *RowOptions) error {
+	if sw.flushed || sw.closed {
+		return fmt.Errorf("流式写入器已经完成写入")
+	}
+	if !isValidCellRef(cellRef) {
+		return fmt.Errorf("无效的单元格引用: %s", cellRef)
+	}
+	row, col, err := ParseCellRef(cellRef)
+	if err != nil {
+		return err
+	}
+	rowNum := row + 1
+	if rowNum <= sw.lastRow {
+		return fmt.Errorf("行号必须递增: 第%d行已经写入", sw.lastRow)
+	}
+	if col+len(values) > MaxColumns {
+		return fmt.Errorf("第%d行超出最大列数%d", rowNum, MaxColumns)
+	}
+
+	if err := sw.start(); err != nil {
+		return err
+	}
+
+	xml := fmt.Sprintf("    <row r=\"%d\"", rowNum)
+	if opts != nil {
+		if opts.Height > 0 {
+			xml += fmt.Sprintf(" ht=\"%f\" customHeight=\"1\"", opts.Height)
+		}
+		if opts.Hidden {
+			xml += " hidden=\"1\""
+		}
+		if opts.StyleID > 0 {
+			if err := sw.checkStyleID(opts.StyleID); err != nil {
+				return err
+			}
+			xml += fmt.Sprintf(" s=\"%d\" customFormat=\"1\"", opts.StyleID)
+		}
+	}
+	xml += ">\n"
+
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		cellXML, err := sw.cellXML(CellRef(row, col+i), value)
+		if err != nil {
+			return err
+		}
+		xml += cellXML
+	}
+	xml += "    </row>\n"
+
+	if _, err := sw.writer.WriteString(xml); err != nil {
+		return err
+	}
+	sw.lastRow = rowNum
+	sw.rowCount++
+	return nil
+}
+
+// Rows 返回已写入的行数
+func (sw *StreamWriter) Rows() int {
+	return sw.rowCount
+}
+
+// Flush 结束写入，写入合并单元格、自动筛选、条件格式、数据验证等sheetData之后的内容
+// 调用Flush之后不能再写入行，也不能再添加这些内容，保存工作簿之前必须调用
+func (sw *StreamWriter) Flush() error {
+	if sw.flushed {
+		return nil
+	}
+	if sw.closed {
+		return fmt.Errorf("流式写入器已经关闭")
+	}
+	if err := sw.start(); err != nil {
+		return err
+	}
+
+	// 保护设置在Flush时写入，密码的盐值总是由已写入的内容和密码生成，与是否开启确定性输出无关
+	if err := sw.writer.Flush(); err != nil {
+		return err
+	}
+	xml := "  </sheetData>\n"
+	if p := sw.Sheet.Protection; p != nil {
+		hash := p.Password
+		if p.password != "" {
+			hash = opc.NewPasswordHashWithSalt(p.password, opc.DeterministicSalt(p.password, sw.digest.Sum(nil)))
+		}
+		xml += sw.Sheet.sheetProtectionXML(hash)
+	}
+	xml += sw.Sheet.sheetMetadataXML(newSaveState())
+	xml += "</worksheet>"
+
+	if _, err := sw.writer.WriteString(xml); err != nil {
+		return err
+	}
+	if err := sw.writer.Flush(); err != nil {
+		return err
+	}
+	sw.flushed = true
+	return sw.file.Close()
+}
+
+// Close 删除流式写入使用的临时文件，之后不能再保存该工作表
+func (sw *StreamWriter) Close() error {
+	if sw.closed {
+		return nil
+	}
+	if !sw.flushed {
+		sw.file.Close()
+	}
+	sw.closed = true
+	if err := os.Remove(sw.file.Name()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// open 打开已写入的工作表XML，供保存时复制到zip条目中
+func (sw *StreamWriter) open() (io.ReadCloser, error) {
+	if err := sw.ready(); err != nil {
+		return nil, err
+	}
+	return os.Open(sw.file.Name())
+}
+
+// ready 检查工作表是否可以保存，已关闭、未调用Flush或包含需要工作表关系的内容时返回错误
+func (sw *StreamWriter) ready() error {
+	if sw.closed {
+		return fmt.Errorf("工作表 %s 的流式写入器已经关闭", sw.Sheet.Name)
+	}
+	if !sw.flushed {
+		return fmt.Errorf("工作表 %s 的流式写入器未调用Flush", sw.Sheet.Name)
+	}
+	// 直接向字段添加的内容需要工作表关系，流式写入的工作表无法写出
+	ws := sw.Sheet
+	if ws.hasDrawing() || len(ws.Tables) > 0 || len(ws.Comments) > 0 || len(ws.Hyperlinks) > 0 {
+		return fmt.Errorf("流式写入的工作表 %s 不支持表格、图表、图片、批注和超链接", ws.Name)
+	}
+	return nil
+}
+
+// start 在写入第一行之前写入工作表开头、视图和列定义
+func (sw *StreamWriter) start() error {
+	if sw.started {
+		return nil
+	}
+	sw.started = true
+
+	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
+	xml += "<worksheet xmlns=\"http://schemas.openxmlformats.org/spreadsheetml/2006/main\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\">\n"
+	xml += sw.Sheet.sheetPrXML()
+	xml += sw.Sheet.sheetViewsXML()
+
+	if len(sw.Sheet.Columns) > 0 {
+		xml += "  <cols>\n"
+		for _, col := range sw.Sheet.Columns {
+			xml += fmt.Sprintf("    <col min=\"%d\" max=\"%d\" width=\"%f\" customWidth=\"1\"", col.Min, col.Max, col.Width)
+			if col.Hidden {
+				xml += " hidden=\"1\""
+			}
+			xml += "/>\n"
+		}
+		xml += "  </cols>\n"
+	}
+
+	xml += "  <sheetData>\n"
+	_, err := sw.writer.WriteString(xml)
+	return err
+}
+
+// cellXML 生成一个单元格的XML，字符串使用内联字符串，不进入共享字符串表
+func (sw *StreamWriter) cellXML(cellRef string, value interface{}) (string, error) {
+	var styleID int
+	var formula string
+	switch v := value.(type) {
+	case StreamCell:
+		value, styleID, formula = v.Value, v.StyleID, v.Formula
+	case *StreamCell:
+		value, styleID, formula = v.Value, v.StyleID, v.Formula
+	}
+	if err := sw.checkStyleID(styleID); err != nil {
+		return "", err
+	}
+
+	xml := fmt.Sprintf("      <c r=\"%s\"", cellRef)
+	if styleID > 0 {
+		xml += fmt.Sprintf(" s=\"%d\"", styleID)
+	}
+
+	// 只有样式或公式的单元格
+	if value == nil {
+		xml += ">"
+		if formula != "" {
+			xml += "<f>" + escapeXML(storedFormula(formula)) + "</f>"
+		}
+		return xml + "</c>\n", nil
+	}
+
+	cell := &Cell{}
+	cell.setValue(value)
+
+	switch cell.DataType {
+	case "n":
+		xml += ">"
+		if formula != "" {
+			xml += "<f>" + escapeXML(storedFormula(formula)) + "</f>"
+		}
+		xml += fmt.Sprintf("<v>%v</v>", cell.Value)
+	case "b":
+		xml += " t=\"b\">"
+		if formula != "" {
+			xml += "<f>" + escapeXML(storedFormula(formula)) + "</f>"
+		}
+		xml += fmt.Sprintf("<v>%d</v>", boolToInt(cell.Value.(bool)))
+	default:
+		if formula != "" {
+			xml += " t=\"str\"><f>" + escapeXML(storedFormula(formula)) + "</f><v>" + escapeXML(fmt.Sprintf("%v", cell.Value)) + "</v>"
+		} else {
+			xml += " t=\"inlineStr\"><is><t xml:space=\"preserve\">" + escapeXML(fmt.Sprintf("%v", cell.Value)) + "</t></is>"
+		}
+	}
+	return xml + "</c>\n", nil
+}
+
+// checkWritable 检查流式写入的工作表是否还能添加sheetData之后的内容，普通工作表总是可以
+func (ws *Worksheet) checkWritable() error {
+	if ws.stream != nil && (ws.stream.flushed || ws.stream.closed) {
+		return fmt.Errorf("工作表 %s 的流式写入器已经完成写入", ws.Name)
+	}
+	return nil
+}
+
+// checkStyleID 检查样式索引是否已在样式表中注册
+func (sw *StreamWriter) checkStyleID(styleID int) error {
+	if styleID < 0 || styleID >= len(sw.wb.Styles.CellXfs) {
+		return fmt.Errorf("无效的样式索引: %d", styleID)
+	}
+	return nil
+}
+
+// Close 删除工作簿中流式写入器使用的临时文件，之后不能再保存流式写入的工作表
+// 创建了流式写入器的工作簿在保存完成或放弃保存后都需要调用，通常在NewStreamWriter之后defer调用
+func (wb *Workbook) Close() error {
+	var firstErr error
+	for _, ws := range wb.Worksheets {
+		if ws.stream == nil {
+			continue
+		}
+		if err := ws.stream.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
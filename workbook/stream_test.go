@@ -0,0 +1,71 @@
+package workbook
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// BenchmarkStreamWriter 流式写入不同行数的审计日志并写出工作簿
+// 除了每次操作的分配次数和字节数，还报告写完所有行之后的堆内存占用，用来验证内存占用与行数无关
+// 例如: go test ./workbook -run '^$' -bench StreamWriter -benchtime 1x
+func BenchmarkStreamWriter(b *testing.B) {
+	for _, rows := range []int{10000, 100000, 500000} {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			b.ReportAllocs()
+			var heapInUse uint64
+			for i := 0; i < b.N; i++ {
+				wb := NewWorkbook()
+				sw, err := wb.NewStreamWriter("审计日志")
+				if err != nil {
+					b.Fatal(err)
+				}
+				timeStyleID, err := wb.Styles.NewStyle(&Style{NumFmt: "yyyy-mm-dd hh:mm:ss"})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := sw.SetColWidth(1, 6, 16); err != nil {
+					b.Fatal(err)
+				}
+				if err := sw.WriteRow("编号", "时间", "用户", "操作", "金额", "成功"); err != nil {
+					b.Fatal(err)
+				}
+
+				start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+				for row := 1; row <= rows; row++ {
+					err := sw.WriteRow(
+						row,
+						StreamCell{Value: start.Add(time.Duration(row) * time.Second), StyleID: timeStyleID},
+						fmt.Sprintf("user%03d", row%1000),
+						"导出报表",
+						float64(row)*1.25,
+						row%7 != 0,
+					)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := sw.Flush(); err != nil {
+					b.Fatal(err)
+				}
+
+				b.StopTimer()
+				runtime.GC()
+				var stats runtime.MemStats
+				runtime.ReadMemStats(&stats)
+				heapInUse = max(heapInUse, stats.HeapInuse)
+				b.StartTimer()
+
+				if _, err := wb.WriteTo(io.Discard); err != nil {
+					b.Fatal(err)
+				}
+				if err := wb.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(heapInUse)/(1<<20), "heap-MB")
+		})
+	}
+}
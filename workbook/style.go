@@ -0,0 +1,454 @@
+package workbook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Style 描述一个完整的单元格样式，通过Styles.NewStyle注册后得到单元格XF索引
+// 各组成部分为nil时使用默认值
+type Style struct {
+	Font       *Font
+	Fill       *Fill
+	Border     *Border
+	NumFmt     string // 数字格式代码，如"0.00%"、"yyyy-mm-dd"，为空表示常规格式
+	Alignment  *Alignment
+	Protection *Protection
+}
+
+// Protection 表示单元格保护属性，只在工作表受保护时生效
+type Protection struct {
+	Locked bool // 锁定单元格，Excel默认锁定所有单元格
+	Hidden bool // 隐藏公式
+}
+
+// 默认字体
+const (
+	defaultFontName = "Calibri"
+	defaultFontSize = 11
+)
+
+// 内置超链接样式的builtinId和字体颜色，与Excel默认主题一致
+const (
+	builtinStyleHyperlink = 8
+	hyperlinkColor        = "FF0563C1"
+)
+
+// 自定义数字格式的起始ID，更小的ID保留给内置格式
+const firstCustomNumFmtID = 164
+
+// 与区域设置无关的内置数字格式
+var standardNumberFormats = map[string]int{
+	"General":                  0,
+	"0":                        1,
+	"0.00":                     2,
+	"#,##0":                    3,
+	"#,##0.00":                 4,
+	"0%":                       9,
+	"0.00%":                    10,
+	"0.00E+00":                 11,
+	"# ?/?":                    12,
+	"# ??/??":                  13,
+	"mm-dd-yy":                 14,
+	"d-mmm-yy":                 15,
+	"d-mmm":                    16,
+	"mmm-yy":                   17,
+	"h:mm AM/PM":               18,
+	"h:mm:ss AM/PM":            19,
+	"h:mm":                     20,
+	"h:mm:ss":                  21,
+	"m/d/yy h:mm":              22,
+	"#,##0 ;(#,##0)":           37,
+	"#,##0 ;[Red](#,##0)":      38,
+	"#,##0.00;(#,##0.00)":      39,
+	"#,##0.00;[Red](#,##0.00)": 40,
+	"mm:ss":                    45,
+	"[h]:mm:ss":                46,
+	"mmss.0":                   47,
+	"##0.0E+0":                 48,
+	"@":                        49,
+}
+
+// 填充和边框允许的样式
+var (
+	validPatternTypes = map[string]bool{
+		"none": true, "solid": true, "mediumGray": true, "darkGray": true, "lightGray": true,
+		"darkHorizontal": true, "darkVertical": true, "darkDown": true, "darkUp": true,
+		"darkGrid": true, "darkTrellis": true, "lightHorizontal": true, "lightVertical": true,
+		"lightDown": true, "lightUp": true, "lightGrid": true, "lightTrellis": true,
+		"gray125": true, "gray0625": true,
+	}
+	validBorderStyles = map[string]bool{
+		"none": true, "thin": true, "medium": true, "dashed": true, "dotted": true, "thick": true,
+		"double": true, "hair": true, "mediumDashed": true, "dashDot": true, "mediumDashDot": true,
+		"dashDotDot": true, "mediumDashDotDot": true, "slantDashDot": true,
+	}
+)
+
+// NewStyle 注册一个单元格样式并返回其XF索引，可以直接用于Worksheet.SetCellStyleID
+// 字体、填充、边框和数字格式会被复用，完全相同的样式总是返回同一个索引
+func (s *Styles) NewStyle(style *Style) (int, error) {
+	if style == nil {
+		return 0, nil
+	}
+
+	fontID, err := s.internFont(style.Font)
+	if err != nil {
+		return 0, err
+	}
+	fillID, err := s.internFill(style.Fill)
+	if err != nil {
+		return 0, err
+	}
+	borderID, err := s.internBorder(style.Border)
+	if err != nil {
+		return 0, err
+	}
+	numFmtID := s.internNumFmt(style.NumFmt)
+
+	var alignment *Alignment
+	if style.Alignment != nil {
+		if style.Alignment.Horizontal != "" && !validHorizontalAlignments[style.Alignment.Horizontal] {
+			return 0, fmt.Errorf("无效的水平对齐方式: %s", style.Alignment.Horizontal)
+		}
+		if style.Alignment.Vertical != "" && !validVerticalAlignments[style.Alignment.Vertical] {
+			return 0, fmt.Errorf("无效的垂直对齐方式: %s", style.Alignment.Vertical)
+		}
+		a := *style.Alignment
+		alignment = &a
+	}
+
+	var protection *Protection
+	if style.Protection != nil {
+		p := *style.Protection
+		protection = &p
+	}
+
+	xf := &CellXf{
+		FontId:            fontID,
+		FillId:            fillID,
+		BorderId:          borderID,
+		NumFmtId:          numFmtID,
+		Alignment:         alignment,
+		Protection:        protection,
+		ApplyFont:         fontID > 0,
+		ApplyFill:         fillID > 0,
+		ApplyBorder:       borderID > 0,
+		ApplyNumberFormat: numFmtID > 0,
+		ApplyAlignment:    alignment != nil,
+		ApplyProtection:   protection != nil,
+	}
+
+	return s.internXf(xf), nil
+}
+
+// internXf 返回与xf相同的已注册XF的索引，不存在时注册
+func (s *Styles) internXf(xf *CellXf) int {
+	key := xfKey(xf)
+	for i, existing := range s.CellXfs {
+		if xfKey(existing) == key {
+			return i
+		}
+	}
+	s.CellXfs = append(s.CellXfs, xf)
+	return len(s.CellXfs) - 1
+}
+
+// withProtection 返回与styleID格式相同、保护属性为protection的样式ID
+func (s *Styles) withProtection(styleID int, protection *Protection) int {
+	if styleID < 0 || styleID >= len(s.CellXfs) {
+		styleID = 0
+	}
+	xf := *s.CellXfs[styleID]
+	p := *protection
+	xf.Protection = &p
+	xf.ApplyProtection = true
+	return s.internXf(&xf)
+}
+
+// withHyperlinkStyle 返回在styleID基础上应用内置超链接样式的样式ID，填充、边框等其他格式保持不变
+func (s *Styles) withHyperlinkStyle(styleID int) int {
+	if styleID < 0 || styleID >= len(s.CellXfs) {
+		styleID = 0
+	}
+	xfID := s.hyperlinkStyleXf()
+	xf := *s.CellXfs[styleID]
+	xf.XfId = xfID
+	xf.FontId = s.CellStyleXfs[xfID].FontId
+	xf.ApplyFont = true
+	return s.internXf(&xf)
+}
+
+// hyperlinkStyleXf 返回内置超链接样式的单元格样式XF索引，不存在时添加
+func (s *Styles) hyperlinkStyleXf() int {
+	for _, cs := range s.CellStyles {
+		if cs.BuiltinId == builtinStyleHyperlink && cs.XfId > 0 && cs.XfId < len(s.CellStyleXfs) {
+			return cs.XfId
+		}
+	}
+	fontID, _ := s.internFont(&Font{Underline: true, Color: hyperlinkColor})
+	s.AddCellStyleXf(fontID, 0, 0, 0, nil)
+	xfID := len(s.CellStyleXfs) - 1
+	s.AddCellStyle("Hyperlink", xfID, builtinStyleHyperlink)
+	return xfID
+}
+
+// Dxf 表示差异格式，条件格式满足条件时叠加到单元格原有格式之上
+// 只输出设置了的部分，例如只设置字体颜色时单元格的填充和边框保持不变
+type Dxf struct {
+	Font      *Font
+	Fill      *Fill
+	Border    *Border
+	NumFmtID  int
+	NumFmt    string
+	Alignment *Alignment
+}
+
+// NewDifferentialStyle 注册一个差异格式并返回其索引，用于条件格式
+// 与NewStyle不同，字体不会补充默认名称和大小；相同的差异格式总是返回同一个索引
+func (s *Styles) NewDifferentialStyle(style *Style) (int, error) {
+	if style == nil {
+		return 0, fmt.Errorf("差异格式不能为空")
+	}
+
+	dxf := &Dxf{}
+	if style.Font != nil {
+		f := *style.Font
+		color, err := normalizeColor(f.Color)
+		if err != nil {
+			return 0, err
+		}
+		f.Color = color
+		dxf.Font = &f
+	}
+	if style.Fill != nil {
+		f := *style.Fill
+		if f.PatternType == "" {
+			f.PatternType = "solid"
+		}
+		if !validPatternTypes[f.PatternType] {
+			return 0, fmt.Errorf("无效的填充样式: %s", f.PatternType)
+		}
+		var err error
+		if f.FgColor, err = normalizeColor(f.FgColor); err != nil {
+			return 0, err
+		}
+		if f.BgColor, err = normalizeColor(f.BgColor); err != nil {
+			return 0, err
+		}
+		// 差异格式中纯色填充的颜色取自bgColor
+		if f.PatternType == "solid" && f.BgColor == "" {
+			f.BgColor = f.FgColor
+		}
+		dxf.Fill = &f
+	}
+	if style.Border != nil {
+		b, err := normalizeBorder(style.Border)
+		if err != nil {
+			return 0, err
+		}
+		dxf.Border = b
+	}
+	if style.NumFmt != "" {
+		dxf.NumFmtID = s.internNumFmt(style.NumFmt)
+		dxf.NumFmt = style.NumFmt
+	}
+	if style.Alignment != nil {
+		a := *style.Alignment
+		dxf.Alignment = &a
+	}
+
+	key := dxfKey(dxf)
+	for i, existing := range s.Dxfs {
+		if dxfKey(existing) == key {
+			return i, nil
+		}
+	}
+	s.Dxfs = append(s.Dxfs, dxf)
+	return len(s.Dxfs) - 1, nil
+}
+
+// internFont 查找或添加字体，返回字体ID
+func (s *Styles) internFont(font *Font) (int, error) {
+	if font == nil {
+		return 0, nil
+	}
+
+	f := *font
+	if f.Name == "" {
+		f.Name = defaultFontName
+	}
+	if f.Size <= 0 {
+		f.Size = defaultFontSize
+	}
+	color, err := normalizeColor(f.Color)
+	if err != nil {
+		return 0, err
+	}
+	f.Color = color
+
+	for i, existing := range s.Fonts {
+		if *existing == f {
+			return i, nil
+		}
+	}
+	s.Fonts = append(s.Fonts, &f)
+	return len(s.Fonts) - 1, nil
+}
+
+// internFill 查找或添加填充，返回填充ID
+func (s *Styles) internFill(fill *Fill) (int, error) {
+	if fill == nil {
+		return 0, nil
+	}
+
+	f := *fill
+	if f.PatternType == "" {
+		f.PatternType = "solid"
+	}
+	if !validPatternTypes[f.PatternType] {
+		return 0, fmt.Errorf("无效的填充样式: %s", f.PatternType)
+	}
+	var err error
+	if f.FgColor, err = normalizeColor(f.FgColor); err != nil {
+		return 0, err
+	}
+	if f.BgColor, err = normalizeColor(f.BgColor); err != nil {
+		return 0, err
+	}
+
+	for i, existing := range s.Fills {
+		if *existing == f {
+			return i, nil
+		}
+	}
+	s.Fills = append(s.Fills, &f)
+	return len(s.Fills) - 1, nil
+}
+
+// internBorder 查找或添加边框，返回边框ID
+func (s *Styles) internBorder(border *Border) (int, error) {
+	if border == nil {
+		return 0, nil
+	}
+
+	b, err := normalizeBorder(border)
+	if err != nil {
+		return 0, err
+	}
+
+	key := borderKey(b)
+	for i, existing := range s.Borders {
+		if borderKey(existing) == key {
+			return i, nil
+		}
+	}
+	s.Borders = append(s.Borders, b)
+	return len(s.Borders) - 1, nil
+}
+
+// normalizeBorder 复制边框并检查样式和颜色，未设置的边用空样式填充
+func normalizeBorder(border *Border) (*Border, error) {
+	b := &Border{}
+	sides := []**BorderStyle{&b.Left, &b.Right, &b.Top, &b.Bottom}
+	for i, side := range []*BorderStyle{border.Left, border.Right, border.Top, border.Bottom} {
+		bs := &BorderStyle{}
+		if side != nil {
+			if side.Style != "" && !validBorderStyles[side.Style] {
+				return nil, fmt.Errorf("无效的边框样式: %s", side.Style)
+			}
+			color, err := normalizeColor(side.Color)
+			if err != nil {
+				return nil, err
+			}
+			bs.Style = side.Style
+			bs.Color = color
+		}
+		*sides[i] = bs
+	}
+	return b, nil
+}
+
+// internNumFmt 查找或添加数字格式，返回数字格式ID
+func (s *Styles) internNumFmt(code string) int {
+	if code == "" {
+		return 0
+	}
+	if id, ok := standardNumberFormats[code]; ok {
+		return id
+	}
+
+	nextID := firstCustomNumFmtID
+	for _, nf := range s.NumberFormats {
+		if nf.Code == code && nf.ID >= firstCustomNumFmtID {
+			return nf.ID
+		}
+		if nf.ID >= nextID {
+			nextID = nf.ID + 1
+		}
+	}
+	s.AddNumberFormat(nextID, code)
+	return nextID
+}
+
+// normalizeColor 将RRGGBB或AARRGGBB格式的颜色统一为大写的AARRGGBB格式
+func normalizeColor(color string) (string, error) {
+	if color == "" {
+		return "", nil
+	}
+	color = strings.ToUpper(strings.TrimPrefix(color, "#"))
+	if len(color) != 6 && len(color) != 8 || strings.Trim(color, "0123456789ABCDEF") != "" {
+		return "", fmt.Errorf("无效的颜色: %s", color)
+	}
+	if len(color) == 6 {
+		color = "FF" + color
+	}
+	return color, nil
+}
+
+// borderKey 返回边框的比较键
+func borderKey(b *Border) string {
+	key := ""
+	for _, side := range []*BorderStyle{b.Left, b.Right, b.Top, b.Bottom} {
+		if side == nil {
+			key += "|"
+			continue
+		}
+		key += side.Style + ":" + side.Color + "|"
+	}
+	return key
+}
+
+// dxfKey 返回差异格式的比较键
+func dxfKey(dxf *Dxf) string {
+	key := ""
+	if dxf.Font != nil {
+		key += fmt.Sprintf("f:%v", *dxf.Font)
+	}
+	if dxf.Fill != nil {
+		key += fmt.Sprintf("/p:%v", *dxf.Fill)
+	}
+	if dxf.Border != nil {
+		key += "/b:" + borderKey(dxf.Border)
+	}
+	key += fmt.Sprintf("/n:%d", dxf.NumFmtID)
+	if dxf.Alignment != nil {
+		key += fmt.Sprintf("/a:%v", *dxf.Alignment)
+	}
+	return key
+}
+
+// xfKey 返回单元格XF的比较键
+func xfKey(xf *CellXf) string {
+	key := fmt.Sprintf("%d/%d/%d/%d", xf.FontId, xf.FillId, xf.BorderId, xf.NumFmtId)
+	if xf.XfId != 0 {
+		key += fmt.Sprintf("/s:%d", xf.XfId)
+	}
+	if xf.Alignment != nil {
+		key += fmt.Sprintf("/a:%s:%s:%t", xf.Alignment.Horizontal, xf.Alignment.Vertical, xf.Alignment.WrapText)
+	}
+	if xf.Protection != nil {
+		key += fmt.Sprintf("/p:%t:%t", xf.Protection.Locked, xf.Protection.Hidden)
+	}
+	return key
+}
@@ -14,6 +14,7 @@ type Styles struct {
 	CellStyles    []*CellStyleDef
 	CellStyleXfs  []*CellStyleXf
 	CellXfs       []*CellXf
+	Dxfs          []*Dxf // 差异格式，由条件格式引用
 }
 
 // NewStyles 创建一个新的样式集合
@@ -190,16 +191,19 @@ func (s *Styles) AddCellStyleXf(fontId, fillId, borderId, numFmtId int, alignmen
 
 // CellXf 表示单元格XF
 type CellXf struct {
+	XfId              int // 所属的单元格样式XF，0表示常规样式
 	FontId            int
 	FillId            int
 	BorderId          int
 	NumFmtId          int
 	Alignment         *Alignment
+	Protection        *Protection
 	ApplyFont         bool
 	ApplyFill         bool
 	ApplyBorder       bool
 	ApplyNumberFormat bool
 	ApplyAlignment    bool
+	ApplyProtection   bool
 }
 
 // AddCellXf 添加单元格XF
@@ -221,146 +225,80 @@ func (s *Styles) AddCellXf(fontId, fillId, borderId, numFmtId int, alignment *Al
 }
 
 // CreateStyle 创建一个完整的单元格样式并返回样式ID
+// 等价于使用SimpleStyle构造的样式调用NewStyle，相同的参数总是返回同一个样式ID
+// 与之前的版本一样不返回错误，无效的字体、填充、边框或对齐会被忽略；需要检查参数时使用NewStyle(SimpleStyle(...))
 func (s *Styles) CreateStyle(fontName string, fontSize float64, bold, italic, underline bool, fontColor string,
 	fillPattern, fillColor string, borderStyle string, borderColor string, numFmtCode string,
 	hAlign, vAlign string, wrapText bool) int {
 
-	// 添加字体
-	fontId := 0
+	style := SimpleStyle(fontName, fontSize, bold, italic, underline, fontColor,
+		fillPattern, fillColor, borderStyle, borderColor, numFmtCode, hAlign, vAlign, wrapText)
+	if styleIndex, err := s.NewStyle(style); err == nil {
+		return styleIndex
+	}
+
+	// 去掉无效的部分后重新注册
+	if _, err := s.internFont(style.Font); err != nil {
+		style.Font = nil
+	}
+	if _, err := s.internFill(style.Fill); err != nil {
+		style.Fill = nil
+	}
+	if _, err := s.internBorder(style.Border); err != nil {
+		style.Border = nil
+	}
+	if a := style.Alignment; a != nil && (a.Horizontal != "" && !validHorizontalAlignments[a.Horizontal] ||
+		a.Vertical != "" && !validVerticalAlignments[a.Vertical]) {
+		style.Alignment = nil
+	}
+	styleIndex, _ := s.NewStyle(style)
+	return styleIndex
+}
+
+// SimpleStyle 使用CreateStyle的参数构造一个Style，四周使用相同的边框
+// 数字格式代码按原样写出，人民币等格式不会映射为依赖区域设置的内置格式
+func SimpleStyle(fontName string, fontSize float64, bold, italic, underline bool, fontColor string,
+	fillPattern, fillColor string, borderStyle string, borderColor string, numFmtCode string,
+	hAlign, vAlign string, wrapText bool) *Style {
+
+	style := &Style{NumFmt: numFmtCode}
+
+	// 字体
 	if fontName != "" || fontSize > 0 || bold || italic || underline || fontColor != "" {
-		s.AddFont(fontName, fontSize, bold, italic, underline, fontColor)
-		fontId = len(s.Fonts) - 1
+		style.Font = &Font{
+			Name:      fontName,
+			Size:      fontSize,
+			Bold:      bold,
+			Italic:    italic,
+			Underline: underline,
+			Color:     fontColor,
+		}
 	}
 
-	// 添加填充
-	fillId := 0
+	// 填充
 	if fillPattern != "" {
-		s.AddFill(fillPattern, fillColor)
-		fillId = len(s.Fills) - 1
+		style.Fill = &Fill{PatternType: fillPattern, FgColor: fillColor}
 	}
 
-	// 添加边框
-	borderId := 0
+	// 四周使用相同的边框
 	if borderStyle != "" {
-		border := s.AddBorder()
-		border.Left.Style = borderStyle
-		border.Left.Color = borderColor
-		border.Right.Style = borderStyle
-		border.Right.Color = borderColor
-		border.Top.Style = borderStyle
-		border.Top.Color = borderColor
-		border.Bottom.Style = borderStyle
-		border.Bottom.Color = borderColor
-		borderId = len(s.Borders) - 1
-	}
-
-	// 添加数字格式 - 改进
-	numFmtId := 0
-	if numFmtCode != "" {
-		// 处理货币格式中的引号问题
-		if strings.Contains(numFmtCode, "\"¥\"") {
-			// 人民币格式使用编号7
-			numFmtId = 7
-		} else if numFmtCode == "0.00E+00" || numFmtCode == "##0.0E+0" {
-			// 使用Excel内置的科学计数格式ID
-			numFmtId = 11
-		} else {
-			// 检查是否是其他内置格式
-			builtinId := getBuiltinNumberFormatId(numFmtCode)
-			if builtinId > 0 {
-				numFmtId = builtinId
-			} else {
-				// 如果不是内置格式，创建自定义格式
-				// Excel自定义格式从164开始
-				numFmtId = 164 + len(s.NumberFormats)
-				s.AddNumberFormat(numFmtId, numFmtCode)
-			}
+		style.Border = &Border{
+			Left:   &BorderStyle{Style: borderStyle, Color: borderColor},
+			Right:  &BorderStyle{Style: borderStyle, Color: borderColor},
+			Top:    &BorderStyle{Style: borderStyle, Color: borderColor},
+			Bottom: &BorderStyle{Style: borderStyle, Color: borderColor},
 		}
 	}
 
-	var alignment *Alignment
+	// 对齐
 	if hAlign != "" || vAlign != "" || wrapText {
-		alignment = &Alignment{
+		style.Alignment = &Alignment{
 			Horizontal: hAlign,
 			Vertical:   vAlign,
 			WrapText:   wrapText,
 		}
 	}
-
-	// 创建单元格XF并返回其索引
-	styleIndex := s.AddCellXf(fontId, fillId, borderId, numFmtId, alignment)
-	return styleIndex
-}
-
-// 获取内置数字格式ID
-func getBuiltinNumberFormatId(format string) int {
-	builtinFormats := map[string]int{
-		// 常规格式
-		"General": 0,
-
-		// 数值格式
-		"0":        1, // 整数
-		"0.00":     2, // 小数
-		"#,##0":    3, // 千位分隔的整数
-		"#,##0.00": 4, // 千位分隔的小数
-
-		// 货币格式
-		"¥#,##0;¥\\-#,##0":        7,  // 人民币格式
-		"¥#,##0;[Red]¥\\-#,##0":   8,  // 人民币格式(负数为红色)
-		"\"¥\"#,##0.00":           7,  // 人民币格式带小数 - 这个格式有问题
-		"$#,##0.00":               44, // 美元格式
-		"$#,##0.00_);($#,##0.00)": 43, // 会计专用美元格式
-		"_(\"$\"* #,##0.00_)":     42, // 会计专用美元格式(负数带括号)
-		"_-* #,##0.00_-":          4,  // 会计专用格式
-
-		// 百分比格式
-		"0%":    9,  // 整数百分比
-		"0.00%": 10, // 小数百分比
-
-		// 科学计数格式
-		"0.00E+00": 11, // 科学计数 - 确保正确提供此格式
-		"##0.0E+0": 11, // 科学计数 - 另一种写法
-
-		// 分数格式
-		"# ?/?":   12, // 分数 (例如:1/4)
-		"# ??/??": 13, // 分数 (例如:3/16)
-
-		// 日期格式 - 增加更多常见日期格式
-		"mm-dd-yy":                       14, // 月-日-年
-		"d-mmm-yy":                       15, // 日-月缩写-年
-		"d-mmm":                          16, // 日-月缩写
-		"mmm-yy":                         17, // 月缩写-年
-		"mm/dd/yy":                       30, // 月/日/年
-		"mm/dd/yyyy":                     22, // 月/日/完整年份
-		"yyyy/mm/dd":                     20, // ISO 8601 格式
-		"dd/mm/yyyy":                     21, // 欧洲日期格式
-		"yyyy-mm-dd":                     22, // ISO 日期格式
-		"yyyy-mm-dd;@":                   22, // ISO 日期格式(带文本)
-		"yyyy/mm/dd;@":                   22, // ISO 日期格式(带文本)
-		"[$-804]yyyy\"年\"mm\"月\"dd\"日\"": 31, // 中文日期格式
-
-		// 时间格式
-		"h:mm AM/PM":    18, // 12小时制时间
-		"h:mm:ss AM/PM": 19, // 12小时制时间(带秒)
-		"h:mm":          20, // 24小时制时间
-		"h:mm:ss":       21, // 24小时制时间(带秒)
-		"m/d/yy h:mm":   22, // 日期和时间
-		"mm:ss":         45, // 分:秒
-		"[h]:mm:ss":     46, // 超过24小时的时间
-		"mmss.0":        47, // 分秒.毫秒
-
-		// 其他格式
-		"#,##0 ;(#,##0)":           37, // 带括号的负数
-		"#,##0 ;[Red](#,##0)":      38, // 红色负数
-		"#,##0.00;(#,##0.00)":      39, // 带括号的负小数
-		"#,##0.00;[Red](#,##0.00)": 40, // 红色负小数
-		"@":                        49, // 文本
-	}
-
-	if id, ok := builtinFormats[format]; ok {
-		return id
-	}
-	return 0
+	return style
 }
 
 // ToXML 将样式转换为XML
@@ -513,8 +451,8 @@ func (s *Styles) ToXML() string {
 	for _, xf := range s.CellXfs {
 		xml += "    <xf"
 
-		// 引用已经存在的样式ID
-		xml += fmt.Sprintf(" xfId=\"0\"")
+		// 引用所属的单元格样式
+		xml += fmt.Sprintf(" xfId=\"%d\"", xf.XfId)
 
 		// 设置字体
 		if xf.FontId > 0 {
@@ -549,6 +487,11 @@ func (s *Styles) ToXML() string {
 			xml += " applyAlignment=\"1\""
 		}
 
+		// 设置保护
+		if xf.Protection != nil {
+			xml += " applyProtection=\"1\""
+		}
+
 		xml += ">\n"
 
 		// 添加对齐信息
@@ -565,6 +508,11 @@ func (s *Styles) ToXML() string {
 			}
 			xml += " />\n"
 		}
+
+		// 添加保护信息
+		if xf.Protection != nil {
+			xml += fmt.Sprintf("      <protection locked=\"%d\" hidden=\"%d\" />\n", boolToInt(xf.Protection.Locked), boolToInt(xf.Protection.Hidden))
+		}
 		xml += "    </xf>\n"
 	}
 	xml += "  </cellXfs>\n"
@@ -580,10 +528,89 @@ func (s *Styles) ToXML() string {
 	}
 	xml += "  </cellStyles>\n"
 
+	// 差异格式
+	if len(s.Dxfs) > 0 {
+		xml += fmt.Sprintf("  <dxfs count=\"%d\">\n", len(s.Dxfs))
+		for _, dxf := range s.Dxfs {
+			xml += dxf.toXML()
+		}
+		xml += "  </dxfs>\n"
+	}
+
 	xml += "</styleSheet>"
 	return xml
 }
 
+// toXML 将差异格式转换为XML，子元素顺序为font、numFmt、fill、alignment、border
+func (dxf *Dxf) toXML() string {
+	xml := "    <dxf>\n"
+	if font := dxf.Font; font != nil {
+		xml += "      <font>"
+		if font.Bold {
+			xml += "<b />"
+		}
+		if font.Italic {
+			xml += "<i />"
+		}
+		if font.Underline {
+			xml += "<u />"
+		}
+		if font.Size > 0 {
+			xml += fmt.Sprintf("<sz val=\"%g\" />", font.Size)
+		}
+		if font.Color != "" {
+			xml += fmt.Sprintf("<color rgb=\"%s\" />", font.Color)
+		}
+		if font.Name != "" {
+			xml += fmt.Sprintf("<name val=\"%s\" />", escapeXML(font.Name))
+		}
+		xml += "</font>\n"
+	}
+	if dxf.NumFmt != "" {
+		xml += fmt.Sprintf("      <numFmt numFmtId=\"%d\" formatCode=\"%s\" />\n", dxf.NumFmtID, escapeXML(dxf.NumFmt))
+	}
+	if fill := dxf.Fill; fill != nil {
+		xml += fmt.Sprintf("      <fill><patternFill patternType=\"%s\">", fill.PatternType)
+		if fill.FgColor != "" {
+			xml += fmt.Sprintf("<fgColor rgb=\"%s\" />", fill.FgColor)
+		}
+		if fill.BgColor != "" {
+			xml += fmt.Sprintf("<bgColor rgb=\"%s\" />", fill.BgColor)
+		}
+		xml += "</patternFill></fill>\n"
+	}
+	if a := dxf.Alignment; a != nil {
+		xml += "      <alignment"
+		if a.Horizontal != "" {
+			xml += fmt.Sprintf(" horizontal=\"%s\"", a.Horizontal)
+		}
+		if a.Vertical != "" {
+			xml += fmt.Sprintf(" vertical=\"%s\"", a.Vertical)
+		}
+		if a.WrapText {
+			xml += " wrapText=\"1\""
+		}
+		xml += " />\n"
+	}
+	if b := dxf.Border; b != nil {
+		xml += "      <border>"
+		names := []string{"left", "right", "top", "bottom"}
+		for i, side := range []*BorderStyle{b.Left, b.Right, b.Top, b.Bottom} {
+			if side.Style == "" {
+				continue
+			}
+			xml += fmt.Sprintf("<%s style=\"%s\">", names[i], side.Style)
+			if side.Color != "" {
+				xml += fmt.Sprintf("<color rgb=\"%s\" />", side.Color)
+			}
+			xml += fmt.Sprintf("</%s>", names[i])
+		}
+		xml += "</border>\n"
+	}
+	xml += "    </dxf>\n"
+	return xml
+}
+
 // CreateBorderWithStyle 创建一个边框样式并返回边框ID
 func (s *Styles) CreateBorderWithStyle(style, color string) int {
 	border := s.AddBorder()
@@ -598,7 +625,26 @@ func (s *Styles) CreateBorderWithStyle(style, color string) int {
 	return len(s.Borders) - 1
 }
 
-// AddDirectStyleID 直接添加一个CellStyle并返回样式ID
+// AddDirectStyleID 将CellStyle注册为单元格XF并返回样式ID，相同的CellStyle总是返回同一个样式ID
 func (s *Styles) AddDirectStyleID(style *CellStyle) int {
-	return s.AddCellXf(style.FontID, style.FillID, style.BorderID, style.NumberFormatID, style.Alignment)
+	if style == nil {
+		return 0
+	}
+	var alignment *Alignment
+	if style.Alignment != nil {
+		a := *style.Alignment
+		alignment = &a
+	}
+	return s.internXf(&CellXf{
+		FontId:            style.FontID,
+		FillId:            style.FillID,
+		BorderId:          style.BorderID,
+		NumFmtId:          style.NumberFormatID,
+		Alignment:         alignment,
+		ApplyFont:         style.FontID > 0,
+		ApplyFill:         style.FillID > 0,
+		ApplyBorder:       style.BorderID > 0,
+		ApplyNumberFormat: style.NumberFormatID > 0,
+		ApplyAlignment:    alignment != nil,
+	})
 }
@@ -0,0 +1,417 @@
+package workbook
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 表格部件的内容类型和关系类型
+const (
+	contentTypeTable = "application/vnd.openxmlformats-officedocument.spreadsheetml.table+xml"
+	relTypeTable     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/table"
+)
+
+// 常用的内置表格样式，完整的名称为TableStyleLight1~21、TableStyleMedium1~28和TableStyleDark1~11
+const (
+	TableStyleLight1   = "TableStyleLight1"
+	TableStyleLight9   = "TableStyleLight9"
+	TableStyleMedium2  = "TableStyleMedium2"
+	TableStyleMedium9  = "TableStyleMedium9"
+	TableStyleMedium15 = "TableStyleMedium15"
+	TableStyleDark1    = "TableStyleDark1"
+	TableStyleNone     = "None" // 不使用表格样式
+)
+
+// 汇总行的汇总函数
+const (
+	TotalsFunctionSum       = "sum"
+	TotalsFunctionAverage   = "average"
+	TotalsFunctionCount     = "count" // 非空单元格的数量
+	TotalsFunctionCountNums = "countNums"
+	TotalsFunctionMax       = "max"
+	TotalsFunctionMin       = "min"
+	TotalsFunctionStdDev    = "stdDev"
+	TotalsFunctionVar       = "var"
+	TotalsFunctionCustom    = "custom" // 使用TotalsFormula中的公式
+)
+
+// totalsSubtotalCodes 汇总函数对应的SUBTOTAL功能号，忽略筛选隐藏的行
+var totalsSubtotalCodes = map[string]int{
+	TotalsFunctionAverage:   101,
+	TotalsFunctionCountNums: 102,
+	TotalsFunctionCount:     103,
+	TotalsFunctionMax:       104,
+	TotalsFunctionMin:       105,
+	TotalsFunctionStdDev:    107,
+	TotalsFunctionSum:       109,
+	TotalsFunctionVar:       110,
+}
+
+var tableStylePattern = regexp.MustCompile(`^TableStyle(Light([1-9]|1[0-9]|2[01])|Medium([1-9]|1[0-9]|2[0-8])|Dark([1-9]|1[01]))$`)
+
+// TableColumn 表示表格中的一列
+type TableColumn struct {
+	Name           string // 列名称，为空时使用标题行中的文本
+	TotalsFunction string // 汇总行使用的汇总函数
+	TotalsLabel    string // 汇总行显示的文字，与TotalsFunction二选一
+	TotalsFormula  string // TotalsFunction为custom时的汇总公式
+}
+
+// TableSpec 表示表格的设置
+type TableSpec struct {
+	Name              string // 表格名称，在工作簿中唯一，为空时自动生成Table1, Table2, ...
+	Style             string // 表格样式，为空时使用TableStyleMedium2
+	Columns           []*TableColumn
+	HeaderRow         bool            // 区域的第一行为标题行
+	TotalsRow         bool            // 区域的最后一行为汇总行
+	AutoFilter        bool            // 在标题行显示筛选按钮
+	Filters           []*FilterColumn // 筛选条件，列序号相对于表格的第一列
+	ShowFirstColumn   bool            // 突出显示第一列
+	ShowLastColumn    bool            // 突出显示最后一列
+	ShowRowStripes    bool            // 镶边行
+	ShowColumnStripes bool            // 镶边列
+}
+
+// Table 表示工作表中的表格
+type Table struct {
+	Ref  string // 表格区域，包含标题行和汇总行
+	Spec *TableSpec
+}
+
+// NewTableSpec 创建表格设置，默认带标题行和筛选按钮，使用TableStyleMedium2和镶边行
+func NewTableSpec(name string) *TableSpec {
+	return &TableSpec{
+		Name:           name,
+		Style:          TableStyleMedium2,
+		HeaderRow:      true,
+		AutoFilter:     true,
+		ShowRowStripes: true,
+	}
+}
+
+// SetStyle 设置表格样式及镶边行、镶边列
+func (spec *TableSpec) SetStyle(style string, rowStripes, columnStripes bool) *TableSpec {
+	spec.Style = style
+	spec.ShowRowStripes = rowStripes
+	spec.ShowColumnStripes = columnStripes
+	return spec
+}
+
+// SetColumn 设置第index列（从0开始）的名称和汇总方式
+func (spec *TableSpec) SetColumn(index int, column *TableColumn) *TableSpec {
+	for len(spec.Columns) <= index {
+		spec.Columns = append(spec.Columns, &TableColumn{})
+	}
+	spec.Columns[index] = column
+	return spec
+}
+
+// SetTotal 为第index列（从0开始）设置汇总函数并显示汇总行
+func (spec *TableSpec) SetTotal(index int, function string) *TableSpec {
+	spec.TotalsRow = true
+	column := &TableColumn{TotalsFunction: function}
+	if index < len(spec.Columns) && spec.Columns[index] != nil {
+		column = spec.Columns[index]
+		column.TotalsFunction = function
+	}
+	return spec.SetColumn(index, column)
+}
+
+// SetTotalLabel 为第index列（从0开始）设置汇总行显示的文字并显示汇总行
+func (spec *TableSpec) SetTotalLabel(index int, label string) *TableSpec {
+	spec.TotalsRow = true
+	column := &TableColumn{TotalsLabel: label}
+	if index < len(spec.Columns) && spec.Columns[index] != nil {
+		column = spec.Columns[index]
+		column.TotalsLabel = label
+	}
+	return spec.SetColumn(index, column)
+}
+
+// SetFilter 设置表格的筛选条件
+func (spec *TableSpec) SetFilter(filters ...*FilterColumn) *TableSpec {
+	spec.AutoFilter = true
+	spec.Filters = filters
+	return spec
+}
+
+// check 检查表格设置是否有效，rows和cols为表格区域的行数和列数
+func (spec *TableSpec) check(rows, cols int) error {
+	if !isValidName(spec.Name) {
+		return fmt.Errorf("无效的表格名称: %s", spec.Name)
+	}
+	if spec.Style != "" && spec.Style != TableStyleNone && !tableStylePattern.MatchString(spec.Style) {
+		return fmt.Errorf("无效的表格样式: %s", spec.Style)
+	}
+	want := 1
+	if spec.HeaderRow {
+		want++
+	}
+	if spec.TotalsRow {
+		want++
+	}
+	if rows < want {
+		return fmt.Errorf("表格区域至少需要%d行", want)
+	}
+	if len(spec.Columns) > cols {
+		return fmt.Errorf("表格设置了%d列，超出表格区域的%d列", len(spec.Columns), cols)
+	}
+	for i, column := range spec.Columns {
+		if column == nil {
+			continue
+		}
+		if column.TotalsFunction != "" && column.TotalsFunction != TotalsFunctionCustom && totalsSubtotalCodes[column.TotalsFunction] == 0 {
+			return fmt.Errorf("第%d列的汇总函数无效: %s", i+1, column.TotalsFunction)
+		}
+		if column.TotalsFunction == TotalsFunctionCustom && column.TotalsFormula == "" {
+			return fmt.Errorf("第%d列的自定义汇总缺少公式", i+1)
+		}
+		if column.TotalsFunction != "" && column.TotalsLabel != "" {
+			return fmt.Errorf("第%d列不能同时设置汇总函数和汇总文字", i+1)
+		}
+	}
+	if len(spec.Filters) > 0 && (!spec.HeaderRow || !spec.AutoFilter) {
+		return fmt.Errorf("表格需要标题行和筛选按钮才能设置筛选条件")
+	}
+	for _, fc := range spec.Filters {
+		if fc == nil {
+			return fmt.Errorf("筛选条件不能为空")
+		}
+		if err := fc.check(cols); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTable 将区域转换为表格，表格的名称在工作簿中唯一
+// 有标题行时使用标题行中的文本作为列名称，空白或重复的列名称自动补全并写回标题行
+// 有汇总行时在汇总行写入汇总文字和SUBTOTAL公式
+// 例如: ws.AddTable("A1:G7", NewTableSpec("Sales").SetTotalLabel(0, "合计").SetTotal(3, TotalsFunctionSum))
+func (ws *Worksheet) AddTable(ref string, spec *TableSpec) (*Table, error) {
+	if ws.stream != nil {
+		return nil, fmt.Errorf("流式写入的工作表 %s 不支持表格", ws.Name)
+	}
+	if ws.wb == nil {
+		return nil, fmt.Errorf("工作表 %s 不属于任何工作簿，无法添加表格", ws.Name)
+	}
+	if spec == nil {
+		spec = NewTableSpec("")
+	}
+	area, ok := parseRangeRef(ref)
+	if !ok || !area.IsRange || strings.ContainsAny(ref, "!$") || area.From.Row < 0 || area.From.Col < 0 {
+		return nil, fmt.Errorf("无效的表格区域: %s", ref)
+	}
+	if spec.Name == "" {
+		spec.Name = ws.wb.nextTableName()
+	}
+	if ws.wb.GetTable(spec.Name) != nil {
+		return nil, fmt.Errorf("表格名称 %s 已存在", spec.Name)
+	}
+	for _, dn := range ws.wb.DefinedNames {
+		if strings.EqualFold(dn.Name, spec.Name) {
+			return nil, fmt.Errorf("表格名称 %s 与定义的名称重复", spec.Name)
+		}
+	}
+
+	row1, col1, row2, col2 := area.bounds()
+	if err := spec.check(row2-row1+1, col2-col1+1); err != nil {
+		return nil, err
+	}
+	for _, table := range ws.Tables {
+		if overlaps(area, table.Ref) {
+			return nil, fmt.Errorf("表格区域 %s 与表格 %s 重叠", ref, table.Spec.Name)
+		}
+	}
+	if ws.AutoFilter != nil && overlaps(area, ws.AutoFilter.Ref) {
+		return nil, fmt.Errorf("表格区域 %s 与自动筛选区域 %s 重叠", ref, ws.AutoFilter.Ref)
+	}
+	for _, mc := range ws.MergedCells {
+		if overlaps(area, mc.TopLeftRef+":"+mc.BottomRightRef) {
+			return nil, fmt.Errorf("表格区域 %s 不能包含合并单元格 %s:%s", ref, mc.TopLeftRef, mc.BottomRightRef)
+		}
+	}
+
+	table := &Table{Ref: area.areaString(), Spec: spec}
+	table.resolveColumns(ws)
+	table.writeTotals(ws)
+	if spec.HeaderRow && spec.AutoFilter {
+		ws.applyFilter(row1+1, table.lastDataRow(), col1, spec.Filters)
+	}
+	ws.Tables = append(ws.Tables, table)
+	return table, nil
+}
+
+// GetTable 根据名称获取表格，名称不区分大小写，不存在时返回nil
+func (wb *Workbook) GetTable(name string) *Table {
+	for _, ws := range wb.Worksheets {
+		for _, table := range ws.Tables {
+			if strings.EqualFold(table.Spec.Name, name) {
+				return table
+			}
+		}
+	}
+	return nil
+}
+
+// nextTableName 返回未被使用的表格名称Table1, Table2, ...
+func (wb *Workbook) nextTableName() string {
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("Table%d", i)
+		if wb.GetTable(name) == nil {
+			return name
+		}
+	}
+}
+
+// bounds 返回表格区域的行列范围
+func (t *Table) bounds() (row1, col1, row2, col2 int) {
+	area, _ := parseRangeRef(t.Ref)
+	return area.bounds()
+}
+
+// lastDataRow 返回最后一个数据行的索引（从0开始）
+func (t *Table) lastDataRow() int {
+	_, _, row2, _ := t.bounds()
+	if t.Spec.TotalsRow {
+		row2--
+	}
+	return row2
+}
+
+// resolveColumns 确定每一列的名称：优先使用设置的名称，其次是标题行中的文本，都为空时使用Column1, Column2, ...
+// 列名称不区分大小写地唯一，有标题行时将列名称写回标题行
+func (t *Table) resolveColumns(ws *Worksheet) {
+	row1, col1, _, col2 := t.bounds()
+	columns := make([]*TableColumn, col2-col1+1)
+	copy(columns, t.Spec.Columns)
+
+	used := make(map[string]bool)
+	for i := range columns {
+		if columns[i] == nil {
+			columns[i] = &TableColumn{}
+		}
+		column := columns[i]
+		name := column.Name
+		if name == "" && t.Spec.HeaderRow {
+			if cell := ws.GetCell(row1, col1+i); cell != nil {
+				name = strings.TrimSpace(toText(cellScalar(cell)))
+			}
+		}
+		if name == "" {
+			name = fmt.Sprintf("Column%d", i+1)
+		}
+		unique := name
+		for n := 2; used[strings.ToLower(unique)]; n++ {
+			unique = fmt.Sprintf("%s%d", name, n)
+		}
+		used[strings.ToLower(unique)] = true
+		column.Name = unique
+
+		// 标题行的单元格必须是与列名称相同的文本，保留原有的样式
+		if t.Spec.HeaderRow {
+			if cell := ws.GetCell(row1, col1+i); cell != nil {
+				cell.Formula = ""
+				cell.setValue(unique)
+			} else {
+				ws.SetCellValue(row1, col1+i, unique)
+			}
+		}
+	}
+	t.Spec.Columns = columns
+}
+
+// writeTotals 在汇总行写入汇总文字和汇总公式
+func (t *Table) writeTotals(ws *Worksheet) {
+	if !t.Spec.TotalsRow {
+		return
+	}
+	row1, col1, row2, _ := t.bounds()
+	first := row1
+	if t.Spec.HeaderRow {
+		first++
+	}
+	for i, column := range t.Spec.Columns {
+		ref := CellRef(row2, col1+i)
+		switch {
+		case column.TotalsLabel != "":
+			ws.AddCell(ref, column.TotalsLabel)
+		case column.TotalsFunction == TotalsFunctionCustom:
+			ws.SetCellFormula(ref, strings.TrimPrefix(column.TotalsFormula, "="))
+		case column.TotalsFunction != "":
+			data := CellRef(first, col1+i) + ":" + CellRef(row2-1, col1+i)
+			ws.SetCellFormula(ref, fmt.Sprintf("SUBTOTAL(%d,%s)", totalsSubtotalCodes[column.TotalsFunction], data))
+		}
+	}
+}
+
+// tablePartsXML 生成tableParts元素
+func (ws *Worksheet) tablePartsXML(state *saveState) string {
+	if len(ws.Tables) == 0 {
+		return ""
+	}
+	xml := fmt.Sprintf("  <tableParts count=\"%d\">\n", len(ws.Tables))
+	for _, table := range ws.Tables {
+		xml += "    <tablePart r:id=\"" + state.tables[table] + "\" />\n"
+	}
+	xml += "  </tableParts>\n"
+	return xml
+}
+
+// ToXML 生成表格部件，id为表格在工作簿中的编号
+func (t *Table) ToXML(id int) string {
+	spec := t.Spec
+	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
+	xml += fmt.Sprintf("<table xmlns=\"http://schemas.openxmlformats.org/spreadsheetml/2006/main\" id=\"%d\" name=\"%s\" displayName=\"%s\" ref=\"%s\"",
+		id, escapeXML(spec.Name), escapeXML(spec.Name), t.Ref)
+	if !spec.HeaderRow {
+		xml += " headerRowCount=\"0\""
+	}
+	if spec.TotalsRow {
+		xml += " totalsRowCount=\"1\""
+	} else {
+		xml += " totalsRowShown=\"0\""
+	}
+	xml += ">\n"
+
+	if spec.HeaderRow && spec.AutoFilter {
+		row1, col1, _, col2 := t.bounds()
+		filter := &AutoFilter{
+			Ref:     CellRef(row1, col1) + ":" + CellRef(t.lastDataRow(), col2),
+			Columns: spec.Filters,
+		}
+		xml += "  " + filter.toXML() + "\n"
+	}
+
+	xml += fmt.Sprintf("  <tableColumns count=\"%d\">\n", len(spec.Columns))
+	for i, column := range spec.Columns {
+		xml += fmt.Sprintf("    <tableColumn id=\"%d\" name=\"%s\"", i+1, escapeXML(column.Name))
+		if column.TotalsLabel != "" {
+			xml += " totalsRowLabel=\"" + escapeXML(column.TotalsLabel) + "\""
+		}
+		if column.TotalsFunction != "" {
+			xml += " totalsRowFunction=\"" + column.TotalsFunction + "\""
+		}
+		if column.TotalsFunction == TotalsFunctionCustom {
+			xml += "><totalsRowFormula>" + escapeXML(strings.TrimPrefix(column.TotalsFormula, "=")) + "</totalsRowFormula></tableColumn>\n"
+		} else {
+			xml += " />\n"
+		}
+	}
+	xml += "  </tableColumns>\n"
+
+	xml += "  <tableStyleInfo"
+	style := spec.Style
+	if style == "" {
+		style = TableStyleMedium2
+	}
+	if style != TableStyleNone {
+		xml += " name=\"" + style + "\""
+	}
+	xml += fmt.Sprintf(" showFirstColumn=\"%d\" showLastColumn=\"%d\" showRowStripes=\"%d\" showColumnStripes=\"%d\" />\n",
+		boolToInt(spec.ShowFirstColumn), boolToInt(spec.ShowLastColumn), boolToInt(spec.ShowRowStripes), boolToInt(spec.ShowColumnStripes))
+
+	xml += "</table>"
+	return xml
+}
@@ -128,3 +128,21 @@ func GetTimeFromExcelSerialDate(serialDate float64) time.Time {
 	duration := time.Duration(daysPassed * 24 * float64(time.Hour))
 	return baseDate.Add(duration)
 }
+
+// boolToInt 将布尔值转换为整数
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// escapeXML 转义XML属性和文本中的特殊字符
+func escapeXML(s string) string {
+	s = strings.ReplaceAll(s, "&", "&amp;")
+	s = strings.ReplaceAll(s, "<", "&lt;")
+	s = strings.ReplaceAll(s, ">", "&gt;")
+	s = strings.ReplaceAll(s, "\"", "&quot;")
+	s = strings.ReplaceAll(s, "'", "&apos;")
+	return s
+}
@@ -0,0 +1,327 @@
+package workbook
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/landaiqing/go-dockit/opc"
+)
+
+// ValidationError 表示一个校验错误
+type ValidationError = opc.ValidationError
+
+// ValidationErrors 表示一组校验错误
+type ValidationErrors = opc.ValidationErrors
+
+// 校验错误的类别，可以使用errors.Is判断
+var (
+	ErrInvalidValue     = opc.ErrInvalidValue
+	ErrInvalidColor     = opc.ErrInvalidColor
+	ErrDuplicateID      = opc.ErrDuplicateID
+	ErrMissingReference = opc.ErrMissingReference
+	ErrInvalidName      = opc.ErrInvalidName
+)
+
+// Excel的行列上限
+const (
+	MaxRows    = 1048576
+	MaxColumns = 16384
+)
+
+// 工作表名称的最大长度
+const maxSheetNameLength = 31
+
+// 对齐方式允许的取值
+var (
+	validHorizontalAlignments = map[string]bool{
+		"general": true, "left": true, "center": true, "right": true, "fill": true,
+		"justify": true, "centerContinuous": true, "distributed": true,
+	}
+	validVerticalAlignments = map[string]bool{
+		"top": true, "center": true, "bottom": true, "justify": true, "distributed": true,
+	}
+)
+
+// SetStrict 设置严格模式，严格模式下保存前会先校验工作簿，校验失败时拒绝写出
+func (wb *Workbook) SetStrict(strict bool) *Workbook {
+	wb.Strict = strict
+	return wb
+}
+
+// Validate 校验工作簿中会导致Excel无法打开的错误
+// 没有错误时返回nil，否则返回ValidationErrors，其中每个错误都带有出错位置
+func (wb *Workbook) Validate() error {
+	var errs ValidationErrors
+
+	validateStyles(&errs, wb.Styles)
+
+	names := make(map[string]bool)
+	for i, ws := range wb.Worksheets {
+		path := fmt.Sprintf("sheets[%d]", i)
+
+		if err := ValidateSheetName(ws.Name); err != nil {
+			errs.Add(path+"/name", ws.Name, err)
+		}
+		key := strings.ToLower(ws.Name)
+		if names[key] {
+			errs.Add(path+"/name", ws.Name, ErrDuplicateID)
+		}
+		names[key] = true
+
+		validateWorksheet(&errs, path, ws, wb.Styles)
+	}
+
+	definedNames := make(map[string]bool)
+	for i, dn := range wb.DefinedNames {
+		path := fmt.Sprintf("definedNames[%d]", i)
+		if err := dn.check(wb); err != nil {
+			errs.Add(path, dn.Name, fmt.Errorf("%w: %v", ErrInvalidValue, err))
+		}
+		key := strings.ToLower(dn.Scope + "!" + dn.Name)
+		if definedNames[key] {
+			errs.Add(path+"/name", dn.Name, ErrDuplicateID)
+		}
+		definedNames[key] = true
+	}
+
+	return errs.Err()
+}
+
+// ValidateSheetName 检查工作表名称是否符合Excel的规则
+// 名称不能为空，不能超过31个字符，不能包含[]:*?/\，也不能以单引号开头或结尾
+func ValidateSheetName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > maxSheetNameLength ||
+		strings.ContainsAny(name, "[]:*?/\\") ||
+		strings.HasPrefix(name, "'") || strings.HasSuffix(name, "'") {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+// validateWorksheet 校验工作表中的单元格、合并区域和样式
+func validateWorksheet(errs *ValidationErrors, path string, ws *Worksheet, styles *Styles) {
+	for _, ref := range ws.invalidRefs {
+		errs.Add(path+"/cells/"+ref, ref, ErrInvalidValue)
+	}
+
+	for _, row := range ws.Rows {
+		for i, cell := range row.Cells {
+			cellPath := path + "/cells/" + CellRef(row.Index-1, cell.Col)
+			if cell.Col < 0 || cell.Col >= MaxColumns || i > 0 && cell.Col <= row.Cells[i-1].Col {
+				errs.Add(cellPath+"/col", fmt.Sprintf("%d", cell.Col), ErrInvalidValue)
+			}
+			if cell.StyleID < 0 || cell.StyleID >= len(styles.CellXfs) {
+				errs.Add(cellPath+"/styleId", fmt.Sprintf("%d", cell.StyleID), ErrMissingReference)
+			}
+		}
+	}
+
+	for i, mc := range ws.MergedCells {
+		mergePath := fmt.Sprintf("%s/mergeCells[%d]", path, i)
+		if !isValidCellRef(mc.TopLeftRef) {
+			errs.Add(mergePath+"/topLeft", mc.TopLeftRef, ErrInvalidValue)
+		}
+		if !isValidCellRef(mc.BottomRightRef) {
+			errs.Add(mergePath+"/bottomRight", mc.BottomRightRef, ErrInvalidValue)
+		}
+	}
+
+	for i, dv := range ws.DataValidations {
+		if err := dv.check(); err != nil {
+			errs.Add(fmt.Sprintf("%s/dataValidations[%d]", path, i), dv.Sqref, fmt.Errorf("%w: %v", ErrInvalidValue, err))
+		}
+	}
+
+	for i, cf := range ws.ConditionalFormats {
+		cfPath := fmt.Sprintf("%s/conditionalFormatting[%d]", path, i)
+		for j, rule := range cf.Rules {
+			rulePath := fmt.Sprintf("%s/rules[%d]", cfPath, j)
+			if err := rule.check(); err != nil {
+				errs.Add(rulePath, rule.Type, fmt.Errorf("%w: %v", ErrInvalidValue, err))
+			}
+			if rule.DxfID >= len(styles.Dxfs) {
+				errs.Add(rulePath+"/dxfId", fmt.Sprintf("%d", rule.DxfID), ErrMissingReference)
+			}
+		}
+	}
+
+	if ws.View != nil {
+		if err := ws.View.check(); err != nil {
+			errs.Add(path+"/sheetView", ws.Name, fmt.Errorf("%w: %v", ErrInvalidValue, err))
+		}
+	}
+	if ws.PageSetup != nil {
+		if err := ws.PageSetup.check(); err != nil {
+			errs.Add(path+"/pageSetup", ws.Name, fmt.Errorf("%w: %v", ErrInvalidValue, err))
+		}
+	}
+	if ws.TabColor != "" {
+		if _, err := normalizeColor(ws.TabColor); err != nil {
+			errs.Add(path+"/tabColor", ws.TabColor, ErrInvalidValue)
+		}
+	}
+
+	if af := ws.AutoFilter; af != nil {
+		if area, ok := parseRangeRef(af.Ref); !ok || !area.IsRange {
+			errs.Add(path+"/autoFilter", af.Ref, ErrInvalidValue)
+		} else {
+			_, col1, _, col2 := area.bounds()
+			for i, fc := range af.Columns {
+				if err := fc.check(col2 - col1 + 1); err != nil {
+					errs.Add(fmt.Sprintf("%s/autoFilter/filterColumn[%d]", path, i), fmt.Sprintf("%d", fc.Col), fmt.Errorf("%w: %v", ErrInvalidValue, err))
+				}
+			}
+		}
+	}
+
+	for i, table := range ws.Tables {
+		tablePath := fmt.Sprintf("%s/tables[%d]", path, i)
+		area, ok := parseRangeRef(table.Ref)
+		if !ok || !area.IsRange {
+			errs.Add(tablePath+"/ref", table.Ref, ErrInvalidValue)
+			continue
+		}
+		row1, col1, row2, col2 := area.bounds()
+		if err := table.Spec.check(row2-row1+1, col2-col1+1); err != nil {
+			errs.Add(tablePath, table.Spec.Name, fmt.Errorf("%w: %v", ErrInvalidValue, err))
+		}
+	}
+
+	for i, comment := range ws.Comments {
+		if err := comment.check(); err != nil {
+			errs.Add(fmt.Sprintf("%s/comments[%d]", path, i), comment.Ref, fmt.Errorf("%w: %v", ErrInvalidValue, err))
+		}
+	}
+
+	for i, link := range ws.Hyperlinks {
+		if err := link.check(); err != nil {
+			errs.Add(fmt.Sprintf("%s/hyperlinks[%d]", path, i), link.Ref, fmt.Errorf("%w: %v", ErrInvalidValue, err))
+		}
+	}
+
+	for i, chart := range ws.Charts {
+		if err := chart.Spec.check(); err != nil {
+			errs.Add(fmt.Sprintf("%s/charts[%d]", path, i), chart.Spec.Type, fmt.Errorf("%w: %v", ErrInvalidValue, err))
+		}
+	}
+
+	for i, col := range ws.Columns {
+		colPath := fmt.Sprintf("%s/cols[%d]", path, i)
+		if col.Min < 1 || col.Max < col.Min || col.Max > MaxColumns {
+			errs.Add(colPath, fmt.Sprintf("%d:%d", col.Min, col.Max), ErrInvalidValue)
+		}
+		validateCellStyle(errs, colPath+"/style", col.Style, styles)
+	}
+
+	for i, row := range ws.Rows {
+		rowPath := fmt.Sprintf("%s/rows[%d]", path, i)
+		// 行必须按行号升序排列
+		if row.Index < 1 || row.Index > MaxRows || i > 0 && row.Index <= ws.Rows[i-1].Index {
+			errs.Add(rowPath+"/index", fmt.Sprintf("%d", row.Index), ErrInvalidValue)
+		}
+		validateCellStyle(errs, rowPath+"/style", row.Style, styles)
+	}
+}
+
+// validateCellStyle 校验单元格样式引用的字体、填充和边框是否存在，以及对齐方式的取值
+func validateCellStyle(errs *ValidationErrors, path string, style *CellStyle, styles *Styles) {
+	if style == nil {
+		return
+	}
+
+	if style.FontID < 0 || style.FontID >= len(styles.Fonts) {
+		errs.Add(path+"/fontId", fmt.Sprintf("%d", style.FontID), ErrMissingReference)
+	}
+	if style.FillID < 0 || style.FillID >= len(styles.Fills) {
+		errs.Add(path+"/fillId", fmt.Sprintf("%d", style.FillID), ErrMissingReference)
+	}
+	if style.BorderID < 0 || style.BorderID >= len(styles.Borders) {
+		errs.Add(path+"/borderId", fmt.Sprintf("%d", style.BorderID), ErrMissingReference)
+	}
+
+	validateAlignment(errs, path+"/alignment", style.Alignment)
+}
+
+// validateAlignment 校验对齐方式
+func validateAlignment(errs *ValidationErrors, path string, alignment *Alignment) {
+	if alignment == nil {
+		return
+	}
+	if alignment.Horizontal != "" && !validHorizontalAlignments[alignment.Horizontal] {
+		errs.Add(path+"/horizontal", alignment.Horizontal, ErrInvalidValue)
+	}
+	if alignment.Vertical != "" && !validVerticalAlignments[alignment.Vertical] {
+		errs.Add(path+"/vertical", alignment.Vertical, ErrInvalidValue)
+	}
+}
+
+// validateStyles 校验样式表中的颜色、对齐方式和单元格XF引用的字体、填充与边框
+func validateStyles(errs *ValidationErrors, styles *Styles) {
+	for i, font := range styles.Fonts {
+		checkColor(errs, fmt.Sprintf("styles/fonts[%d]/color", i), font.Color)
+	}
+	for i, fill := range styles.Fills {
+		checkColor(errs, fmt.Sprintf("styles/fills[%d]/fgColor", i), fill.FgColor)
+		checkColor(errs, fmt.Sprintf("styles/fills[%d]/bgColor", i), fill.BgColor)
+	}
+	for i, border := range styles.Borders {
+		path := fmt.Sprintf("styles/borders[%d]", i)
+		sides := []string{"left", "right", "top", "bottom"}
+		for j, bs := range []*BorderStyle{border.Left, border.Right, border.Top, border.Bottom} {
+			if bs != nil {
+				checkColor(errs, path+"/"+sides[j]+"/color", bs.Color)
+			}
+		}
+	}
+	for i, xf := range styles.CellXfs {
+		path := fmt.Sprintf("styles/cellXfs[%d]", i)
+		if xf.FontId < 0 || xf.FontId >= len(styles.Fonts) {
+			errs.Add(path+"/fontId", fmt.Sprintf("%d", xf.FontId), ErrMissingReference)
+		}
+		if xf.FillId < 0 || xf.FillId >= len(styles.Fills) {
+			errs.Add(path+"/fillId", fmt.Sprintf("%d", xf.FillId), ErrMissingReference)
+		}
+		if xf.BorderId < 0 || xf.BorderId >= len(styles.Borders) {
+			errs.Add(path+"/borderId", fmt.Sprintf("%d", xf.BorderId), ErrMissingReference)
+		}
+		if xf.XfId < 0 || xf.XfId >= len(styles.CellStyleXfs) {
+			errs.Add(path+"/xfId", fmt.Sprintf("%d", xf.XfId), ErrMissingReference)
+		}
+		validateAlignment(errs, path+"/alignment", xf.Alignment)
+	}
+}
+
+// checkColor 检查颜色是否为RRGGBB或AARRGGBB格式，空字符串表示未设置
+func checkColor(errs *ValidationErrors, path, color string) {
+	if color == "" {
+		return
+	}
+	if len(color) != 6 && len(color) != 8 || strings.Trim(color, "0123456789abcdefABCDEF") != "" {
+		errs.Add(path, color, ErrInvalidColor)
+	}
+}
+
+// isValidCellRef 判断是否为A1格式的有效单元格引用，列名必须为大写字母
+func isValidCellRef(ref string) bool {
+	index := 0
+	for index < len(ref) && ref[index] >= 'A' && ref[index] <= 'Z' {
+		index++
+	}
+	if index == 0 || index > 3 || index == len(ref) || ref[index] == '0' {
+		return false
+	}
+
+	row := 0
+	for _, c := range ref[index:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+		row = row*10 + int(c-'0')
+		if row > MaxRows {
+			return false
+		}
+	}
+
+	return ColNameToIndex(ref[:index]) < MaxColumns
+}
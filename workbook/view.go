@@ -0,0 +1,304 @@
+package workbook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 窗格的状态
+const (
+	PaneStateFrozen = "frozen" // 冻结窗格
+	PaneStateSplit  = "split"  // 拆分窗格
+)
+
+// SheetView 表示工作表的视图设置
+type SheetView struct {
+	ShowGridLines     bool   // 显示网格线
+	ShowRowColHeaders bool   // 显示行号和列标
+	RightToLeft       bool   // 从右到左显示
+	ZoomScale         int    // 缩放比例，10~400，0表示100
+	TopLeftCell       string // 左上角显示的单元格
+	Pane              *Pane  // 冻结或拆分的窗格
+	ActiveCell        string // 活动单元格
+	Sqref             string // 选中的区域，为空时为活动单元格
+}
+
+// Pane 表示冻结或拆分的窗格
+type Pane struct {
+	State       string  // frozen或split
+	XSplit      float64 // 冻结时为左侧冻结的列数，拆分时为左侧窗格的宽度，单位为1/20磅
+	YSplit      float64 // 冻结时为上方冻结的行数，拆分时为上方窗格的高度，单位为1/20磅
+	TopLeftCell string  // 右下窗格左上角显示的单元格
+}
+
+// NewSheetView 创建默认的视图设置，显示网格线和行号列标
+func NewSheetView() *SheetView {
+	return &SheetView{
+		ShowGridLines:     true,
+		ShowRowColHeaders: true,
+	}
+}
+
+// view 返回工作表的视图设置，不存在时创建默认设置
+func (ws *Worksheet) view() *SheetView {
+	if ws.View == nil {
+		ws.View = NewSheetView()
+	}
+	return ws.View
+}
+
+// FreezePanes 冻结cellRef上方的行和左侧的列，例如 "B2" 冻结第一行和第一列，"A2" 只冻结第一行
+// cellRef为 "A1" 时取消冻结
+func (ws *Worksheet) FreezePanes(cellRef string) error {
+	row, col, err := ParseCellRef(cellRef)
+	if err != nil || !isValidCellRef(cellRef) {
+		return fmt.Errorf("无效的冻结位置: %s", cellRef)
+	}
+	view := ws.view()
+	if row == 0 && col == 0 {
+		view.Pane = nil
+		return nil
+	}
+	view.Pane = &Pane{
+		State:       PaneStateFrozen,
+		XSplit:      float64(col),
+		YSplit:      float64(row),
+		TopLeftCell: cellRef,
+	}
+	return nil
+}
+
+// SplitPanes 将窗口拆分为可以分别滚动的窗格，width和height为左侧和上方窗格的大小，单位为磅
+// 两者都为0时取消拆分
+func (ws *Worksheet) SplitPanes(width, height float64) error {
+	if width < 0 || height < 0 {
+		return fmt.Errorf("拆分窗格的大小不能为负数")
+	}
+	view := ws.view()
+	if width == 0 && height == 0 {
+		view.Pane = nil
+		return nil
+	}
+	view.Pane = &Pane{State: PaneStateSplit, XSplit: width * 20, YSplit: height * 20}
+	return nil
+}
+
+// SetZoom 设置缩放比例，范围为10~400
+func (ws *Worksheet) SetZoom(percent int) error {
+	if percent < 10 || percent > 400 {
+		return fmt.Errorf("缩放比例必须在10到400之间: %d", percent)
+	}
+	ws.view().ZoomScale = percent
+	return nil
+}
+
+// SetShowGridLines 设置是否显示网格线
+func (ws *Worksheet) SetShowGridLines(show bool) *Worksheet {
+	ws.view().ShowGridLines = show
+	return ws
+}
+
+// SetShowHeaders 设置是否显示行号和列标
+func (ws *Worksheet) SetShowHeaders(show bool) *Worksheet {
+	ws.view().ShowRowColHeaders = show
+	return ws
+}
+
+// SetRightToLeft 设置工作表是否从右到左显示
+func (ws *Worksheet) SetRightToLeft(rightToLeft bool) *Worksheet {
+	ws.view().RightToLeft = rightToLeft
+	return ws
+}
+
+// SetSelection 设置活动单元格和选中的区域，sqref为空时只选中活动单元格
+// 例如: ws.SetSelection("B3", "B3:D10")
+func (ws *Worksheet) SetSelection(activeCell, sqref string) error {
+	if !isValidCellRef(activeCell) {
+		return fmt.Errorf("无效的活动单元格: %s", activeCell)
+	}
+	sqref = strings.Join(strings.Fields(strings.ReplaceAll(sqref, ",", " ")), " ")
+	for _, ref := range strings.Fields(sqref) {
+		if _, ok := parseRangeRef(ref); !ok || strings.ContainsAny(ref, "!$") {
+			return fmt.Errorf("无效的选中区域: %s", ref)
+		}
+	}
+	view := ws.view()
+	view.ActiveCell = activeCell
+	view.Sqref = strings.ToUpper(sqref)
+	return nil
+}
+
+// SetTabColor 设置工作表标签的颜色，color为空时使用默认颜色
+func (ws *Worksheet) SetTabColor(color string) error {
+	if color == "" {
+		ws.TabColor = ""
+		return nil
+	}
+	normalized, err := normalizeColor(color)
+	if err != nil {
+		return fmt.Errorf("无效的标签颜色: %w", err)
+	}
+	ws.TabColor = normalized
+	return nil
+}
+
+// check 检查视图设置是否有效
+func (v *SheetView) check() error {
+	if v.ZoomScale != 0 && (v.ZoomScale < 10 || v.ZoomScale > 400) {
+		return fmt.Errorf("缩放比例必须在10到400之间: %d", v.ZoomScale)
+	}
+	for _, ref := range []string{v.TopLeftCell, v.ActiveCell} {
+		if ref != "" && !isValidCellRef(ref) {
+			return fmt.Errorf("无效的单元格引用: %s", ref)
+		}
+	}
+	if p := v.Pane; p != nil {
+		if p.State != PaneStateFrozen && p.State != PaneStateSplit {
+			return fmt.Errorf("无效的窗格状态: %s", p.State)
+		}
+		if p.XSplit < 0 || p.YSplit < 0 || p.XSplit == 0 && p.YSplit == 0 {
+			return fmt.Errorf("窗格的拆分位置无效")
+		}
+		if p.TopLeftCell != "" && !isValidCellRef(p.TopLeftCell) {
+			return fmt.Errorf("无效的单元格引用: %s", p.TopLeftCell)
+		}
+	}
+	return nil
+}
+
+// sheetPrXML 生成sheetPr元素
+func (ws *Worksheet) sheetPrXML() string {
+	fitToPage := ws.PageSetup != nil && ws.PageSetup.FitToPage
+	if ws.TabColor == "" && !fitToPage {
+		return ""
+	}
+	xml := "  <sheetPr>"
+	if ws.TabColor != "" {
+		xml += "<tabColor rgb=\"" + ws.TabColor + "\" />"
+	}
+	if fitToPage {
+		xml += "<pageSetUpPr fitToPage=\"1\" />"
+	}
+	xml += "</sheetPr>\n"
+	return xml
+}
+
+// sheetViewsXML 生成sheetViews元素，工作簿的活动工作表为选中状态
+func (ws *Worksheet) sheetViewsXML() string {
+	view := ws.View
+	if view == nil {
+		view = NewSheetView()
+	}
+
+	xml := "  <sheetViews>\n    <sheetView"
+	if ws.wb != nil && ws.wb.ActiveSheet() == ws {
+		xml += " tabSelected=\"1\""
+	}
+	if !view.ShowGridLines {
+		xml += " showGridLines=\"0\""
+	}
+	if !view.ShowRowColHeaders {
+		xml += " showRowColHeaders=\"0\""
+	}
+	if view.RightToLeft {
+		xml += " rightToLeft=\"1\""
+	}
+	if view.TopLeftCell != "" {
+		xml += " topLeftCell=\"" + view.TopLeftCell + "\""
+	}
+	if view.ZoomScale != 0 {
+		xml += fmt.Sprintf(" zoomScale=\"%d\" zoomScaleNormal=\"%d\"", view.ZoomScale, view.ZoomScale)
+	}
+	xml += " workbookViewId=\"0\""
+
+	if view.Pane == nil && view.ActiveCell == "" {
+		return xml + " />\n  </sheetViews>\n"
+	}
+	xml += ">\n"
+
+	// 选中区域属于活动窗格：同时拆分行列时为右下窗格，只拆分行时为左下窗格，只拆分列时为右上窗格
+	activePane := ""
+	if p := view.Pane; p != nil {
+		switch {
+		case p.XSplit > 0 && p.YSplit > 0:
+			activePane = "bottomRight"
+		case p.YSplit > 0:
+			activePane = "bottomLeft"
+		default:
+			activePane = "topRight"
+		}
+		xml += "      <pane"
+		if p.XSplit > 0 {
+			xml += " xSplit=\"" + formatNumber(p.XSplit) + "\""
+		}
+		if p.YSplit > 0 {
+			xml += " ySplit=\"" + formatNumber(p.YSplit) + "\""
+		}
+		if p.TopLeftCell != "" {
+			xml += " topLeftCell=\"" + p.TopLeftCell + "\""
+		}
+		xml += " activePane=\"" + activePane + "\" state=\"" + p.State + "\" />\n"
+		if activePane == "bottomRight" {
+			xml += "      <selection pane=\"topRight\" />\n"
+			xml += "      <selection pane=\"bottomLeft\" />\n"
+		}
+	}
+
+	activeCell, sqref := view.ActiveCell, view.Sqref
+	if activeCell == "" && view.Pane != nil {
+		activeCell = view.Pane.TopLeftCell
+	}
+	if sqref == "" {
+		sqref = activeCell
+	}
+	xml += "      <selection"
+	if activePane != "" {
+		xml += " pane=\"" + activePane + "\""
+	}
+	if activeCell != "" {
+		xml += " activeCell=\"" + activeCell + "\" sqref=\"" + sqref + "\""
+	}
+	xml += " />\n"
+	xml += "    </sheetView>\n  </sheetViews>\n"
+	return xml
+}
+
+// SetActiveSheet 设置打开工作簿时显示的工作表，index从0开始
+func (wb *Workbook) SetActiveSheet(index int) error {
+	if index < 0 || index >= len(wb.Worksheets) {
+		return fmt.Errorf("工作表序号超出范围: %d", index)
+	}
+	wb.activeTab = index
+	return nil
+}
+
+// ActiveSheet 返回打开工作簿时显示的工作表，没有工作表时返回nil
+func (wb *Workbook) ActiveSheet() *Worksheet {
+	if len(wb.Worksheets) == 0 {
+		return nil
+	}
+	return wb.Worksheets[min(wb.activeTab, len(wb.Worksheets)-1)]
+}
+
+// SetFirstVisibleTab 设置工作表标签栏中显示的第一个标签，index从0开始
+func (wb *Workbook) SetFirstVisibleTab(index int) error {
+	if index < 0 || index >= len(wb.Worksheets) {
+		return fmt.Errorf("工作表序号超出范围: %d", index)
+	}
+	wb.firstTab = index
+	return nil
+}
+
+// bookViewsXML 生成bookViews元素
+func (wb *Workbook) bookViewsXML() string {
+	xml := "  <bookViews>\n    <workbookView"
+	last := max(len(wb.Worksheets)-1, 0)
+	if tab := min(wb.firstTab, last); tab > 0 {
+		xml += fmt.Sprintf(" firstSheet=\"%d\"", tab)
+	}
+	if tab := min(wb.activeTab, last); tab > 0 {
+		xml += fmt.Sprintf(" activeTab=\"%d\"", tab)
+	}
+	xml += " />\n  </bookViews>\n"
+	return xml
+}
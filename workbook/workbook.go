@@ -1,22 +1,34 @@
 package workbook
 
 import (
-	"archive/zip"
+	"bytes"
 	"fmt"
-	"os"
+	"io"
 	"time"
+
+	"github.com/landaiqing/go-dockit/opc"
 )
 
 // Workbook 表示一个Excel工作簿
 type Workbook struct {
 	Worksheets    []*Worksheet
 	Properties    *WorkbookProperties
-	Relationships *Relationships
+	Relationships *Relationships // 额外的包级关系，保存时ID排在基本关系之后
 	Styles        *Styles
 	Theme         *Theme
 	ContentTypes  *ContentTypes
 	Rels          *WorkbookRels
 	SharedStrings *SharedStrings
+	Media         *MediaStore
+	DefinedNames  []*DefinedName // 定义的名称，见DefineName
+	Protection    *WorkbookProtection
+	CustomParts   []*opc.Part     // 自定义部件，保存时原样写入包中
+	Strict        bool            // 严格模式，保存前校验工作簿，见Validate
+	SaveOptions   opc.SaveOptions // 写出选项，如确定性输出和压缩级别
+	TempDir       string          // 流式写入器临时文件所在的目录，为空时使用系统临时目录，见StreamWriter
+	customXML     []string        // 自定义XML数据部件的名称
+	activeTab     int             // 活动工作表的序号，见SetActiveSheet
+	firstTab      int             // 标签栏中第一个显示的工作表序号
 }
 
 // WorkbookProperties 包含工作簿的元数据
@@ -47,6 +59,7 @@ func NewWorkbook() *Workbook {
 		ContentTypes:  NewContentTypes(),
 		Rels:          NewWorkbookRels(),
 		SharedStrings: NewSharedStrings(),
+		Media:         NewMediaStore("media"),
 	}
 }
 
@@ -54,136 +67,210 @@ func NewWorkbook() *Workbook {
 func (wb *Workbook) AddWorksheet(name string) *Worksheet {
 	ws := NewWorksheet(name)
 	ws.SheetID = len(wb.Worksheets) + 1
+	ws.wb = wb
 	wb.Worksheets = append(wb.Worksheets, ws)
 	return ws
 }
 
 // Save 保存Excel工作簿到文件
 func (wb *Workbook) Save(filename string) error {
-	// 创建一个新的zip文件
-	file, err := os.Create(filename)
+	// 先组装包，严格模式下校验失败时不会创建文件
+	pkg, err := wb.Package()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	// 创建一个新的zip writer
-	zipWriter := zip.NewWriter(file)
-	defer zipWriter.Close()
+	return pkg.Save(filename)
+}
 
-	// 为每个工作表添加内容类型覆盖
-	for i := range wb.Worksheets {
-		wb.ContentTypes.AddWorksheetOverride(i + 1)
+// Bytes 将工作簿序列化为字节数据
+func (wb *Workbook) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := wb.WriteTo(&buf); err != nil {
+		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	// 添加[Content_Types].xml
-	contentTypesWriter, err := zipWriter.Create("[Content_Types].xml")
+// WriteTo 将工作簿写入w，实现io.WriterTo接口
+// 可以直接写入HTTP响应、对象存储上传流或内存缓冲区
+func (wb *Workbook) WriteTo(w io.Writer) (int64, error) {
+	pkg, err := wb.Package()
 	if err != nil {
-		return err
+		return 0, err
 	}
-	_, err = contentTypesWriter.Write([]byte(wb.ContentTypes.ToXML()))
-	if err != nil {
-		return err
+	return pkg.WriteTo(w)
+}
+
+// Package 将工作簿组装为OPC包，可以在写出之前检查或调整包中的部件
+// 流式写入工作表的部件在写出时从临时文件读取，写出之前不能调用Close
+func (wb *Workbook) Package() (*opc.Package, error) {
+	if wb.Strict {
+		if err := wb.Validate(); err != nil {
+			return nil, err
+		}
 	}
 
-	// 添加_rels/.rels
-	relsDir, err := zipWriter.Create("_rels/.rels")
-	if err != nil {
-		return err
+	pkg := opc.NewPackage()
+	pkg.ContentTypes = wb.ContentTypes.ContentTypes
+	pkg.Options = wb.SaveOptions
+
+	// 包级关系
+	pkg.Relationships.AddRelationship("rId1", opc.RelTypeOfficeDocument, "xl/workbook.xml")
+
+	// 用户添加的包级关系，ID排在基本关系之后重新分配
+	for _, rel := range wb.Relationships.Relationships {
+		pkg.Relationships.Relationships = append(pkg.Relationships.Relationships, &Relationship{
+			ID:         pkg.Relationships.NextID(),
+			Type:       rel.Type,
+			Target:     rel.Target,
+			TargetMode: rel.TargetMode,
+		})
 	}
 
-	// 创建根关系
-	rootRels := NewRelationships()
-	rootRels.AddRelationship("rId1", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument", "xl/workbook.xml")
-	_, err = relsDir.Write([]byte(rootRels.ToXML()))
-	if err != nil {
-		return err
+	// 工作簿及其关系，关系ID只记录在本次打包的state中
+	state := newSaveState()
+	workbookPart := pkg.AddPart("xl/workbook.xml", contentTypeWorkbook, nil)
+	workbookPart.Relationships = wb.workbookRels(state)
+	workbookPart.Data = []byte(wb.toXML(state))
+
+	// 添加xl/worksheets/sheet1.xml, sheet2.xml, ...
+	drawings, charts, tables, comments, vmlBlock := 0, 0, 0, 0, 1
+	for i, ws := range wb.Worksheets {
+		name := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		if ws.stream != nil {
+			// 流式写入的工作表在写出时从临时文件复制
+			if err := ws.stream.ready(); err != nil {
+				return nil, err
+			}
+			part := opc.NewPart(name, contentTypeWorksheet, nil)
+			part.Open = ws.stream.open
+			pkg.PutPart(part)
+			continue
+		}
+
+		// 工作表引用的绘图部件和表格需要先分配关系ID
+		if ws.hasDrawing() {
+			drawings++
+		}
+		if len(ws.Comments) > 0 {
+			comments++
+		}
+		sheetRels := ws.sheetRels(state, drawings, comments, tables+1)
+		if ws.hasDrawing() {
+			charts = wb.addDrawingParts(pkg, ws, state, drawings, charts)
+		}
+		if len(ws.Comments) > 0 {
+			vmlBlock = wb.addCommentParts(pkg, ws, comments, vmlBlock)
+		}
+		for _, table := range ws.Tables {
+			tables++
+			pkg.AddPart(fmt.Sprintf("xl/tables/table%d.xml", tables), contentTypeTable, []byte(table.ToXML(tables)))
+		}
+		sheetPart := pkg.AddPart(name, contentTypeWorksheet, []byte(ws.toXML(wb.SharedStrings, state)))
+		sheetPart.Relationships = sheetRels
 	}
 
-	// 添加xl/workbook.xml
-	workbookWriter, err := zipWriter.Create("xl/workbook.xml")
-	if err != nil {
-		return err
+	pkg.AddPart("xl/styles.xml", contentTypeStyles, []byte(wb.Styles.ToXML()))
+	pkg.AddPart("xl/theme/theme1.xml", opc.ContentTypeTheme, []byte(wb.Theme.ToXML()))
+	pkg.AddPart("xl/sharedStrings.xml", contentTypeSharedStrings, []byte(wb.SharedStrings.ToXML()))
+
+	// 添加xl/media/下的文件
+	for _, file := range wb.Media.Files {
+		pkg.AddPart("xl/"+file.Path, "", file.Data)
 	}
-	_, err = workbookWriter.Write([]byte(wb.ToXML()))
-	if err != nil {
-		return err
+
+	// 添加自定义部件，与内置部件同名时替换内置部件
+	for _, part := range wb.CustomParts {
+		pkg.PutPart(part)
 	}
 
-	// 添加xl/_rels/workbook.xml.rels
-	workbookRelsWriter, err := zipWriter.Create("xl/_rels/workbook.xml.rels")
-	if err != nil {
-		return err
+	return pkg, nil
+}
+
+// sheetRels 按绘图部件、批注、外部链接、表格的顺序创建工作表的关系，关系ID记录到state中
+// drawing和comments为绘图部件和批注部件的序号，table为工作表第一个表格部件的序号
+func (ws *Worksheet) sheetRels(state *saveState, drawing, comments, table int) *Relationships {
+	sheetRels := NewRelationships()
+	if ws.hasDrawing() {
+		state.drawings[ws] = sheetRels.Add(relTypeDrawing, fmt.Sprintf("../drawings/drawing%d.xml", drawing)).ID
 	}
+	if len(ws.Comments) > 0 {
+		sheetRels.Add(relTypeComments, fmt.Sprintf("../comments%d.xml", comments))
+		state.legacyDrawings[ws] = sheetRels.Add(relTypeVMLDrawing, fmt.Sprintf("../drawings/vmlDrawing%d.vml", comments)).ID
+	}
+	ws.addHyperlinkRels(sheetRels, state)
+	for i, t := range ws.Tables {
+		state.tables[t] = sheetRels.Add(relTypeTable, fmt.Sprintf("../tables/table%d.xml", table+i)).ID
+	}
+	return sheetRels
+}
 
-	// 创建工作簿关系
+// SetDeterministic 设置确定性输出，相同的内容总是生成完全相同的文件，便于在版本库中保存和比对
+// 开启后使用固定时间戳（见SetSaveTime，Recalculate计算NOW和TODAY时也使用它），并按名称排序部件
+// 密码保护的盐值在保存时由受保护的内容和密码生成，与Protect的调用顺序无关
+func (wb *Workbook) SetDeterministic(deterministic bool) *Workbook {
+	wb.SaveOptions.Deterministic = deterministic
+	return wb
+}
+
+// SetSaveTime 设置保存时使用的时间戳，即zip条目的修改时间
+func (wb *Workbook) SetSaveTime(t time.Time) *Workbook {
+	wb.SaveOptions.ModTime = t
+	return wb
+}
+
+// SetCompressionLevel 设置压缩级别：opc.CompressionDefault、opc.CompressionNone或1~9
+func (wb *Workbook) SetCompressionLevel(level int) *Workbook {
+	wb.SaveOptions.CompressionLevel = level
+	return wb
+}
+
+// AddCustomPart 向工作簿包中添加一个自定义部件，例如附件或其他应用的数据
+// name为包内路径，如attachments/data.json；contentType为空时按扩展名使用默认类型
+// 如需引用该部件，可以在Relationships中添加指向它的包级关系
+func (wb *Workbook) AddCustomPart(name, contentType string, data []byte) *opc.Part {
+	part := opc.NewPart(name, contentType, data)
+	for i, existing := range wb.CustomParts {
+		if existing.Name == part.Name {
+			wb.CustomParts[i] = part
+			return part
+		}
+	}
+	wb.CustomParts = append(wb.CustomParts, part)
+	return part
+}
+
+// AddCustomXML 添加一个自定义XML数据部件（customXml/itemN.xml），并建立从工作簿到它的关系
+func (wb *Workbook) AddCustomXML(data []byte) *opc.Part {
+	wb.customXML = append(wb.customXML, fmt.Sprintf("customXml/item%d.xml", len(wb.customXML)+1))
+	return wb.AddCustomPart(wb.customXML[len(wb.customXML)-1], opc.ContentTypeXML, data)
+}
+
+// workbookRels 创建工作簿部件的关系，每个工作表的关系ID记录到state中
+func (wb *Workbook) workbookRels(state *saveState) *Relationships {
 	wbRels := NewRelationships()
 
 	// 添加样式关系
-	wbRels.AddRelationship("rId1", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles", "styles.xml")
+	wbRels.Add(opc.RelTypeStyles, "styles.xml")
 
 	// 添加主题关系
-	wbRels.AddRelationship("rId2", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/theme", "theme/theme1.xml")
+	wbRels.Add(opc.RelTypeTheme, "theme/theme1.xml")
 
 	// 添加共享字符串表关系
-	wbRels.AddRelationship("rId3", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings", "sharedStrings.xml")
+	wbRels.Add("http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings", "sharedStrings.xml")
 
 	// 添加工作表关系
-	for i := range wb.Worksheets {
-		relID := fmt.Sprintf("rId%d", i+4) // 从rId4开始
-		target := fmt.Sprintf("worksheets/sheet%d.xml", i+1)
-		wbRels.AddRelationship(relID, "http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet", target)
-	}
-
-	_, err = workbookRelsWriter.Write([]byte(wbRels.ToXML()))
-	if err != nil {
-		return err
-	}
-
-	// 添加xl/worksheets/sheet1.xml, sheet2.xml, ...
 	for i, ws := range wb.Worksheets {
-		sheetPath := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
-		sheetWriter, err := zipWriter.Create(sheetPath)
-		if err != nil {
-			return err
-		}
-		_, err = sheetWriter.Write([]byte(ws.ToXML(wb.SharedStrings)))
-		if err != nil {
-			return err
-		}
-	}
-
-	// 添加xl/styles.xml
-	stylesWriter, err := zipWriter.Create("xl/styles.xml")
-	if err != nil {
-		return err
-	}
-	_, err = stylesWriter.Write([]byte(wb.Styles.ToXML()))
-	if err != nil {
-		return err
-	}
-
-	// 添加xl/theme/theme1.xml
-	themeWriter, err := zipWriter.Create("xl/theme/theme1.xml")
-	if err != nil {
-		return err
-	}
-	_, err = themeWriter.Write([]byte(wb.Theme.ToXML()))
-	if err != nil {
-		return err
+		target := fmt.Sprintf("worksheets/sheet%d.xml", i+1)
+		state.sheets[ws] = wbRels.Add("http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet", target).ID
 	}
 
-	// 添加xl/sharedStrings.xml
-	sharedStringsWriter, err := zipWriter.Create("xl/sharedStrings.xml")
-	if err != nil {
-		return err
-	}
-	_, err = sharedStringsWriter.Write([]byte(wb.SharedStrings.ToXML()))
-	if err != nil {
-		return err
+	// 添加自定义XML数据关系
+	for _, name := range wb.customXML {
+		wbRels.Add(opc.RelTypeCustomXML, "../"+name)
 	}
 
-	return nil
+	return wbRels
 }
 
 // WorkbookRels 表示工作簿的关系
@@ -200,19 +287,29 @@ func NewWorkbookRels() *WorkbookRels {
 
 // ToXML 将工作簿转换为XML
 func (wb *Workbook) ToXML() string {
+	state := newSaveState()
+	wb.workbookRels(state)
+	return wb.toXML(state)
+}
+
+// toXML 使用state中的工作表关系ID生成工作簿XML
+func (wb *Workbook) toXML(state *saveState) string {
 	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
 	xml += "<workbook xmlns=\"http://schemas.openxmlformats.org/spreadsheetml/2006/main\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\">\n"
 
-	// 工作簿属性
-	xml += "  <workbookPr defaultThemeVersion=\"124226\"/>\n"
-
-	// 工作表
-	xml += "  <sheets>\n"
-	for i, ws := range wb.Worksheets {
-		relID := fmt.Sprintf("rId%d", i+4) // 从rId4开始，与上面的关系ID对应
-		xml += fmt.Sprintf("    <sheet name=\"%s\" sheetId=\"%d\" r:id=\"%s\"/>\n", ws.Name, ws.SheetID, relID)
+	// 视图和工作表，关系ID由workbookRels统一分配
+	body := wb.bookViewsXML()
+	body += "  <sheets>\n"
+	for _, ws := range wb.Worksheets {
+		body += fmt.Sprintf("    <sheet name=\"%s\" sheetId=\"%d\" r:id=\"%s\"/>\n", escapeXML(ws.Name), ws.SheetID, state.sheets[ws])
 	}
-	xml += "  </sheets>\n"
+	body += "  </sheets>\n"
+	body += wb.definedNamesXML()
+
+	// 工作簿属性和保护，保护设置在视图之前
+	xml += "  <workbookPr defaultThemeVersion=\"124226\"/>\n"
+	xml += wb.workbookProtectionXML(body)
+	xml += body
 
 	xml += "</workbook>"
 	return xml
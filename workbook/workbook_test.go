@@ -0,0 +1,69 @@
+package workbook
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestDeterministicSave 检查确定性模式下相同内容的工作簿保存为完全相同的字节，与调用顺序无关
+func TestDeterministicSave(t *testing.T) {
+	build := func(t *testing.T, title string, deterministicFirst bool) *Workbook {
+		wb := NewWorkbook()
+		if deterministicFirst {
+			wb.SetDeterministic(true)
+		}
+		ws := wb.AddWorksheet("Sheet1")
+		ws.SetCellValue(0, 0, title)
+		ws.SetCellValue(1, 0, 42.0)
+		ws.SetCellFormula("B1", "NOW()")
+		ws.SetCellFormula("B2", "A2*2")
+		if err := ws.Protect("secret", nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := wb.Protect("secret", true, false); err != nil {
+			t.Fatal(err)
+		}
+		if !deterministicFirst {
+			wb.SetDeterministic(true)
+		}
+		if err := wb.Recalculate(); err != nil {
+			t.Fatal(err)
+		}
+		return wb
+	}
+	save := func(t *testing.T, wb *Workbook) []byte {
+		var buf bytes.Buffer
+		if _, err := wb.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name  string
+		other string
+		first bool
+		equal bool
+	}{
+		{"相同内容", "报表", true, true},
+		{"相同内容不同调用顺序", "报表", false, true},
+		{"不同内容", "其他报表", true, false},
+	}
+
+	want := save(t, build(t, "报表", true))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := save(t, build(t, tt.other, tt.first))
+			if bytes.Equal(got, want) != tt.equal {
+				t.Errorf("两次保存的结果相同为 %v, 期望 %v", !tt.equal, tt.equal)
+			}
+		})
+	}
+
+	t.Run("重复保存", func(t *testing.T) {
+		wb := build(t, "报表", true)
+		if !bytes.Equal(save(t, wb), save(t, wb)) {
+			t.Error("同一个工作簿两次保存的结果不同")
+		}
+	})
+}
@@ -2,17 +2,34 @@ package workbook
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
 // Worksheet 表示Excel工作簿中的工作表
+// 单元格按行存储：Rows按行号升序排列，每行的Cells按列索引升序排列，只保存存在的行和单元格
 type Worksheet struct {
-	Name        string
-	SheetID     int
-	Cells       map[string]*Cell
-	Columns     []*Column
-	Rows        []*Row
-	MergedCells []*MergedCell
+	Name               string
+	SheetID            int
+	TabColor           string     // 工作表标签的颜色，ARGB格式
+	View               *SheetView // 视图设置，为nil时使用默认设置
+	PageSetup          *PageSetup // 页面设置，为nil时使用默认设置
+	Protection         *SheetProtection
+	Columns            []*Column
+	Rows               []*Row
+	MergedCells        []*MergedCell
+	AutoFilter         *AutoFilter
+	ConditionalFormats []*ConditionalFormat
+	DataValidations    []*DataValidation
+	Charts             []*Chart
+	Pictures           []*Picture
+	Tables             []*Table
+	Comments           []*Comment
+	Hyperlinks         []*Hyperlink
+	wb                 *Workbook     // 所属的工作簿，插入或删除行列时用于调整其他工作表中的公式
+	stream             *StreamWriter // 流式写入的工作表，内容由StreamWriter生成
+	invalidRefs        []string      // AddCell和SetCellValue收到的无效单元格位置，由Validate报告
 }
 
 // NewWorksheet 创建一个新的工作表
@@ -20,7 +37,6 @@ func NewWorksheet(name string) *Worksheet {
 	return &Worksheet{
 		Name:        name,
 		SheetID:     1,
-		Cells:       make(map[string]*Cell),
 		Columns:     make([]*Column, 0),
 		Rows:        make([]*Row, 0),
 		MergedCells: make([]*MergedCell, 0),
@@ -29,20 +45,20 @@ func NewWorksheet(name string) *Worksheet {
 
 // Cell 表示工作表中的单元格
 type Cell struct {
+	Col      int // 列索引，从0开始
 	Value    interface{}
 	Formula  string
-	Style    *CellStyle
+	StyleID  int    // 单元格XF索引，由Styles.NewStyle返回，0表示默认样式
 	DataType string // s: 字符串, n: 数字, b: 布尔值, d: 日期, e: 错误
 }
 
 // NewCell 创建一个新的单元格
 func NewCell() *Cell {
-	return &Cell{
-		Style: NewCellStyle(),
-	}
+	return &Cell{}
 }
 
-// CellStyle 表示单元格样式
+// CellStyle 表示由字体、填充、边框和数字格式ID组成的旧版单元格样式
+// 单元格只保存XF索引，SetCellStyle会将CellStyle注册为XF
 type CellStyle struct {
 	FontID         int
 	FillID         int
@@ -79,9 +95,9 @@ type Column struct {
 
 // Row 表示工作表中的行
 type Row struct {
-	Index  int
+	Index  int // 行号，从1开始
 	Height float64
-	Cells  []*Cell
+	Cells  []*Cell // 按列索引升序排列
 	Style  *CellStyle
 	Hidden bool
 }
@@ -92,17 +108,76 @@ type MergedCell struct {
 	BottomRightRef string // 例如: "B2"
 }
 
-// AddRow 添加一个新的行
+// AddRow 在最后一行之后添加一个新的行
 func (ws *Worksheet) AddRow() *Row {
+	index := 1
+	if n := len(ws.Rows); n > 0 {
+		index = ws.Rows[n-1].Index + 1
+	}
+	return ws.row(index, true)
+}
+
+// row 返回行号为index的行，不存在时create为true则按顺序插入新行，否则返回nil
+func (ws *Worksheet) row(index int, create bool) *Row {
+	n := len(ws.Rows)
+	i := n
+	// 按顺序写入时新行总是在末尾，不需要查找
+	if n == 0 || ws.Rows[n-1].Index < index {
+		if !create {
+			return nil
+		}
+	} else {
+		i = sort.Search(n, func(i int) bool { return ws.Rows[i].Index >= index })
+		if ws.Rows[i].Index == index {
+			return ws.Rows[i]
+		}
+		if !create {
+			return nil
+		}
+	}
+
 	row := &Row{
-		Index: len(ws.Rows) + 1,
+		Index: index,
 		Cells: make([]*Cell, 0),
 		Style: NewCellStyle(),
 	}
-	ws.Rows = append(ws.Rows, row)
+	ws.Rows = append(ws.Rows, nil)
+	copy(ws.Rows[i+1:], ws.Rows[i:])
+	ws.Rows[i] = row
 	return row
 }
 
+// cellIndex 返回列索引为col的单元格在Cells中的位置，以及该单元格是否存在
+func (r *Row) cellIndex(col int) (int, bool) {
+	n := len(r.Cells)
+	if n == 0 || r.Cells[n-1].Col < col {
+		return n, false
+	}
+	i := sort.Search(n, func(i int) bool { return r.Cells[i].Col >= col })
+	return i, r.Cells[i].Col == col
+}
+
+// GetCell 返回列索引为col的单元格，不存在时返回nil
+func (r *Row) GetCell(col int) *Cell {
+	if i, ok := r.cellIndex(col); ok {
+		return r.Cells[i]
+	}
+	return nil
+}
+
+// setCell 将单元格放到列索引为col的位置，替换已有的单元格
+func (r *Row) setCell(col int, cell *Cell) {
+	cell.Col = col
+	i, ok := r.cellIndex(col)
+	if ok {
+		r.Cells[i] = cell
+		return
+	}
+	r.Cells = append(r.Cells, nil)
+	copy(r.Cells[i+1:], r.Cells[i:])
+	r.Cells[i] = cell
+}
+
 // AddColumn 添加一个新的列
 func (ws *Worksheet) AddColumn(min, max int, width float64) *Column {
 	col := &Column{
@@ -115,25 +190,74 @@ func (ws *Worksheet) AddColumn(min, max int, width float64) *Column {
 	return col
 }
 
-// AddCell 在指定位置添加一个单元格
+// AddCell 在指定位置添加一个单元格，替换该位置已有的单元格
+// cellRef为A1格式的引用，无效的引用不会写入工作表，并由Validate报告
 func (ws *Worksheet) AddCell(cellRef string, value interface{}) *Cell {
+	row, col, err := ParseCellRef(cellRef)
+	if err != nil || !isValidCellPos(row, col) {
+		ws.invalidRefs = append(ws.invalidRefs, cellRef)
+		cell := NewCell()
+		cell.setValue(value)
+		return cell
+	}
+	return ws.SetCellValue(row, col, value)
+}
+
+// SetCellValue 在第row行、第col列（均从0开始）添加一个单元格，替换该位置已有的单元格
+// 位置超出工作表范围时不添加单元格，只记录错误由Validate报告，返回的单元格不属于工作表
+func (ws *Worksheet) SetCellValue(row, col int, value interface{}) *Cell {
 	cell := NewCell()
+	cell.setValue(value)
+	if !isValidCellPos(row, col) {
+		ws.invalidRefs = append(ws.invalidRefs, fmt.Sprintf("R%dC%d", row+1, col+1))
+		return cell
+	}
+	ws.row(row+1, true).setCell(col, cell)
+	return cell
+}
+
+// GetCell 返回第row行、第col列（均从0开始）的单元格，不存在或超出工作表范围时返回nil
+func (ws *Worksheet) GetCell(row, col int) *Cell {
+	if !isValidCellPos(row, col) {
+		return nil
+	}
+	if r := ws.row(row+1, false); r != nil {
+		return r.GetCell(col)
+	}
+	return nil
+}
+
+// isValidCellPos 判断从0开始的行列索引是否在工作表范围内
+func isValidCellPos(row, col int) bool {
+	return row >= 0 && row < MaxRows && col >= 0 && col < MaxColumns
+}
+
+// cell 返回cellRef处的单元格，不存在时添加一个空单元格
+func (ws *Worksheet) cell(cellRef string) *Cell {
+	if row, col, err := ParseCellRef(cellRef); err == nil {
+		if cell := ws.GetCell(row, col); cell != nil {
+			return cell
+		}
+	}
+	return ws.AddCell(cellRef, "")
+}
 
-	// 根据值类型设置数据类型
+// setValue 设置单元格的值，并根据值类型设置数据类型
+func (c *Cell) setValue(value interface{}) {
 	switch v := value.(type) {
 	case string:
-		cell.DataType = "s"
-		cell.Value = value
+		c.DataType = "s"
+		c.Value = value
 	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
-		cell.DataType = "n"
-		cell.Value = value
+		c.DataType = "n"
+		c.Value = value
 	case bool:
-		cell.DataType = "b"
-		cell.Value = value
+		c.DataType = "b"
+		c.Value = value
 	case time.Time:
 		// 日期和时间在Excel中表示为序列号
 		// 数字部分代表天数，小数部分代表一天中的时间
-		cell.DataType = "n"
+		c.DataType = "n"
 
 		// 使用正确的Excel日期转换函数
 		// GetExcelSerialDate已经处理了日期转换的细节，包括1900年2月29日的Excel错误
@@ -145,22 +269,16 @@ func (ws *Worksheet) AddCell(cellRef string, value interface{}) *Cell {
 		seconds := float64(v.Second()) / (24.0 * 60.0 * 60.0)
 
 		// 组合日期和时间部分
-		cell.Value = serialDate + hours + minutes + seconds
+		c.Value = serialDate + hours + minutes + seconds
 	default:
-		cell.DataType = "s"
-		cell.Value = fmt.Sprintf("%v", value)
+		c.DataType = "s"
+		c.Value = fmt.Sprintf("%v", value)
 	}
-
-	ws.Cells[cellRef] = cell
-	return cell
 }
 
 // SetCellFormula 设置单元格公式
 func (ws *Worksheet) SetCellFormula(cellRef string, formula string) *Cell {
-	cell, ok := ws.Cells[cellRef]
-	if !ok {
-		cell = ws.AddCell(cellRef, "")
-	}
+	cell := ws.cell(cellRef)
 	cell.Formula = formula
 
 	// 公式单元格的初始值设为空，让Excel自动计算
@@ -172,23 +290,39 @@ func (ws *Worksheet) SetCellFormula(cellRef string, formula string) *Cell {
 	return cell
 }
 
-// SetCellStyle 设置单元格样式
+// SetCellStyle 将旧版样式注册为单元格XF并设置为单元格的样式，相同的CellStyle总是使用同一个XF
+// 工作表不属于任何工作簿时无法注册样式，单元格保持原来的样式
 func (ws *Worksheet) SetCellStyle(cellRef string, style *CellStyle) *Cell {
-	cell, ok := ws.Cells[cellRef]
-	if !ok {
-		cell = ws.AddCell(cellRef, "")
+	cell := ws.cell(cellRef)
+	if ws.wb != nil {
+		cell.StyleID = ws.wb.Styles.AddDirectStyleID(style)
 	}
+	return cell
+}
 
-	// 直接设置样式，确保样式引用正确
-	cell.Style = style
+// SetCellStyleID 设置单元格的样式，styleID为Styles.NewStyle返回的XF索引
+func (ws *Worksheet) SetCellStyleID(cellRef string, styleID int) *Cell {
+	cell := ws.cell(cellRef)
+	cell.StyleID = styleID
 	return cell
 }
 
-// ToXML 将工作表转换为XML
+// ToXML 将工作表转换为XML，绘图、批注和表格的关系ID与保存时分配的相同
 func (ws *Worksheet) ToXML(sharedStrings *SharedStrings) string {
+	state := newSaveState()
+	ws.sheetRels(state, 1, 1, 1)
+	return ws.toXML(sharedStrings, state)
+}
+
+// toXML 使用state中的关系ID生成工作表XML
+func (ws *Worksheet) toXML(sharedStrings *SharedStrings, state *saveState) string {
 	xml := "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n"
 	xml += "<worksheet xmlns=\"http://schemas.openxmlformats.org/spreadsheetml/2006/main\" xmlns:r=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships\">\n"
 
+	// 工作表属性和视图
+	xml += ws.sheetPrXML()
+	xml += ws.sheetViewsXML()
+
 	// 列定义
 	if len(ws.Columns) > 0 {
 		xml += "  <cols>\n"
@@ -202,209 +336,163 @@ func (ws *Worksheet) ToXML(sharedStrings *SharedStrings) string {
 		xml += "  </cols>\n"
 	}
 
-	// 单元格数据
-	xml += "  <sheetData>\n"
+	// 单元格数据，行和单元格已按顺序存储，直接依次输出
+	data := ws.sheetDataXML(sharedStrings)
+	xml += data
 
-	// 按行组织单元格
-	rowMap := make(map[int]map[string]*Cell)
-	for cellRef, cell := range ws.Cells {
-		rowIndex, colIndex, err := ParseCellRef(cellRef)
-		if err != nil {
-			continue
-		}
+	// 确定性输出时保护密码的盐值由单元格数据和密码生成
+	if p := ws.Protection; p != nil {
+		xml += ws.sheetProtectionXML(ws.wb.savedPasswordHash(p.Password, p.password, []byte(data)))
+	}
+	xml += ws.sheetMetadataXML(state)
 
-		// ParseCellRef返回的是从0开始的索引，而Excel行索引从1开始
-		// 将行索引加1，使其与row.Index匹配
-		rowNum := rowIndex + 1
+	// 绘图部件，包含图表和图片
+	if id := state.drawings[ws]; id != "" {
+		xml += "  <drawing r:id=\"" + id + "\" />\n"
+	}
+	if id := state.legacyDrawings[ws]; id != "" {
+		xml += "  <legacyDrawing r:id=\"" + id + "\" />\n"
+	}
 
-		if _, ok := rowMap[rowNum]; !ok {
-			rowMap[rowNum] = make(map[string]*Cell)
-		}
+	// 表格
+	xml += ws.tablePartsXML(state)
 
-		// 确保cellRef格式正确，重新生成标准格式的单元格引用
-		standardCellRef := CellRef(rowIndex, colIndex)
-		rowMap[rowNum][standardCellRef] = cell
-	}
+	xml += "</worksheet>"
+	return xml
+}
 
-	// 收集所有行索引
-	rowIndices := make([]int, 0)
+// sheetMetadataXML 生成sheetProtection之后、绘图部件之前的元素，流式写入的工作表也使用它结束工作表
+func (ws *Worksheet) sheetMetadataXML(state *saveState) string {
+	var xml string
 
-	// 添加从单元格收集的行
-	for rowIdx := range rowMap {
-		rowIndices = append(rowIndices, rowIdx)
+	// 自动筛选
+	if ws.AutoFilter != nil {
+		xml += "  " + ws.AutoFilter.toXML() + "\n"
 	}
 
-	// 添加显式定义的行
-	for _, row := range ws.Rows {
-		found := false
-		for _, idx := range rowIndices {
-			if idx == row.Index {
-				found = true
-				break
-			}
-		}
-		if !found {
-			rowIndices = append(rowIndices, row.Index)
+	// 合并单元格
+	if len(ws.MergedCells) > 0 {
+		xml += "  <mergeCells count=\"" + fmt.Sprintf("%d", len(ws.MergedCells)) + "\">\n"
+		for _, mergedCell := range ws.MergedCells {
+			xml += "    <mergeCell ref=\"" + mergedCell.TopLeftRef + ":" + mergedCell.BottomRightRef + "\" />\n"
 		}
+		xml += "  </mergeCells>\n"
 	}
 
-	// 按行号排序
-	for i := 0; i < len(rowIndices)-1; i++ {
-		for j := i + 1; j < len(rowIndices); j++ {
-			if rowIndices[i] > rowIndices[j] {
-				rowIndices[i], rowIndices[j] = rowIndices[j], rowIndices[i]
-			}
-		}
-	}
+	// 条件格式、数据验证、超链接和页面设置
+	xml += ws.conditionalFormattingXML()
+	xml += ws.dataValidationsXML()
+	xml += ws.hyperlinksXML(state)
+	xml += ws.pageSetupXML()
+	return xml
+}
 
-	// 输出行和单元格
-	for _, rowIdx := range rowIndices {
-		// 查找是否有显式添加的行
-		var rowHeight float64
-		var rowHidden bool
-
-		for _, r := range ws.Rows {
-			if r.Index == rowIdx {
-				rowHeight = r.Height
-				rowHidden = r.Hidden
-				break
-			}
+// sheetDataXML 生成sheetData元素，耗时与单元格数量成正比
+func (ws *Worksheet) sheetDataXML(sharedStrings *SharedStrings) string {
+	var b strings.Builder
+	b.WriteString("  <sheetData>\n")
+	for _, row := range ws.Rows {
+		fmt.Fprintf(&b, "    <row r=\"%d\"", row.Index)
+		if row.Height > 0 {
+			fmt.Fprintf(&b, " ht=\"%f\" customHeight=\"1\"", row.Height)
 		}
-
-		xml += fmt.Sprintf("    <row r=\"%d\"", rowIdx)
-
-		if rowHeight > 0 {
-			xml += fmt.Sprintf(" ht=\"%f\" customHeight=\"1\"", rowHeight)
+		if row.Hidden {
+			b.WriteString(" hidden=\"1\"")
 		}
+		b.WriteString(">\n")
 
-		if rowHidden {
-			xml += " hidden=\"1\""
+		for _, cell := range row.Cells {
+			b.WriteString(cell.toXML(CellRef(row.Index-1, cell.Col), sharedStrings))
 		}
+		b.WriteString("    </row>\n")
+	}
+	b.WriteString("  </sheetData>\n")
+	return b.String()
+}
 
-		xml += ">\n"
+// toXML 将单元格转换为XML，字符串值加入共享字符串表
+func (cell *Cell) toXML(cellRef string, sharedStrings *SharedStrings) string {
+	xml := fmt.Sprintf("      <c r=\"%s\"", cellRef)
 
-		// 输出该行的单元格
-		if cells, ok := rowMap[rowIdx]; ok {
-			// 对单元格按列排序
-			cellRefs := make([]string, 0, len(cells))
-			for cellRef := range cells {
-				cellRefs = append(cellRefs, cellRef)
-			}
+	if cell.StyleID > 0 {
+		xml += fmt.Sprintf(" s=\"%d\"", cell.StyleID)
+	}
 
-			// 简单排序，确保单元格按列顺序输出
-			for i := 0; i < len(cellRefs)-1; i++ {
-				for j := i + 1; j < len(cellRefs); j++ {
-					_, col1, _ := ParseCellRef(cellRefs[i])
-					_, col2, _ := ParseCellRef(cellRefs[j])
-					if col1 > col2 {
-						cellRefs[i], cellRefs[j] = cellRefs[j], cellRefs[i]
-					}
-				}
+	// 设置数据类型，公式单元格的类型由缓存的计算结果决定
+	if cell.Formula != "" {
+		switch v := cell.Value.(type) {
+		case string:
+			if v != "" {
+				xml += " t=\"str\""
 			}
+		case bool:
+			xml += " t=\"b\""
+		case FormulaError:
+			xml += " t=\"e\""
+		}
+	} else if cell.DataType != "" {
+		// 确保数据类型是有效的Excel类型
+		switch cell.DataType {
+		case "s":
+			xml += " t=\"s\"" // 字符串类型
+		case "b":
+			xml += " t=\"b\"" // 布尔类型
+		case "n":
+			// 数字类型不需要特殊的t属性
+		default:
+			xml += " t=\"s\""
+		}
+	}
 
-			for _, cellRef := range cellRefs {
-				cell := cells[cellRef]
-
-				// 使用标准化的单元格引用
-				xml += fmt.Sprintf("      <c r=\"%s\"", cellRef)
-
-				// 正确处理样式ID引用
-				if cell.Style != nil {
-					// 从样式中获取样式ID
-					styleID := 0
-
-					// 首先尝试使用NumberFormatID，这是格式化日期/数字等的关键
-					if cell.Style.NumberFormatID > 0 {
-						styleID = cell.Style.NumberFormatID
-					} else {
-						// 如果没有NumberFormatID，则按优先级使用其他样式ID
-						if cell.Style.FontID > 0 {
-							styleID = cell.Style.FontID
-						} else if cell.Style.FillID > 0 {
-							styleID = cell.Style.FillID
-						} else if cell.Style.BorderID > 0 {
-							styleID = cell.Style.BorderID
-						}
-					}
-
-					if styleID > 0 {
-						xml += fmt.Sprintf(" s=\"%d\"", styleID)
-					}
-				}
-
-				// 设置数据类型
-				if cell.DataType != "" {
-					// 确保数据类型是有效的Excel类型
-					switch cell.DataType {
-					case "s":
-						xml += " t=\"s\"" // 字符串类型
-					case "b":
-						xml += " t=\"b\"" // 布尔类型
-					case "n":
-						// 数字类型不需要特殊的t属性
-					default:
-						xml += " t=\"s\""
-					}
-				}
+	xml += ">"
 
-				xml += ">"
-
-				// 添加公式
-				if cell.Formula != "" {
-					xml += fmt.Sprintf("<f>%s</f>", cell.Formula)
-
-					// 公式单元格不应该标记为字符串类型，除非明确需要
-					// 让Excel自动根据公式计算结果决定单元格类型
-					if cell.Value != nil {
-						xml += fmt.Sprintf("<v>%v</v>", cell.Value)
-					}
-				} else if cell.Value != nil {
-					// 添加值（仅当没有公式时）
-					switch cell.DataType {
-					case "s": // 字符串
-						strValue, ok := cell.Value.(string)
-						if ok {
-							index := sharedStrings.AddString(strValue)
-							xml += fmt.Sprintf("<v>%d</v>", index)
-						}
-					case "n": // 数字 (包括日期,日期仅是有特殊格式的数字)
-						xml += fmt.Sprintf("<v>%v</v>", cell.Value)
-					case "b": // 布尔值
-						boolValue, ok := cell.Value.(bool)
-						if ok {
-							if boolValue {
-								xml += "<v>1</v>"
-							} else {
-								xml += "<v>0</v>"
-							}
-						}
-					default:
-						// 默认作为字符串处理
-						strValue := fmt.Sprintf("%v", cell.Value)
-						index := sharedStrings.AddString(strValue)
-						xml += fmt.Sprintf("<v>%d</v>", index)
-					}
-				}
+	// 添加公式
+	if cell.Formula != "" {
+		xml += "<f>" + escapeXML(storedFormula(cell.Formula)) + "</f>"
 
-				xml += "</c>\n"
+		// 写入Recalculate计算的缓存值，没有缓存值时由Excel打开时计算
+		switch v := cell.Value.(type) {
+		case nil:
+		case string:
+			if v != "" {
+				xml += "<v>" + escapeXML(v) + "</v>"
 			}
+		case bool:
+			xml += fmt.Sprintf("<v>%d</v>", boolToInt(v))
+		case float64:
+			xml += "<v>" + formatNumber(v) + "</v>"
+		default:
+			xml += fmt.Sprintf("<v>%v</v>", v)
 		}
-
-		xml += "    </row>\n"
-	}
-
-	xml += "  </sheetData>\n"
-
-	// 合并单元格
-	if len(ws.MergedCells) > 0 {
-		xml += "  <mergeCells count=\"" + fmt.Sprintf("%d", len(ws.MergedCells)) + "\">\n"
-		for _, mergedCell := range ws.MergedCells {
-			xml += "    <mergeCell ref=\"" + mergedCell.TopLeftRef + ":" + mergedCell.BottomRightRef + "\" />\n"
+	} else if cell.Value != nil {
+		// 添加值（仅当没有公式时）
+		switch cell.DataType {
+		case "s": // 字符串
+			strValue, ok := cell.Value.(string)
+			if ok {
+				index := sharedStrings.AddString(strValue)
+				xml += fmt.Sprintf("<v>%d</v>", index)
+			}
+		case "n": // 数字 (包括日期,日期仅是有特殊格式的数字)
+			xml += fmt.Sprintf("<v>%v</v>", cell.Value)
+		case "b": // 布尔值
+			boolValue, ok := cell.Value.(bool)
+			if ok {
+				if boolValue {
+					xml += "<v>1</v>"
+				} else {
+					xml += "<v>0</v>"
+				}
+			}
+		default:
+			// 默认作为字符串处理
+			strValue := fmt.Sprintf("%v", cell.Value)
+			index := sharedStrings.AddString(strValue)
+			xml += fmt.Sprintf("<v>%d</v>", index)
 		}
-		xml += "  </mergeCells>\n"
 	}
 
-	xml += "</worksheet>"
+	xml += "</c>\n"
 	return xml
 }
 